@@ -12,6 +12,9 @@ require (
 	github.com/mileusna/useragent v1.3.5
 	github.com/pion/mediadevices v0.9.2
 	github.com/torlangballe/zutil v0.0.0-20260130083009-95bbfb17ef1e
+	golang.org/x/term v0.39.0
+	golang.org/x/tools v0.41.0
+	modernc.org/sqlite v1.44.3
 )
 
 require (
@@ -69,11 +72,9 @@ require (
 	golang.org/x/image v0.35.0 // indirect
 	golang.org/x/net v0.49.0 // indirect
 	golang.org/x/sys v0.40.0 // indirect
-	golang.org/x/term v0.39.0 // indirect
 	golang.org/x/text v0.33.0 // indirect
 	golang.org/x/time v0.14.0 // indirect
 	modernc.org/libc v1.67.7 // indirect
 	modernc.org/mathutil v1.7.1 // indirect
 	modernc.org/memory v1.11.0 // indirect
-	modernc.org/sqlite v1.44.3 // indirect
 )
@@ -24,6 +24,12 @@ type App struct {
 	handler        AppHandler // settable interface that handle lots of app-specific callbacks
 }
 
+// DocSearchArgs are the arguments of the AppCalls.SearchDocumentation rpc call.
+type DocSearchArgs struct {
+	Query string
+	Max   int // Max is how many results to return at most, all if 0
+}
+
 type TimeInfo struct {
 	ZoneName          string
 	ZoneOffsetSeconds int
@@ -101,6 +101,9 @@ func (r filesRedirector) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 			return
 		}
 	}
+	if serveHTMLFormPage(w, req, spath) {
+		return
+	}
 	if strings.HasSuffix(spath, ".md") {
 		m := MakeMarkdownConverter()
 		m.ServeAsHTML(w, req, "www/"+spath)
@@ -206,6 +209,7 @@ func ServeZUIWasm(router *mux.Router, serveDirs bool, override func(w http.Respo
 		Override: override,
 	}
 	zrest.AddSubHandler(router, "", RequestRedirector)
+	go indexDocumentationOnce()
 
 	// zlog.Info("HandleApp:", zrest.AppURLPrefix)
 	//	route := router.PathPrefix(zrest.AppURLPrefix)
@@ -5,6 +5,7 @@ package zapp
 import (
 	"time"
 
+	"github.com/torlangballe/zui/zdocs"
 	"github.com/torlangballe/zui/zlabel"
 	"github.com/torlangballe/zui/zstyle"
 	"github.com/torlangballe/zui/zwindow"
@@ -116,3 +117,12 @@ func toggleTimeZoneMode(label *zlabel.Label) {
 	zlocale.IsDisplayServerTime.Set(!zlocale.IsDisplayServerTime.Get(), false)
 	zwindow.GetMain().Reload()
 }
+
+// SearchServerDocumentation searches all the documentation on the server, including files the GUI hasn't loaded.
+// The results can be merged with zdocs.MainIndex.Search's by their Score.
+func SearchServerDocumentation(query string, maxResults int) ([]zdocs.SearchResult, error) {
+	var results []zdocs.SearchResult
+	args := DocSearchArgs{Query: query, Max: maxResults}
+	err := zrpc.MainClient.Call("AppCalls.SearchDocumentation", args, &results)
+	return results, err
+}
@@ -0,0 +1,156 @@
+//go:build !js && !catalyst && server
+
+package zapp
+
+import (
+	"encoding/json"
+	"io/fs"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gorilla/mux"
+	"github.com/torlangballe/zui/zdocs"
+	"github.com/torlangballe/zutil/zlog"
+	"github.com/torlangballe/zutil/zrest"
+	"github.com/torlangballe/zutil/zstr"
+)
+
+var (
+	docIndex     *zdocs.Index
+	docIndexLock sync.Mutex
+	docIndexOnce sync.Once // docIndexOnce makes the first index, which searches wait for
+)
+
+// IndexDocumentation indexes all markdown files under www/doc in AllWebFS for SearchDocumentation.
+// Each paragraph is an item, with the file and the headings it is under as its path.
+// It is started at startup by ServeZUIWasm, call it again if files are added to AllWebFS after that.
+func IndexDocumentation() {
+	ix := zdocs.NewIndex()
+	seen := map[string]bool{}
+	for _, f := range AllWebFS {
+		fs.WalkDir(f.FS, "www/doc", func(fpath string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || path.Ext(fpath) != ".md" || seen[fpath] {
+				return nil // embeded or disk filesystems may not have a doc folder
+			}
+			seen[fpath] = true // filesystems first in AllWebFS override later ones
+			data, err := fs.ReadFile(f.FS, fpath)
+			if zlog.OnError(err, fpath, f.FSName) {
+				return nil
+			}
+			ix.Add(markdownSearchableItems(strings.TrimPrefix(fpath, "www/"), string(data))...)
+			return nil
+		})
+	}
+	zlog.Info("IndexDocumentation:", len(seen), "files", ix.Count(), "paragraphs")
+	docIndexLock.Lock()
+	docIndex = ix
+	docIndexLock.Unlock()
+}
+
+// indexDocumentationOnce indexes the documentation if it hasn't been, or waits for it if it is being indexed.
+func indexDocumentationOnce() {
+	docIndexOnce.Do(IndexDocumentation)
+}
+
+// markdownSearchableItems makes an item of each paragraph of the markdown text of docPath.
+// Headings aren't items, but parts of the path of the paragraphs under them, with their anchor as stub.
+func markdownSearchableItems(docPath, text string) []zdocs.SearchableItem {
+	var items []zdocs.SearchableItem
+	var headings []zdocs.PathPart
+	var levels []int
+	var lines []string
+	var inCode bool
+	filePath := zdocs.AddedPath(nil, zdocs.StaticField, "Docs", "Docs")
+	filePath = zdocs.AddedPath(filePath, zdocs.InlineDocumentation, strings.TrimSuffix(path.Base(docPath), ".md"), docPath)
+	flush := func() {
+		para := strings.TrimSpace(strings.Join(lines, "\n"))
+		lines = lines[:0]
+		if para == "" {
+			return
+		}
+		p := append(append([]zdocs.PathPart{}, filePath...), headings...)
+		items = append(items, zdocs.MakeSearchableItem(p, zdocs.InlineDocumentation, "", "", para))
+	}
+	zstr.RangeStringLines(text, false, func(line string) bool {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			lines = append(lines, line)
+			return true
+		}
+		if inCode {
+			lines = append(lines, line)
+			return true
+		}
+		if strings.TrimSpace(line) == "" {
+			flush()
+			return true
+		}
+		var title string
+		pre := zstr.HeadUntilWithRest(line, " ", &title)
+		level := strings.Count(pre, "#")
+		if level == 0 || level != len(pre) { // not a heading of all # to start
+			lines = append(lines, line)
+			return true
+		}
+		flush()
+		for len(levels) != 0 && levels[len(levels)-1] >= level {
+			levels = levels[:len(levels)-1]
+			headings = headings[:len(headings)-1]
+		}
+		title = strings.TrimSpace(title)
+		levels = append(levels, level)
+		headings = append(headings, zdocs.PathPart{Type: zdocs.InlineDocumentation, PartName: title, PathStub: "#" + headingAnchor(title)})
+		return true
+	})
+	flush()
+	return items
+}
+
+// headingAnchor makes an anchor for a heading the way markdown converters do; lowercase, spaces as dashes, other punctuation removed.
+func headingAnchor(title string) string {
+	var anchor []rune
+	for _, r := range strings.ToLower(title) {
+		switch {
+		case unicode.IsLetter(r), unicode.IsDigit(r), r == '-', r == '_':
+			anchor = append(anchor, r)
+		case r == ' ':
+			anchor = append(anchor, '-')
+		}
+	}
+	return string(anchor)
+}
+
+// SearchDocumentation returns the documentation paragraphs best matching query, at most maxResults of them if it isn't 0.
+// If the documentation hasn't been indexed yet, it waits until it is.
+func SearchDocumentation(query string, maxResults int) []zdocs.SearchResult {
+	indexDocumentationOnce()
+	docIndexLock.Lock()
+	ix := docIndex
+	docIndexLock.Unlock()
+	return ix.Search(query, maxResults)
+}
+
+// SearchDocumentation is the rpc call for the GUI to search the documentation on the server, see SearchDocumentation.
+func (AppCalls) SearchDocumentation(args DocSearchArgs, results *[]zdocs.SearchResult) error {
+	*results = SearchDocumentation(args.Query, args.Max)
+	return nil
+}
+
+// handleDocSearch returns SearchDocumentation results as JSON for the q parameter, and max if set.
+func handleDocSearch(w http.ResponseWriter, req *http.Request) {
+	vals := req.URL.Query()
+	maxResults, _ := strconv.Atoi(vals.Get("max"))
+	results := SearchDocumentation(vals.Get("q"), maxResults)
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(results)
+	zlog.OnError(err, vals.Get("q"))
+}
+
+// SetDocumentationSearchHandler adds a docsearch?q=<query>&max=<count> GET handler to router, for searching outside the GUI.
+func SetDocumentationSearchHandler(router *mux.Router) {
+	zrest.AddHandler(router, "docsearch", handleDocSearch).Methods("GET")
+}
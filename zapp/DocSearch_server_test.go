@@ -0,0 +1,101 @@
+//go:build !js && !catalyst && server
+
+package zapp
+
+import (
+	"testing"
+
+	"github.com/torlangballe/zui/zdocs"
+)
+
+func TestHeadingAnchor(t *testing.T) {
+	tests := map[string]string{
+		"Getting Started":         "getting-started",
+		"What's new in v2.1?":     "whats-new-in-v21",
+		"snake_case and-dashes":   "snake_case-and-dashes",
+		"Ærlig talt: Øl & Åpning": "ærlig-talt-øl--åpning",
+		"":                        "",
+	}
+	for title, want := range tests {
+		if got := headingAnchor(title); got != want {
+			t.Errorf("headingAnchor(%q) = %q, want %q", title, got, want)
+		}
+	}
+}
+
+const testMarkdown = `Intro paragraph.
+
+# Setup
+First setup paragraph,
+continued.
+
+## Network
+Set the timeout.
+
+` + "```" + `
+# not a heading
+
+code
+` + "```" + `
+### Deep
+Deep text.
+## Users
+Add users.
+#hashtag isn't a heading
+# Other
+Last.
+`
+
+func TestMarkdownSearchableItems(t *testing.T) {
+	items := markdownSearchableItems("doc/guide.md", testMarkdown)
+	want := []struct {
+		text     string
+		headings []string
+	}{
+		{"Intro paragraph.", nil},
+		{"First setup paragraph,\ncontinued.", []string{"#setup"}},
+		{"Set the timeout.", []string{"#setup", "#network"}},
+		{"```\n# not a heading\n\ncode\n```", []string{"#setup", "#network"}},
+		{"Deep text.", []string{"#setup", "#network", "#deep"}},
+		{"Add users.\n#hashtag isn't a heading", []string{"#setup", "#users"}},
+		{"Last.", []string{"#other"}},
+	}
+	if len(items) != len(want) {
+		for _, item := range items {
+			t.Logf("%q %s", item.Text, zdocs.PathSimpleString(item.DocLink.Path))
+		}
+		t.Fatalf("got %d items, want %d", len(items), len(want))
+	}
+	for i, w := range want {
+		item := items[i]
+		if item.Text != w.text {
+			t.Errorf("item %d text: %q, want %q", i, item.Text, w.text)
+		}
+		path := item.DocLink.Path
+		if len(path) != 2+len(w.headings) {
+			t.Errorf("item %d path: %+v", i, path)
+			continue
+		}
+		if path[0].PartName != "Docs" || path[1].PartName != "guide" || path[1].PathStub != "doc/guide.md" || path[1].Type != zdocs.InlineDocumentation {
+			t.Errorf("item %d file path: %+v", i, path[:2])
+		}
+		for j, stub := range w.headings {
+			if path[2+j].PathStub != stub || path[2+j].Type != zdocs.InlineDocumentation {
+				t.Errorf("item %d heading %d: %+v, want %s", i, j, path[2+j], stub)
+			}
+		}
+	}
+}
+
+func TestMarkdownSearch(t *testing.T) {
+	ix := zdocs.NewIndex()
+	ix.Add(markdownSearchableItems("doc/guide.md", testMarkdown)...)
+	results := ix.Search("timeout", 0)
+	if len(results) != 1 || results[0].Snippet.Match != "timeout" {
+		t.Fatal("search for timeout:", results)
+	}
+	path := results[0].SearchableItem.DocLink.Path
+	if path[len(path)-1].PartName != "Network" {
+		t.Error("result not under its heading:", zdocs.PathSimpleString(path))
+	}
+}
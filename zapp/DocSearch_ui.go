@@ -0,0 +1,106 @@
+//go:build zui && !catalyst
+
+package zapp
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/torlangballe/zui/zdocs"
+	"github.com/torlangballe/zui/zkeyboard"
+	"github.com/torlangballe/zui/zmenu"
+	"github.com/torlangballe/zui/ztext"
+	"github.com/torlangballe/zui/zview"
+	"github.com/torlangballe/zui/zwidgets"
+	"github.com/torlangballe/zutil/zlog"
+)
+
+// maxDocSearchResults is how many results a documentation search field shows.
+const maxDocSearchResults = 20
+
+// NewDocumentationSearchField returns a search field for the GUI under root, which it indexes in zdocs.MainIndex with zdocs.IndexView,
+// and the documentation on the server, see SearchServerDocumentation.
+// Pressing return searches both and pops up the best results in a menu below it.
+// Choosing a result opens it; GUI ones with zdocs.PartOpener, documentation files in a DocumentationView.
+func NewDocumentationSearchField(root zview.View, rootPath []zdocs.PathPart) *ztext.SearchField {
+	zdocs.IndexView(root, rootPath)
+	search := ztext.SearchFieldNew(ztext.Style{}, 16)
+	search.SetObjectName("docsearch")
+	menu := zmenu.NewMenuedOwner()
+	search.TextView.SetKeyHandler(func(km zkeyboard.KeyMod, down bool) bool {
+		if !down || !km.Key.IsReturnish() || km.Modifier != zkeyboard.ModifierNone {
+			return false
+		}
+		query := search.Text()
+		results := zdocs.MainIndex.Search(query, maxDocSearchResults)
+		go func() {
+			server, err := SearchServerDocumentation(query, maxDocSearchResults)
+			zlog.OnError(err, query)
+			results = mergeDocSearchResults(results, server, maxDocSearchResults)
+			popDocSearchResults(search, menu, results)
+		}()
+		return true
+	})
+	return search
+}
+
+// mergeDocSearchResults merges results from different indexes by score, at most maxResults of them.
+// Documentation the GUI has loaded is on the server too, so results with the same text as a better one are skipped.
+func mergeDocSearchResults(a, b []zdocs.SearchResult, maxResults int) []zdocs.SearchResult {
+	all := append(append([]zdocs.SearchResult{}, a...), b...)
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].Score > all[j].Score
+	})
+	var merged []zdocs.SearchResult
+	texts := map[string]bool{}
+	for _, r := range all {
+		text := strings.TrimSpace(r.SearchableItem.Text)
+		if texts[text] {
+			continue
+		}
+		texts[text] = true
+		merged = append(merged, r)
+		if len(merged) == maxResults {
+			break
+		}
+	}
+	return merged
+}
+
+func popDocSearchResults(search *ztext.SearchField, menu *zmenu.MenuedOwner, results []zdocs.SearchResult) {
+	var items []zmenu.MenuedOItem
+	for _, r := range results {
+		path := r.SearchableItem.DocLink.Path
+		name := zdocs.PathSimpleString(path) + ": " + r.Snippet.Pre + r.Snippet.Match + r.Snippet.Post
+		items = append(items, zmenu.MenuedFuncAction(name, func() {
+			openDocSearchResult(path)
+		}))
+	}
+	if len(items) == 0 {
+		items = append(items, zmenu.MenuedOItem{Name: "No matches", IsDisabled: true})
+	}
+	menu.MinWidth = search.Rect().Size.W
+	menu.PopInPos(search.AbsoluteRect().BottomLeft(), items)
+}
+
+// openDocSearchResult shows the documentation file path is in, at its last heading, or opens the GUI at path.
+func openDocSearchResult(path []zdocs.PathPart) {
+	var docPath, anchor string
+	for _, p := range path {
+		if p.Type != zdocs.InlineDocumentation {
+			continue
+		}
+		if strings.HasPrefix(p.PathStub, "#") {
+			anchor = p.PathStub
+		} else if strings.HasSuffix(p.PathStub, ".md") {
+			docPath = strings.TrimPrefix(p.PathStub, zwidgets.DocumentationPathPrefix)
+		}
+	}
+	if docPath != "" {
+		zwidgets.DocumentationViewPresent(docPath+anchor, false)
+		return
+	}
+	if zdocs.PartOpener != nil {
+		zdocs.PartOpener.OpenGUIFromPathParts(path)
+	}
+}
@@ -0,0 +1,159 @@
+//go:build !js && !catalyst && server
+
+package zapp
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"html"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/torlangballe/zui/zfields"
+	"github.com/torlangballe/zutil/zlog"
+)
+
+// HTMLFormPage is a page with a struct as a plain HTML form, for clients that can't or won't load the wasm gui.
+// See AddHTMLFormPage.
+type HTMLFormPage[S any] struct {
+	Title  string
+	Params zfields.FieldParameters
+	Get    func(req *http.Request) (S, error) // Get returns the struct to show. It should be a copy, as it is changed by posted values even if invalid
+	Set    func(req *http.Request, s S) error // Set is called with the posted struct when it is valid. If Set is nil, the form is read-only
+}
+
+var (
+	htmlFormPages     = map[string]http.HandlerFunc{}
+	htmlFormPagesLock sync.Mutex
+)
+
+// htmlFormCSRFCookie is the cookie with the token a posted form must have in its zfields.HTMLCSRFTokenName input.
+// Another site can make a browser post to a page, with its cookies, but can't read the cookie to put it in the form.
+const htmlFormCSRFCookie = "zformcsrf"
+
+const htmlFormStyle = `body { font-family: sans-serif; margin: 20px; }
+.zfield { margin: 6px 0; }
+.zfield label { display: inline-block; min-width: 160px; }
+.zdesc { color: gray; font-size: small; }
+.zerror { color: red; }
+.zmessage { color: green; margin-bottom: 10px; }
+fieldset { margin: 10px 0; }
+th { text-align: left; }`
+
+// AddHTMLFormPage makes the files redirector of ServeZUIWasm serve page at spath, relative to zrest.AppURLPrefix.
+// A GET shows the struct, a POST sets it from the form if it is valid, or shows it again with the errors.
+// Use ServeHTTP directly to add it to a router elsewhere.
+func AddHTMLFormPage[S any](spath string, page *HTMLFormPage[S]) {
+	htmlFormPagesLock.Lock()
+	htmlFormPages[strings.Trim(spath, "/")] = page.ServeHTTP
+	htmlFormPagesLock.Unlock()
+}
+
+// serveHTMLFormPage serves a page added with AddHTMLFormPage for spath, returning false if there is none.
+func serveHTMLFormPage(w http.ResponseWriter, req *http.Request, spath string) bool {
+	htmlFormPagesLock.Lock()
+	handler := htmlFormPages[strings.Trim(spath, "/")]
+	htmlFormPagesLock.Unlock()
+	if handler == nil {
+		return false
+	}
+	handler(w, req)
+	return true
+}
+
+func (p *HTMLFormPage[S]) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	s, err := p.Get(req)
+	if err != nil {
+		zlog.Error("get", p.Title, err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	form := zfields.HTMLForm{Params: p.Params}
+	form.Params.AllStatic = form.Params.AllStatic || p.Set == nil
+	form.CSRFToken = htmlFormCSRFToken(w, req)
+	status := http.StatusOK
+	switch req.Method {
+	case http.MethodGet:
+		if req.URL.Query().Get("saved") != "" {
+			form.Message = "Saved"
+		}
+	case http.MethodPost:
+		if p.Set == nil {
+			http.Error(w, "form is read-only", http.StatusMethodNotAllowed)
+			return
+		}
+		err = req.ParseForm()
+		if err == nil && !isHTMLFormPostFromSelf(req) {
+			zlog.Error("form post from elsewhere rejected", p.Title, req.RemoteAddr, req.Header.Get("Origin"), req.Referer())
+			http.Error(w, "form not posted from this site", http.StatusForbidden)
+			return
+		}
+		if err == nil {
+			err = zfields.ParseHTMLForm(&s, req.PostForm, form.Params)
+		}
+		if err == nil {
+			err = p.Set(req, s)
+		}
+		if err == nil {
+			// Redirecting after saving stops a reload from posting again.
+			u := *req.URL
+			u.RawQuery = "saved=1"
+			http.Redirect(w, req, u.String(), http.StatusSeeOther)
+			return
+		}
+		status = http.StatusBadRequest
+		verrs, is := err.(zfields.ValidationErrors)
+		if !is {
+			verrs = zfields.ValidationErrors{{Message: err.Error()}}
+		}
+		form.Errors = verrs
+	default:
+		http.Error(w, "unsupported method", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	title := html.EscapeString(p.Title)
+	_, err = fmt.Fprintf(w, "<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>%s</title>\n<style>\n%s\n</style></head>\n<body>\n<h1>%s</h1>\n%s</body></html>\n",
+		title, htmlFormStyle, title, form.HTML(&s))
+	zlog.OnError(err, p.Title)
+}
+
+// htmlFormCSRFToken returns the token from the htmlFormCSRFCookie cookie, setting a new one if there is none.
+func htmlFormCSRFToken(w http.ResponseWriter, req *http.Request) string {
+	cookie, err := req.Cookie(htmlFormCSRFCookie)
+	if err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	bytes := make([]byte, 16)
+	rand.Read(bytes)
+	token := hex.EncodeToString(bytes)
+	http.SetCookie(w, &http.Cookie{Name: htmlFormCSRFCookie, Value: token, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode})
+	return token
+}
+
+// isHTMLFormPostFromSelf checks that a parsed post has the CSRF token of its cookie,
+// and that its Origin or Referer, if sent, is this host.
+func isHTMLFormPostFromSelf(req *http.Request) bool {
+	cookie, err := req.Cookie(htmlFormCSRFCookie)
+	if err != nil || cookie.Value == "" {
+		return false
+	}
+	posted := req.PostForm.Get(zfields.HTMLCSRFTokenName)
+	if subtle.ConstantTimeCompare([]byte(posted), []byte(cookie.Value)) != 1 {
+		return false
+	}
+	from := req.Header.Get("Origin")
+	if from == "" || from == "null" {
+		from = req.Referer()
+	}
+	if from == "" {
+		return true
+	}
+	u, err := url.Parse(from)
+	return err == nil && u.Host == req.Host
+}
@@ -3,12 +3,18 @@
 package zbutton
 
 import (
-	"github.com/torlangballe/zui/zkeyboard"
 	"github.com/torlangballe/zutil/zgeo"
 )
 
 func New(text string) *Button {
-	return nil
+	v := &Button{}
+	v.MakeHeadless(v, "button")
+	v.SetText(text)
+	v.SetObjectName(text)
+	v.SetMargin(zgeo.RectFromXY2(12, 4, -12, -8))
+	f := zgeo.FontNice(zgeo.FontDefaultSize-2, zgeo.FontStyleNormal)
+	v.SetFont(f)
+	return v
 }
 
 func (v *Button) MakeReturnKeyDefault() {
@@ -18,4 +24,17 @@ func (v *Button) SetMargin(m zgeo.Rect) {
 	v.margin = m
 }
 
+// Margin returns what was set with SetMargin. It is used when rendering headless buttons.
+func (v *Button) Margin() zgeo.Rect {
+	return v.margin
+}
+
 func (v *Button) MakeEscapeCanceler() {}
+
+func (v *Button) Text() string {
+	return v.NativeView.InnerText()
+}
+
+func (v *Button) SetText(str string) {
+	v.NativeView.SetInnerText(str)
+}
@@ -286,3 +286,8 @@ func (c *Canvas) ZImage(ensureCopy bool, got func(image *zimage.Image)) {
 	}
 	zimage.FromGo(ni, got)
 }
+
+// DrawGoImage draws img with its top-left at pos, blending it with what is already on the canvas.
+func (c *Canvas) DrawGoImage(img image.Image, pos zgeo.Pos) {
+	c.context.DrawImage(img, int(pos.X), int(pos.Y))
+}
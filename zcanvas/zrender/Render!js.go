@@ -0,0 +1,236 @@
+//go:build !js && zui
+
+// Package zrender paints a headless zview tree into a gg-backed zcanvas.Canvas.
+// It is used to make images of screens built from containers, labels, buttons and custom views
+// without a browser, typically for golden-image snapshot tests.
+package zrender
+
+import (
+	"errors"
+	"image"
+	"os"
+	"sort"
+
+	"github.com/torlangballe/zui/zcanvas"
+	"github.com/torlangballe/zui/zimage"
+	"github.com/torlangballe/zui/ztextinfo"
+	"github.com/torlangballe/zui/zview"
+	"github.com/torlangballe/zutil/zgeo"
+	"github.com/torlangballe/zutil/zlog"
+)
+
+type selfDrawer interface {
+	DrawHandler() func(rect zgeo.Rect, canvas *zcanvas.Canvas, view zview.View)
+	ForceDrawSelf()
+	Canvas() *zcanvas.Canvas
+}
+
+type texter interface {
+	Text() string
+}
+
+type onOwner interface {
+	On() bool
+}
+
+var (
+	DefaultButtonColor               = zgeo.ColorNewGray(0.93, 1)
+	DefaultButtonStrokeColor         = zgeo.ColorNewGray(0.6, 1)
+	DefaultCheckBoxColor             = zgeo.ColorNew(0.2, 0.4, 1, 1)
+	DisabledAlpha            float32 = 0.4 // alpha multiplied in for views that are not usable, like in js
+)
+
+// RenderToGoImage paints view and all its shown children into a new image the size of view.
+func RenderToGoImage(view zview.View) image.Image {
+	size := view.Rect().Size
+	zlog.Assert(!size.IsNull(), "render view with no size:", view.Native().Hierarchy())
+	canvas := zcanvas.New()
+	canvas.SetSize(size)
+	Render(view, canvas, zgeo.PosD(-view.Rect().Pos.X, -view.Rect().Pos.Y))
+	return canvas.GoImage(zgeo.Rect{})
+}
+
+// RenderToPNGFile renders view with RenderToGoImage, and saves it as a png at filepath.
+func RenderToPNGFile(view zview.View, filepath string) error {
+	img := RenderToGoImage(view)
+	return zimage.GoImageToPNGFile(img, filepath)
+}
+
+// CompareWithGolden renders view, and compares it with the png at goldenPath.
+// If update is true, the rendering is written to goldenPath instead. A missing golden is an error otherwise,
+// so a deleted or misnamed golden doesn't pass.
+// pixelDiffPercent is how much a pixel can change before it is considered different, see zimage.GoImagesDiffAreaRatio.
+// An error is returned if the images differ, with the difference written next to the golden as <goldenPath>.failed.png.
+func CompareWithGolden(view zview.View, goldenPath string, pixelDiffPercent float64, update bool) error {
+	img := RenderToGoImage(view)
+	if update {
+		return zimage.GoImageToPNGFile(img, goldenPath)
+	}
+	_, err := os.Stat(goldenPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return zlog.Error("no golden, run with update to make it:", goldenPath)
+	}
+	golden, _, err := zimage.GoImageFromFile(goldenPath)
+	if err != nil {
+		return zlog.Error("read golden", goldenPath, err)
+	}
+	if golden.Bounds().Size() != img.Bounds().Size() {
+		zimage.GoImageToPNGFile(img, goldenPath+".failed.png")
+		return zlog.Error("golden size differs:", golden.Bounds().Size(), img.Bounds().Size(), goldenPath)
+	}
+	ratio := zimage.GoImagesDiffAreaRatio(golden, img, pixelDiffPercent, true)
+	if ratio > 0 {
+		zimage.GoImageToPNGFile(img, goldenPath+".failed.png")
+		return zlog.Error("rendering differs from golden:", goldenPath, "changed area:", ratio)
+	}
+	return nil
+}
+
+// Render paints view and its children into canvas, with view's rect offset by pos.
+func Render(view zview.View, canvas *zcanvas.Canvas, pos zgeo.Pos) {
+	renderNative(view.Native(), canvas, pos, 1)
+}
+
+func renderNative(nv *zview.NativeView, canvas *zcanvas.Canvas, pos zgeo.Pos, alpha float32) {
+	if !nv.IsShown() {
+		return
+	}
+	alpha *= nv.Alpha()
+	if !nv.IsUsable() && nv.Flags&zview.ViewNoDimUsableFlag == 0 {
+		alpha *= DisabledAlpha
+	}
+	r := nv.Rect()
+	r.Pos.Add(pos)
+	corner, _ := nv.Corners()
+	drawBackground(nv, canvas, r, corner, alpha)
+	drawContent(nv, canvas, r, alpha)
+
+	children := append([]*zview.NativeView{}, nv.NativeChildren()...)
+	sort.SliceStable(children, func(i, j int) bool {
+		return children[i].ZIndex() < children[j].ZIndex()
+	})
+	if len(children) != 0 {
+		canvas.PushState()
+		canvas.ClipPath(zgeo.PathNewRect(r, zgeo.SizeBoth(corner)), false)
+		cpos := r.Pos.Minus(nv.ContentOffset())
+		for _, c := range children {
+			renderNative(c, canvas, cpos, alpha)
+		}
+		canvas.PopState()
+	}
+	drawStroke(nv, canvas, r, corner, alpha)
+}
+
+func withAlpha(col zgeo.Color, alpha float32) zgeo.Color {
+	col.Colors.A *= alpha
+	return col
+}
+
+func drawBackground(nv *zview.NativeView, canvas *zcanvas.Canvas, r zgeo.Rect, corner float64, alpha float32) {
+	for _, d := range nv.DropShadows() {
+		if !d.Color.Valid || d.Inset {
+			continue
+		}
+		sr := r.Plus(zgeo.RectFromXYWH(d.Delta.W, d.Delta.H, 0, 0)).ExpandedD(d.Spread)
+		canvas.SetColor(withAlpha(d.Color, alpha))
+		canvas.FillRect(sr, corner)
+	}
+	bg := nv.BGColor()
+	if !bg.Valid || bg.Colors.A == 0 {
+		if nv.Kind() != "button" {
+			return
+		}
+		bg = DefaultButtonColor
+		if corner == 0 {
+			corner = 5
+		}
+	}
+	canvas.SetColor(withAlpha(bg, alpha))
+	canvas.FillRect(r, corner)
+}
+
+func drawStroke(nv *zview.NativeView, canvas *zcanvas.Canvas, r zgeo.Rect, corner float64, alpha float32) {
+	s := nv.Stroke()
+	if s.Width == 0 || !s.Color.Valid {
+		if nv.Kind() != "button" && nv.Kind() != "checkbox" {
+			return
+		}
+		s = zview.HeadlessStroke{Width: 1, Color: DefaultButtonStrokeColor, Inset: true}
+	}
+	if s.Inset {
+		r = r.ExpandedD(-s.Width / 2)
+	} else {
+		r = r.ExpandedD(s.Width / 2)
+	}
+	canvas.SetColor(withAlpha(s.Color, alpha))
+	canvas.StrokePath(zgeo.PathNewRect(r, zgeo.SizeBoth(corner)), s.Width, zgeo.PathLineSquare)
+}
+
+func drawContent(nv *zview.NativeView, canvas *zcanvas.Canvas, r zgeo.Rect, alpha float32) {
+	sd, _ := nv.View.(selfDrawer)
+	if sd != nil && sd.DrawHandler() != nil {
+		sd.ForceDrawSelf()
+		canvas.DrawGoImage(sd.Canvas().GoImage(zgeo.Rect{}), r.Pos)
+		return
+	}
+	if nv.Kind() == "checkbox" {
+		drawCheckBox(nv, canvas, r, alpha)
+		return
+	}
+	var ti ztextinfo.Info
+	to, _ := nv.View.(ztextinfo.Owner)
+	if to != nil {
+		ti = to.GetTextInfo()
+	} else {
+		t, _ := nv.View.(texter)
+		if t == nil {
+			return
+		}
+		ti = *ztextinfo.New()
+		ti.Text = t.Text()
+		ti.Font = nv.Font()
+		ti.Alignment = zgeo.CenterLeft
+	}
+	if ti.Text == "" {
+		return
+	}
+	mo, _ := nv.View.(zview.MarginOwner)
+	if mo != nil {
+		r.Add(mo.Margin())
+	}
+	switch nv.Kind() {
+	case "button":
+		ti.Alignment = zgeo.Center
+	case "input", "textarea":
+		r = r.ExpandedD(-4)
+	}
+	if ti.Alignment == zgeo.AlignmentNone {
+		ti.Alignment = zgeo.TopLeft
+	}
+	col := nv.Color()
+	if !col.Valid {
+		col = zgeo.ColorBlack
+	}
+	ti.Color = withAlpha(col, alpha)
+	ti.Rect = r
+	ti.Draw(canvas)
+}
+
+func drawCheckBox(nv *zview.NativeView, canvas *zcanvas.Canvas, r zgeo.Rect, alpha float32) {
+	oo, _ := nv.View.(onOwner)
+	if oo == nil || !oo.On() {
+		return
+	}
+	box := r.ExpandedD(-1)
+	canvas.SetColor(withAlpha(DefaultCheckBoxColor, alpha))
+	canvas.FillRect(box, 3)
+	at := func(x, y float64) zgeo.Pos {
+		return zgeo.PosD(box.Pos.X+box.Size.W*x, box.Pos.Y+box.Size.H*y)
+	}
+	path := zgeo.PathNew()
+	path.MoveTo(at(0.25, 0.5))
+	path.LineTo(at(0.45, 0.7))
+	path.LineTo(at(0.75, 0.3))
+	canvas.SetColor(withAlpha(zgeo.ColorWhite, alpha))
+	canvas.StrokePath(path, 2, zgeo.PathLineRound)
+}
@@ -0,0 +1,58 @@
+//go:build !js && zui
+
+package zrender
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/torlangballe/zui/zcustom"
+	"github.com/torlangballe/zutil/zgeo"
+)
+
+var update = flag.Bool("update", false, "write golden images instead of comparing with them")
+
+func makeBackgroundsView() *zcustom.CustomView {
+	parent := zcustom.NewView("parent")
+	parent.SetBGColor(zgeo.ColorNew(1, 0, 0, 1))
+	parent.SetRect(zgeo.RectFromXYWH(0, 0, 40, 30))
+	child := zcustom.NewView("child")
+	child.SetBGColor(zgeo.ColorNew(0, 0, 1, 1))
+	child.SetRect(zgeo.RectFromXYWH(10, 5, 20, 10))
+	parent.AddChild(child, nil)
+	return parent
+}
+
+func TestRenderBackgrounds(t *testing.T) {
+	view := makeBackgroundsView()
+	img := RenderToGoImage(view)
+	if img.Bounds().Dx() != 40 || img.Bounds().Dy() != 30 {
+		t.Fatal("image size:", img.Bounds())
+	}
+	r, _, b, _ := img.At(2, 2).RGBA()
+	if r>>8 != 255 || b != 0 {
+		t.Error("parent background not red at 2,2:", img.At(2, 2))
+	}
+	r, _, b, _ = img.At(15, 10).RGBA()
+	if r != 0 || b>>8 != 255 {
+		t.Error("child background not blue at 15,10:", img.At(15, 10))
+	}
+	err := CompareWithGolden(view, "testdata/backgrounds.png", 1, *update)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCompareWithMissingGolden(t *testing.T) {
+	goldenPath := filepath.Join(t.TempDir(), "missing.png")
+	err := CompareWithGolden(makeBackgroundsView(), goldenPath, 1, false)
+	if err == nil {
+		t.Error("missing golden passed")
+	}
+	_, err = os.Stat(goldenPath)
+	if err == nil {
+		t.Error("missing golden was written without update")
+	}
+}
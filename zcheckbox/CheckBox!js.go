@@ -0,0 +1,49 @@
+//go:build !js && zui
+
+package zcheckbox
+
+import (
+	"github.com/torlangballe/zutil/zbool"
+	"github.com/torlangballe/zutil/zkeyvalue"
+)
+
+func NewWithStore(defaultVal bool, storeKey string) *CheckBox {
+	val := defaultVal
+	if storeKey != "" && zkeyvalue.DefaultStore != nil {
+		v, got := zkeyvalue.DefaultStore.GetBool(storeKey, defaultVal)
+		if got {
+			val = v
+		}
+	}
+	v := New(zbool.FromBool(val))
+	v.storeKey = storeKey
+	return v
+}
+
+func New(on zbool.BoolInd) *CheckBox {
+	v := &CheckBox{}
+	v.MakeHeadless(v, "checkbox")
+	v.SetCanTabFocus(false)
+	v.SetValue(on)
+	v.NativeView.SetPressedHandler("$toggle", 0, func() {
+		v.Toggle()
+		if v.storeKey != "" && zkeyvalue.DefaultStore != nil {
+			zkeyvalue.DefaultStore.SetBool(v.On(), v.storeKey, true)
+		}
+		v.changed.CallAll(true)
+	})
+	return v
+}
+
+func (v *CheckBox) SetValueHandler(id string, handler func(edited bool)) {
+	v.changed.Add(id, handler)
+}
+
+func (v *CheckBox) Value() zbool.BoolInd {
+	b, _ := v.JSGet("value").(zbool.BoolInd)
+	return b
+}
+
+func (v *CheckBox) SetValue(b zbool.BoolInd) {
+	v.JSSet("value", b)
+}
@@ -4,4 +4,15 @@ package zclipboard
 
 // See https://github.com/d-tsuji/clipboard/blob/master/clipboard_darwin.go for some implementations
 
-func SetString(str string) {}
+var (
+	PasteIntoTextFieldFunc func(got func(s string))
+	headlessString         string // headlessString is what was copied, as there is no system clipboard here
+)
+
+func SetString(str string) {
+	headlessString = str
+}
+
+func GetString(got func(s string)) {
+	got(headlessString)
+}
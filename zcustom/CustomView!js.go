@@ -2,11 +2,60 @@
 
 package zcustom
 
-import "github.com/torlangballe/zui/zview"
-
-func (v *CustomView) drawSelf()                         {}
-func (v *CustomView) makeCanvas()                       {}
-func (v *CustomView) ReadyToShow(beforeWindow bool)     {}
-func (v *CustomView) ExposeIn(secs float64)             {}
-func (v *CustomView) Init(view zview.View, name string) { v.SetObjectName(name) }
-func (v *CustomView) Expose()                           { v.ExposeIn(0.1) }
+import (
+	"github.com/torlangballe/zui/zcanvas"
+	"github.com/torlangballe/zui/zview"
+	"github.com/torlangballe/zutil/zstr"
+	"github.com/torlangballe/zutil/ztimer"
+)
+
+func (v *CustomView) Init(view zview.View, name string) {
+	stype := "div"
+	zstr.SplitN(name, "#type:", &name, &stype)
+	v.MakeHeadless(view, stype)
+	v.SetObjectName(name)
+	v.SetSelectable(false)
+	v.exposeTimer = ztimer.TimerNew()
+	v.exposed = true
+}
+
+// drawSelf draws into the view's own canvas, sized to its local rect.
+// Headless views are only drawn on demand, with ForceDrawSelf or when rendered by zcanvas.
+func (v *CustomView) drawSelf() {
+	if v.drawing || v.draw == nil || !v.HasSize() {
+		return
+	}
+	r := v.LocalRect()
+	if r.Size.IsNull() {
+		return
+	}
+	v.drawing = true
+	v.canvas = nil
+	v.makeCanvas()
+	v.draw(r, v.canvas, v.View)
+	v.drawing = false
+	v.exposed = false
+}
+
+func (v *CustomView) makeCanvas() {
+	if v.canvas != nil {
+		return
+	}
+	v.canvas = zcanvas.New()
+	v.canvas.DownsampleImages = v.DownsampleImages
+	v.canvas.SetSize(v.LocalRect().Size)
+}
+
+func (v *CustomView) ReadyToShow(beforeWindow bool) {}
+
+func (v *CustomView) ExposeIn(secs float64) {
+	v.exposed = true
+}
+
+func (v *CustomView) Expose() {
+	v.ExposeIn(0.1)
+}
+
+func (v *CustomView) ForceDrawSelf() {
+	v.drawSelf()
+}
@@ -0,0 +1,433 @@
+package zdocs
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/torlangballe/zutil/zmath"
+)
+
+// Index is an in-memory inverted index of SearchableItems, from GUI views and markdown documentation.
+// Words of a search match words in items exactly, as prefixes, or with a typo or two for longer words, see Search.
+// Items are added and removed by their path, so parts of a GUI can be re-indexed as views come and go, see IndexView.
+type Index struct {
+	lock     sync.Mutex
+	docs     map[int]*indexedItem
+	postings map[string][]int // postings are the ids of docs each word is in, ascending
+	keys     map[string]int   // keys are ids of docs by path and text, so an item is only added once
+	terms    []string         // terms are the words of postings sorted, for prefix lookups. nil if it needs remaking
+	nextID   int
+}
+
+type indexedItem struct {
+	item   SearchableItem
+	key    string
+	tokens []token
+}
+
+// token is a word in a text, lowercased, with the rune range it is at in the text.
+type token struct {
+	word  string
+	start int
+	end   int
+}
+
+const (
+	prefixQuality = 0.6 // prefixQuality is the least quality of a prefix match, for a short stub of a long word
+	typoQuality   = 0.5 // typoQuality is the quality of a match with one typo, less for more
+	phraseBonus   = 0.2 // phraseBonus is added to the score of an item with all the words of a search in order
+	snippetRunes  = 40  // snippetRunes is how much text is kept before and after the match of a snippet
+)
+
+// MainIndex is the index IndexView adds to, and documentation is added to as it is fetched.
+var MainIndex = NewIndex()
+
+func NewIndex() *Index {
+	ix := &Index{}
+	ix.docs = map[int]*indexedItem{}
+	ix.postings = map[string][]int{}
+	ix.keys = map[string]int{}
+	return ix
+}
+
+// pathKey is a string of a path's names and stubs, so paths with the same names for different stubs differ.
+func pathKey(path []PathPart) string {
+	var str string
+	for _, p := range path {
+		str += p.PartName + "\x01" + p.PathStub + "\x02"
+	}
+	return str
+}
+
+func itemKey(item SearchableItem) string {
+	return pathKey(item.DocLink.Path) + "\x00" + item.Text
+}
+
+// Add adds items to the index. Items already in it with the same path and text are skipped.
+func (ix *Index) Add(items ...SearchableItem) {
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+	for _, item := range items {
+		key := itemKey(item)
+		if _, got := ix.keys[key]; got {
+			continue
+		}
+		id := ix.nextID
+		ix.nextID++
+		doc := &indexedItem{item: item, key: key, tokens: tokenize(item.Text)}
+		ix.docs[id] = doc
+		ix.keys[key] = id
+		for _, t := range doc.tokens {
+			ids := ix.postings[t.word]
+			if len(ids) != 0 && ids[len(ids)-1] == id {
+				continue
+			}
+			if len(ids) == 0 {
+				ix.terms = nil
+			}
+			ix.postings[t.word] = append(ids, id)
+		}
+	}
+}
+
+// RemoveUnder removes all items with paths starting with path, returning how many.
+func (ix *Index) RemoveUnder(path []PathPart) int {
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+	prefix := pathKey(path)
+	var count int
+	for id, doc := range ix.docs {
+		if strings.HasPrefix(doc.key, prefix) {
+			ix.remove(id)
+			count++
+		}
+	}
+	return count
+}
+
+// Remove removes items with the same path and text as items, returning how many were in the index.
+func (ix *Index) Remove(items ...SearchableItem) int {
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+	var count int
+	for _, item := range items {
+		id, got := ix.keys[itemKey(item)]
+		if got {
+			ix.remove(id)
+			count++
+		}
+	}
+	return count
+}
+
+func (ix *Index) remove(id int) {
+	doc := ix.docs[id]
+	delete(ix.docs, id)
+	delete(ix.keys, doc.key)
+	for _, t := range doc.tokens {
+		ids := removeID(ix.postings[t.word], id)
+		if len(ids) == 0 {
+			delete(ix.postings, t.word)
+			ix.terms = nil
+			continue
+		}
+		ix.postings[t.word] = ids
+	}
+}
+
+// Update replaces the items under path with items, as when a view is rebuilt.
+func (ix *Index) Update(path []PathPart, items []SearchableItem) {
+	ix.RemoveUnder(path)
+	ix.Add(items...)
+}
+
+// Count returns how many items are in the index.
+func (ix *Index) Count() int {
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+	return len(ix.docs)
+}
+
+func removeID(ids []int, id int) []int {
+	i := sort.SearchInts(ids, id)
+	if i < len(ids) && ids[i] == id {
+		return append(ids[:i], ids[i+1:]...)
+	}
+	return ids
+}
+
+// Search returns the items that match all words in query, best first, at most maxResults of them if maxResults isn't 0.
+// A word matches words in an item it is equal to, is a prefix of, or is within maxTypos of.
+// Each result's Matches are the rune ranges of its Text that matched, with the quality of each match.
+func (ix *Index) Search(query string, maxResults int) []SearchResult {
+	words := tokenize(query)
+	if len(words) == 0 {
+		return nil
+	}
+	ix.lock.Lock()
+	defer ix.lock.Unlock()
+	if ix.terms == nil {
+		for t := range ix.postings {
+			ix.terms = append(ix.terms, t)
+		}
+		sort.Strings(ix.terms)
+	}
+	var candidates map[int]bool
+	for _, w := range words {
+		found := map[int]bool{}
+		for _, term := range ix.matchingTerms(w.word) {
+			for _, id := range ix.postings[term] {
+				if candidates == nil || candidates[id] {
+					found[id] = true
+				}
+			}
+		}
+		candidates = found
+		if len(candidates) == 0 {
+			return nil
+		}
+	}
+	var results []SearchResult
+	for id := range candidates {
+		doc := ix.docs[id]
+		score, matches := scoreTokens(words, doc.tokens)
+		if score == 0 {
+			continue
+		}
+		r := SearchResult{SearchableItem: doc.item, Matches: matches}
+		r.Score = score * doc.item.DocLink.Score
+		r.Snippet = MakeSnippet(doc.item.Text, matches, snippetRunes)
+		r.Snippet.Score = r.Score
+		results = append(results, r)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return len(results[i].SearchableItem.Text) < len(results[j].SearchableItem.Text)
+	})
+	if maxResults != 0 && len(results) > maxResults {
+		results = results[:maxResults]
+	}
+	return results
+}
+
+// MakeSnippet returns the best of matches in text with up to contextRunes of text before and after it on its line,
+// cut at word boundaries with an ellipsis. Matches close after the best are included in the snippet's Match.
+func MakeSnippet(text string, matches []MatchScore, contextRunes int) MatchedText {
+	var mt MatchedText
+	if len(matches) == 0 {
+		return mt
+	}
+	best := matches[0]
+	for _, m := range matches {
+		if m.Score > best.Score {
+			best = m
+		}
+	}
+	runes := []rune(text)
+	start, end := best.Matches.Min, best.Matches.Max
+	for _, m := range matches {
+		if m.Matches.Min >= end && m.Matches.Max-start <= contextRunes {
+			end = m.Matches.Max
+		}
+	}
+	lineStart := start
+	for lineStart > 0 && runes[lineStart-1] != '\n' {
+		lineStart--
+	}
+	lineEnd := end
+	for lineEnd < len(runes) && runes[lineEnd] != '\n' {
+		lineEnd++
+	}
+	pre := max(lineStart, start-contextRunes)
+	if pre > lineStart {
+		for pre < start && !unicode.IsSpace(runes[pre-1]) {
+			pre++
+		}
+		mt.Pre = "…"
+	}
+	post := min(lineEnd, end+contextRunes)
+	var ellipsis string
+	if post < lineEnd {
+		for post > end && !unicode.IsSpace(runes[post]) {
+			post--
+		}
+		ellipsis = "…"
+	}
+	mt.Pre += strings.TrimLeft(string(runes[pre:start]), " \t")
+	mt.Match = string(runes[start:end])
+	mt.Post = strings.TrimRight(string(runes[end:post]), " \t") + ellipsis
+	return mt
+}
+
+// matchingTerms returns the terms in the index word could match, see matchWord.
+// Short words can't have typos, so only terms they are a prefix of are looked up.
+func (ix *Index) matchingTerms(word string) []string {
+	var terms []string
+	if maxTypos(word) == 0 {
+		i := sort.SearchStrings(ix.terms, word)
+		for ; i < len(ix.terms) && strings.HasPrefix(ix.terms[i], word); i++ {
+			terms = append(terms, ix.terms[i])
+		}
+		return terms
+	}
+	for _, t := range ix.terms {
+		if matchWord(word, t) != 0 {
+			terms = append(terms, t)
+		}
+	}
+	return terms
+}
+
+// tokenize splits text into its words of letters and digits, lowercased.
+func tokenize(text string) []token {
+	var tokens []token
+	var word []rune
+	start := 0
+	i := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.Is(unicode.Mn, r) {
+			if len(word) == 0 {
+				start = i
+			}
+			word = append(word, unicode.ToLower(r))
+		} else if len(word) != 0 {
+			tokens = append(tokens, token{word: string(word), start: start, end: i})
+			word = word[:0]
+		}
+		i++
+	}
+	if len(word) != 0 {
+		tokens = append(tokens, token{word: string(word), start: start, end: i})
+	}
+	return tokens
+}
+
+// maxTypos is how many typos a word of a search can have and still match; none for short words.
+func maxTypos(word string) int {
+	n := len([]rune(word))
+	switch {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	}
+	return 0
+}
+
+// matchWord returns how well word matches t, 1 for equal, less for a prefix or typos, 0 for no match.
+func matchWord(word, t string) float64 {
+	if word == t {
+		return 1
+	}
+	if strings.HasPrefix(t, word) {
+		ratio := float64(len([]rune(word))) / float64(len([]rune(t)))
+		return prefixQuality + (1-prefixQuality)*ratio
+	}
+	typos := maxTypos(word)
+	if typos == 0 {
+		return 0
+	}
+	d := editDistance(word, t, typos)
+	if d > typos {
+		// the word may be a prefix with a typo, like "documnt" for "documentation"
+		// so it is compared to the starts of t that are as long, give or take the typos.
+		tr := []rune(t)
+		n := len([]rune(word))
+		for l := max(1, n-typos); l <= n+typos && l < len(tr); l++ {
+			if editDistance(word, string(tr[:l]), typos) <= typos {
+				return typoQuality * prefixQuality
+			}
+		}
+		return 0
+	}
+	return typoQuality / float64(d)
+}
+
+// scoreTokens scores how well tokens of a text match words of a search, from 0 to 1 + phraseBonus.
+// Every word must match a token for a score above 0.
+func scoreTokens(words, tokens []token) (score float64, matches []MatchScore) {
+	firsts := make([]int, len(words)) // firsts is the index of the first best token for each word, for phrase detection
+	for wi, w := range words {
+		best := 0.0
+		firsts[wi] = -1
+		for ti, t := range tokens {
+			q := matchWord(w.word, t.word)
+			if q == 0 {
+				continue
+			}
+			matches = append(matches, MatchScore{Matches: zmath.MakeRange(t.start, t.end), Score: q})
+			if q > best {
+				best = q
+				firsts[wi] = ti
+			}
+		}
+		if best == 0 {
+			return 0, nil
+		}
+		score += best
+	}
+	score /= float64(len(words))
+	if len(words) > 1 {
+		inOrder := true
+		for i := 1; i < len(firsts); i++ {
+			if firsts[i] != firsts[i-1]+1 {
+				inOrder = false
+				break
+			}
+		}
+		if inOrder {
+			score += phraseBonus
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Matches.Min < matches[j].Matches.Min
+	})
+	return score, matches
+}
+
+// editDistance returns the Damerau-Levenshtein (optimal string alignment) distance between a and b,
+// stopping at more than limit, when limit+1 is returned.
+func editDistance(a, b string, limit int) int {
+	ar := []rune(a)
+	br := []rune(b)
+	if abs(len(ar)-len(br)) > limit {
+		return limit + 1
+	}
+	prev2 := make([]int, len(br)+1)
+	prev := make([]int, len(br)+1)
+	cur := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ar); i++ {
+		cur[0] = i
+		rowMin := cur[0]
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ar[i-1] == br[j-2] && ar[i-2] == br[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			rowMin = min(rowMin, cur[j])
+		}
+		if rowMin > limit {
+			return limit + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return min(prev[len(br)], limit+1)
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
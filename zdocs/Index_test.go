@@ -0,0 +1,269 @@
+package zdocs
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/torlangballe/zutil/zmath"
+)
+
+func testItem(area, name, text string) SearchableItem {
+	path := AddedPath(nil, StaticField, area, area)
+	return MakeSearchableItem(path, ValueField, name, name, text)
+}
+
+func resultNames(results []SearchResult) []string {
+	var names []string
+	for _, r := range results {
+		p := r.SearchableItem.DocLink.Path
+		names = append(names, p[len(p)-1].PartName)
+	}
+	return names
+}
+
+func makeTestIndex() *Index {
+	ix := NewIndex()
+	ix.Add(
+		testItem("Settings", "exact", "Network timeout for connections"),
+		testItem("Settings", "prefix", "Networking options"),
+		testItem("Settings", "typo", "Netwrok cables"),
+		testItem("Settings", "phrase", "Set the timeout network wide"),
+		testItem("Docs", "doc", "Documentation of the network and its timeout"),
+		testItem("Docs", "other", "Nothing to see here"),
+	)
+	return ix
+}
+
+func TestIndexSearchRanking(t *testing.T) {
+	ix := makeTestIndex()
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"network", []string{"phrase", "exact", "doc", "prefix", "typo"}}, // exact, then prefix, then typo
+		{"network timeout", []string{"exact", "phrase", "doc"}},           // in order gets a bonus, shorter texts are first of equals
+		{"netw", []string{"typo", "phrase", "exact", "doc", "prefix"}},    // "netwrok" and "network" are equal prefix matches
+		{"documnt", []string{"doc"}},
+		{"cables", []string{"typo"}},
+		{"xyz", nil},
+		{"", nil},
+	}
+	for _, test := range tests {
+		got := resultNames(ix.Search(test.query, 0))
+		if len(got) != len(test.want) {
+			t.Errorf("%q: got %v, want %v", test.query, got, test.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != test.want[i] {
+				t.Errorf("%q: got %v, want %v", test.query, got, test.want)
+				break
+			}
+		}
+	}
+	if got := ix.Search("network", 2); len(got) != 2 {
+		t.Error("maxResults not applied:", len(got))
+	}
+}
+
+func TestIndexSearchScores(t *testing.T) {
+	ix := NewIndex()
+	ix.Add(testItem("A", "one", "timeout"))
+	r := ix.Search("timeout", 0)
+	if len(r) != 1 || r[0].Score != 1 {
+		t.Fatal("exact match should score 1:", r)
+	}
+	if len(r[0].Matches) != 1 || r[0].Matches[0].Matches != zmath.MakeRange(0, 7) || r[0].Matches[0].Score != 1 {
+		t.Error("bad matches:", r[0].Matches)
+	}
+	if r[0].Snippet.Match != "timeout" {
+		t.Error("bad snippet:", r[0].Snippet)
+	}
+	weighted := testItem("A", "two", "timeout too")
+	weighted.DocLink.Score = 0.5
+	ix.Add(weighted)
+	r = ix.Search("timeout", 0)
+	if len(r) != 2 || r[1].Score != 0.5 {
+		t.Error("DocLink.Score not applied:", r)
+	}
+}
+
+func TestIndexTypos(t *testing.T) {
+	ix := NewIndex()
+	ix.Add(testItem("A", "cut", "cut"), testItem("A", "documentation", "documentation"), testItem("A", "form", "form"))
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"cat", ""}, // short words must match exactly or as prefix
+		{"cu", "cut"},
+		{"from", "form"}, // transposed
+		{"forn", "form"},
+		{"documantation", "documentation"},
+		{"docmuentaton", "documentation"}, // two typos for a long word
+		{"dcmntatn", ""},
+		{"documnt", "documentation"}, // prefix with a typo
+	}
+	for _, test := range tests {
+		got := resultNames(ix.Search(test.query, 0))
+		if test.want == "" {
+			if len(got) != 0 {
+				t.Errorf("%q: got %v, want none", test.query, got)
+			}
+			continue
+		}
+		if len(got) != 1 || got[0] != test.want {
+			t.Errorf("%q: got %v, want %s", test.query, got, test.want)
+		}
+	}
+}
+
+func TestIndexRemove(t *testing.T) {
+	ix := makeTestIndex()
+	ix.Add(testItem("Settings", "exact", "Network timeout for connections")) // already in it
+	if ix.Count() != 6 {
+		t.Fatal("count:", ix.Count())
+	}
+	settings := AddedPath(nil, StaticField, "Settings", "Settings")
+	if n := ix.RemoveUnder(settings); n != 4 {
+		t.Error("removed under settings:", n)
+	}
+	if got := resultNames(ix.Search("network", 0)); len(got) != 1 || got[0] != "doc" {
+		t.Error("after RemoveUnder:", got)
+	}
+	if n := ix.Remove(testItem("Docs", "doc", "Documentation of the network and its timeout"), testItem("Docs", "doc", "other text")); n != 1 {
+		t.Error("removed items:", n)
+	}
+	if got := ix.Search("network", 0); len(got) != 0 {
+		t.Error("after Remove:", resultNames(got))
+	}
+	ix.Update(settings, []SearchableItem{testItem("Settings", "new", "Network again")})
+	if got := resultNames(ix.Search("network", 0)); len(got) != 1 || got[0] != "new" {
+		t.Error("after Update:", got)
+	}
+	if n := ix.RemoveUnder(nil); n != 2 || ix.Count() != 0 {
+		t.Error("remove all:", n, ix.Count())
+	}
+	if len(ix.postings) != 0 {
+		t.Error("postings left:", ix.postings)
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		limit int
+		want  int
+	}{
+		{"", "", 2, 0},
+		{"form", "form", 2, 0},
+		{"form", "forms", 2, 1},
+		{"form", "fork", 2, 1},
+		{"form", "from", 2, 1}, // a transposition is one edit
+		{"kitten", "sitting", 5, 3},
+		{"kitten", "sitting", 1, 2}, // stops at limit+1
+		{"a", "abcd", 2, 3},
+		{"æøå", "æoå", 2, 1},
+	}
+	for _, test := range tests {
+		got := editDistance(test.a, test.b, test.limit)
+		if got != test.want {
+			t.Errorf("editDistance(%q, %q, %d) = %d, want %d", test.a, test.b, test.limit, got, test.want)
+		}
+	}
+}
+
+func TestMakeSnippet(t *testing.T) {
+	text := "First line\nThe quick brown fox jumps over the lazy dog and runs far away into the woods\nLast"
+	ix := NewIndex()
+	ix.Add(testItem("A", "a", text))
+	r := ix.Search("lazy", 0)
+	if len(r) != 1 {
+		t.Fatal("no result")
+	}
+	got := MakeSnippet(text, r[0].Matches, 10)
+	want := MatchedText{Pre: "…over the ", Match: "lazy", Post: " dog and…"}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	got = MakeSnippet(text, r[0].Matches, 100)
+	want = MatchedText{Pre: "The quick brown fox jumps over the ", Match: "lazy", Post: " dog and runs far away into the woods"}
+	if got != want {
+		t.Errorf("whole line: got %+v, want %+v", got, want)
+	}
+	r = ix.Search("lazy dog", 0)
+	got = MakeSnippet(text, r[0].Matches, 10)
+	if got.Match != "lazy dog" {
+		t.Errorf("close matches not joined: %+v", got)
+	}
+	if got := MakeSnippet(text, nil, 10); got != (MatchedText{}) {
+		t.Error("snippet without matches:", got)
+	}
+}
+
+func TestMatchText(t *testing.T) {
+	text := "a red car\nblue cars and red bikes\nnothing"
+	got := MatchText("red car", text)
+	if len(got) != 2 {
+		t.Fatal("matches:", got)
+	}
+	if got[0].Pre != "a " || got[0].Match != "red car" || got[0].Post != "" {
+		t.Errorf("best: %+v", got[0])
+	}
+	if got[1].Match != "cars and red" || got[1].Pre != "blue " || got[1].Post != " bikes" {
+		t.Errorf("second: %+v", got[1])
+	}
+	if got[0].Score <= got[1].Score {
+		t.Error("phrase should score higher:", got[0].Score, got[1].Score)
+	}
+	if MatchText("", text) != nil || MatchText("green", text) != nil {
+		t.Error("expected no matches")
+	}
+}
+
+func TestMatchFuzzy(t *testing.T) {
+	r := zmath.MakeRange[int]
+	tests := []struct {
+		match string
+		text  string
+		want  []zmath.Range[int]
+		score float64
+	}{
+		{"fb", "foo bar", []zmath.Range[int]{r(0, 1), r(4, 5)}, 0.8 + 0.2*2/7},
+		{"f b", "foo bar", []zmath.Range[int]{r(0, 1), r(4, 5)}, 0.8 + 0.2*2/7}, // spaces in match are ignored
+		{"fob", "foo bar", []zmath.Range[int]{r(0, 2), r(4, 5)}, 0.8 + 0.2*3/7},
+		{"ba", "foo barbara", []zmath.Range[int]{r(4, 6)}, 0.8 + 0.2*2/11},
+		{"nt", "Network timeout", []zmath.Range[int]{r(0, 1), r(8, 9)}, 0.8 + 0.2*2/15}, // word start preferred
+		{"nt", "Network", []zmath.Range[int]{r(0, 1), r(2, 3)}, 0.75 * (0.8 + 0.2*2/7)}, // no word start for t
+		{"ab", "cab a", []zmath.Range[int]{r(1, 3)}, 0.75 * (0.8 + 0.2*2/5)},            // word start a leaves no b, so falls back
+		{"äö", "Ärger Öl", []zmath.Range[int]{r(0, 1), r(6, 7)}, 0.8 + 0.2*2/8},         // non-ASCII lowercased
+		{"ba", "café Bar", []zmath.Range[int]{r(5, 7)}, 0.8 + 0.2*2/8},                  // ranges are rune indexes
+		{"ss", "STRASSE", []zmath.Range[int]{r(0, 1), r(4, 5)}, 0.75 * (0.8 + 0.2*2/7)},
+		{"xyz", "foo", nil, 0},
+		{"ba", "ab", nil, 0}, // out of order
+		{" ", "foo", nil, 0},
+	}
+	for _, test := range tests {
+		score, matched := MatchFuzzy(test.match, test.text)
+		if !reflect.DeepEqual(matched, test.want) {
+			t.Errorf("%q in %q: matched %v, want %v", test.match, test.text, matched, test.want)
+		}
+		if math.Abs(score-test.score) > 1e-9 {
+			t.Errorf("%q in %q: score %g, want %g", test.match, test.text, score, test.score)
+		}
+	}
+}
+
+func TestMatchFuzzyOrder(t *testing.T) {
+	// Each text is a worse match for "fb" than the one before.
+	texts := []string{"fb", "foo bar", "foo bar baz", "xfxb", "xxxfxxxxb"}
+	last := 1.0
+	for _, text := range texts {
+		score, _ := MatchFuzzy("fb", text)
+		if score <= 0 || score > last {
+			t.Errorf("%q scores %g, after %g", text, score, last)
+		}
+		last = score
+	}
+}
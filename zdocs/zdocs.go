@@ -1,12 +1,10 @@
 package zdocs
 
 import (
-	"bufio"
-	"bytes"
-	"fmt"
+	"sort"
 	"strings"
+	"unicode"
 
-	"github.com/torlangballe/zutil/zlog"
 	"github.com/torlangballe/zutil/zmath"
 	"github.com/torlangballe/zutil/zstr"
 )
@@ -41,14 +39,18 @@ type SearchableItem struct {
 	Text    string
 }
 
+// MatchScore is a rune range of a text that matched a search word, and how well, 0-1.
 type MatchScore struct {
 	Matches zmath.Range[int]
 	Score   float64
 }
 
+// SearchResult is an item found by Index.Search, with the ranges of its Text that matched.
 type SearchResult struct {
 	SearchableItem SearchableItem
 	Matches        []MatchScore
+	Score          float64     // Score ranks results, it is how well all words matched times the item's DocLink.Score
+	Snippet        MatchedText // Snippet is the best match with some text around it, to show in a list of results
 }
 
 type SearchableItemsGetter interface {
@@ -99,86 +101,98 @@ func MakeSearchableItem(currentPath []PathPart, pathPartType DocType, partName,
 	}
 }
 
+// MatchText finds the lines of text that have all the words of matchLower, as Index.Search matches them,
+// and returns each as the matched part from the first to last matched word, with the text before and after it on its line.
+// The best matches are first.
 func MatchText(matchLower, text string) []MatchedText {
-	lower := strings.ToLower(text) // returns a score, 0-1 if no match
-	matchWords := strings.Fields(matchLower)
-	zlog.Info("MatchText:", len(matchWords), matchWords)
-
-	matchWordsLens := make([]int, len(matchWords))
-	for i, w := range matchWords {
-		matchWordsLens[i] = len(w)
+	words := tokenize(matchLower)
+	if len(words) == 0 {
+		return nil
 	}
-	buf := bytes.NewBuffer([]byte(lower))
-	s := bufio.NewScanner(buf)
-	var lines int
-	for s.Scan() {
-		line := s.Text()
-		if s.Err() != nil {
-			break
+	var matched []MatchedText
+	zstr.RangeStringLines(text, false, func(line string) bool {
+		score, matches := scoreTokens(words, tokenize(line))
+		if score == 0 {
+			return true
 		}
-		lineWords := strings.Fields(line)
-		var mi, matches int
-		var scoreSum float64
-		for li, lw := range lineWords {
-			lw = zstr.AlphaNumericASCIIOnly(lw)
-			for {
-				var score float64
-				if strings.HasPrefix(lw, matchWords[mi]) {
-					score = float64(matchWordsLens[mi]) / float64(len(lw))
-					if score < 0.7 && matchWordsLens[mi] < 4 {
-						score = 0 // if small stub on big word, don't use
-					}
-				} else {
-					score = -zstr.GetLevenshteinRatio(lw, matchWords[mi])
-				}
-				mi++
-				if score == 1 {
-					scoreSum += score
-					matches++
-					if mi >= len(matchWords) {
-						scoreSum /= float64(len(matchWords))
-						zlog.Info("Score:", matchWords[mi-1], score, lw, li, matches)
-						addMatch(scoreSum, lineWords, li, matches, lines)
-						scoreSum = 0
-						matches = 0
-						mi = 0
-					}
-					break
-				} else {
-					if scoreSum != 0 {
-						scoreSum /= float64(len(matchWords))
-						zlog.Info("Score2:", scoreSum, matchWords[mi-1], score, lw, li, matches)
-						addMatch(scoreSum, lineWords, li-1, matches, lines)
-					}
-					scoreSum = 0
-					matches = 0
-					if score > -0.3 && score != 0 {
-						zlog.Info("Score3:", matchWords[mi-1], score, lw, li, matches)
-						addMatch(score/float64(len(matchWords)), lineWords, li, 1, lines)
-					}
-					if mi >= len(matchWords) {
-						mi = 0
-						break
-					}
-				}
-			}
+		runes := []rune(line)
+		start := matches[0].Matches.Min
+		end := matches[0].Matches.Max
+		for _, m := range matches[1:] {
+			end = max(end, m.Matches.Max)
 		}
-		lines++
+		mt := MatchedText{
+			Pre:   string(runes[:start]),
+			Match: string(runes[start:end]),
+			Post:  string(runes[end:]),
+			Score: score,
+		}
+		matched = append(matched, mt)
+		return true
+	})
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Score > matched[j].Score
+	})
+	return matched
+}
+
+// MatchFuzzy matches matchLower against text as a type-ahead filter does.
+// Each rune of matchLower must be found in text in order, preferably continuing the last match or at a word start.
+// It returns a score from 0 for no match to 1 for a full match at word starts, and the rune ranges of text that matched.
+func MatchFuzzy(matchLower, text string) (score float64, matched []zmath.Range[int]) {
+	runes := []rune(text)
+	for i, r := range runes {
+		runes[i] = unicode.ToLower(r) // per rune, so matched ranges are indexes in text too
+	}
+	match := []rune(strings.Join(strings.Fields(matchLower), ""))
+	if len(match) == 0 {
+		return 0, nil
 	}
-	return nil
+	score, matched = matchFuzzyRunes(match, runes, true)
+	if matched == nil { // jumping to word starts can pass runes needed later, so try again without
+		score, matched = matchFuzzyRunes(match, runes, false)
+	}
+	return score, matched
 }
 
-func addMatch(score float64, lineWords []string, lwi, matches, line int) {
-	fmt.Print(zstr.EscCyan, line, score, ": ")
-	s := max(0, lwi-3-matches)
-	e := min(len(lineWords), lwi+4)
-	for i := s; i < e; i++ {
-		if i >= lwi-matches+1 && i <= lwi {
-			fmt.Print(zstr.EscYellow)
+func matchFuzzyRunes(match, runes []rune, preferWordStart bool) (score float64, matched []zmath.Range[int]) {
+	isWordStart := func(i int) bool {
+		return i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsDigit(runes[i-1])
+	}
+	var points float64
+	pos := 0
+	for _, r := range match {
+		if pos < len(runes) && runes[pos] == r && len(matched) != 0 && matched[len(matched)-1].Max == pos {
+			matched[len(matched)-1].Max++
+			points++
+			pos++
+			continue
+		}
+		found := -1
+		for i := pos; i < len(runes); i++ {
+			if runes[i] != r {
+				continue
+			}
+			if found == -1 {
+				found = i
+			}
+			if !preferWordStart || isWordStart(i) {
+				found = i
+				break
+			}
+		}
+		if found == -1 {
+			return 0, nil
+		}
+		if isWordStart(found) {
+			points++
 		} else {
-			fmt.Print(zstr.EscGreen)
+			points += 0.5
 		}
-		fmt.Print(lineWords[i], " ")
+		matched = append(matched, zmath.MakeRange(found, found+1))
+		pos = found + 1
 	}
-	fmt.Println(zstr.EscNoColor)
+	score = points / float64(len(match))
+	score *= 0.8 + 0.2*float64(len(match))/float64(len(runes)) // shorter texts are better matches
+	return score, matched
 }
@@ -37,3 +37,23 @@ func GetSearchableItems(root zview.View) []SearchableItem {
 	IsGettingSearchItems = false
 	return items
 }
+
+// IndexView adds view's searchable items under path to MainIndex, and keeps them there while view is in the GUI;
+// they are re-added each time it is added to a parent, and removed when it is removed.
+func IndexView(view zview.View, path []PathPart) {
+	update := func() {
+		IsGettingSearchItems = true
+		var items []SearchableItem
+		sig, _ := view.(SearchableItemsGetter)
+		if sig != nil {
+			items = sig.GetSearchableItems(path)
+		}
+		IsGettingSearchItems = false
+		MainIndex.Update(path, items)
+	}
+	update()
+	view.Native().AddOnAddFunc(update)
+	view.Native().AddOnRemoveFunc(func() {
+		MainIndex.RemoveUnder(path)
+	})
+}
@@ -294,6 +294,24 @@ func (f FlagType) String() string {
 	return flagsNameMap[f]
 }
 
+// Names returns the names of all flags set in f, in order of flag value.
+func (f FlagType) Names() []string {
+	var flags []FlagType
+	for flag := range flagsNameMap {
+		if f&flag != 0 {
+			flags = append(flags, flag)
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i] < flags[j]
+	})
+	names := make([]string, len(flags))
+	for i, flag := range flags {
+		names[i] = flagsNameMap[flag]
+	}
+	return names
+}
+
 func (f *Field) DebugName() string {
 	if f == nil {
 		return "nil"
@@ -381,7 +399,7 @@ func (f *Field) SetFromRVal(rval reflect.Value, zuiTagPart string, sfName, sfPkg
 		}
 		n, floatErr := strconv.ParseFloat(kv.Value, 32)
 		flag := zbool.FromString(kv.Value, false)
-		switch kv.Key {
+		switch kv.Key { // keys added here must also be added to tagKeys in TagValidate.go
 		case "search":
 			f.Flags |= FlagIsTableSearchable
 		case "noguisearch":
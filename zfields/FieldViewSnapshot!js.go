@@ -0,0 +1,163 @@
+//go:build !js && zui
+
+package zfields
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"github.com/torlangballe/zui/zmenu"
+	"github.com/torlangballe/zui/zview"
+	"github.com/torlangballe/zutil/zgeo"
+	"github.com/torlangballe/zutil/zlog"
+)
+
+// SnapshotDefaultSize is the size a FieldView is laid out in by Snapshot if a null size is given.
+var SnapshotDefaultSize = zgeo.SizeD(800, 600)
+
+// SnapshotWithRects makes DescribeFieldView include each field's rect.
+// Headless text is measured with the fonts found on the machine, so goldens shared between machines turn it off.
+var SnapshotWithRects = true
+
+// Snapshot builds a headless FieldView for structPtr with params, lays it out in size,
+// and returns a stable, line-based description of it with DescribeFieldView.
+// It is used to see what a struct's zui tags produce in tests, see CompareSnapshotWithGolden.
+func Snapshot(structPtr any, params FieldViewParameters, size zgeo.Size) string {
+	if size.IsNull() {
+		size = SnapshotDefaultSize
+	}
+	fv := FieldViewNew(reflect.TypeOf(structPtr).Elem().Name(), structPtr, params)
+	fv.Build(true)
+	fv.SetRect(zgeo.Rect{Size: size})
+	return DescribeFieldView(fv)
+}
+
+// CompareSnapshotWithGolden makes a Snapshot of structPtr, and compares it with the text file at goldenPath.
+// If update is true, the snapshot is written to goldenPath instead. A missing golden is an error otherwise.
+// If they differ, the snapshot is written to <goldenPath>.failed, and an error with the first differing line is returned.
+func CompareSnapshotWithGolden(structPtr any, params FieldViewParameters, size zgeo.Size, goldenPath string, update bool) error {
+	snap := Snapshot(structPtr, params, size)
+	if update {
+		return os.WriteFile(goldenPath, []byte(snap), 0644)
+	}
+	golden, err := os.ReadFile(goldenPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return zlog.Error("no golden, run with update to make it:", goldenPath)
+	}
+	if err != nil {
+		return zlog.Error("read golden", goldenPath, err)
+	}
+	if string(golden) == snap {
+		return nil
+	}
+	os.WriteFile(goldenPath+".failed", []byte(snap), 0644)
+	glines := strings.Split(string(golden), "\n")
+	slines := strings.Split(snap, "\n")
+	for i := 0; i < len(glines) || i < len(slines); i++ {
+		var g, s string
+		if i < len(glines) {
+			g = glines[i]
+		}
+		if i < len(slines) {
+			s = slines[i]
+		}
+		if g != s {
+			return zlog.Error("snapshot differs from golden:", goldenPath, "line", i+1, "\nwant:", g, "\ngot: ", s)
+		}
+	}
+	return nil
+}
+
+// DescribeFieldView returns a description of fv's fields in order, with one line per field of
+// name, widget kind, title, flags and rect relative to fv (see SnapshotWithRects), followed by enum options and nested fields indented.
+// Only the information listed is included, so it is stable across runs and diffs well.
+func DescribeFieldView(fv *FieldView) string {
+	var lines []string
+	describeFieldView(fv, fv.Native().AbsoluteRect().Pos, "", &lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+func describeFieldView(fv *FieldView, origin zgeo.Pos, indent string, lines *[]string) {
+	for _, f := range fv.Fields {
+		view, label, _ := fv.FindNamedViewOrInLabelized(f.FieldName)
+		line := indent + f.FieldName + ":"
+		if view == nil {
+			*lines = append(*lines, line+" <no view>")
+			continue
+		}
+		nv := view.Native()
+		line += " " + snapshotWidgetKind(view)
+		title := f.TitleOrName()
+		if label != nil {
+			if t, _ := label.(interface{ Text() string }); t != nil {
+				title = t.Text()
+			}
+		}
+		line += fmt.Sprintf(" title=%q", title)
+		if f.Flags != 0 {
+			line += " flags=" + strings.Join(f.Flags.Names(), ",")
+		}
+		if !nv.IsShown() {
+			line += " hidden"
+		}
+		if !nv.IsUsable() {
+			line += " disabled"
+		}
+		if SnapshotWithRects {
+			r := nv.AbsoluteRect()
+			r.Pos = r.Pos.Minus(origin)
+			line += fmt.Sprintf(" rect=%s", snapshotRectString(r))
+		}
+		*lines = append(*lines, line)
+		for _, o := range snapshotOptions(&f, view) {
+			*lines = append(*lines, indent+"  - "+o)
+		}
+		child, _ := view.(*FieldView)
+		if child != nil {
+			describeFieldView(child, origin, indent+"  ", lines)
+		}
+	}
+}
+
+func snapshotWidgetKind(view zview.View) string {
+	kind := strings.TrimPrefix(reflect.TypeOf(view).String(), "*")
+	if nk := view.Native().Kind(); nk != "" {
+		kind += "/" + nk
+	}
+	return kind
+}
+
+func snapshotRectString(r zgeo.Rect) string {
+	return fmt.Sprintf("[%g,%g %gx%g]", r.Pos.X, r.Pos.Y, r.Size.W, r.Size.H)
+}
+
+func snapshotOptions(f *Field, view zview.View) []string {
+	var options []string
+	mv, _ := view.(*zmenu.MenuView)
+	if mv != nil {
+		for _, item := range mv.Items() {
+			options = append(options, fmt.Sprintf("%q=%v", item.Name, item.Value))
+		}
+		return options
+	}
+	owner := zmenu.OwnerForView(view)
+	if owner != nil {
+		for _, item := range owner.Items() {
+			if item.IsSeparator {
+				options = append(options, "---")
+				continue
+			}
+			options = append(options, fmt.Sprintf("%q=%v", item.Name, item.Value))
+		}
+		return options
+	}
+	if f.Enum != "" {
+		for _, item := range GetEnum(f.Enum) {
+			options = append(options, fmt.Sprintf("%q=%v", item.Name, item.Value))
+		}
+	}
+	return options
+}
@@ -0,0 +1,116 @@
+//go:build !js && zui
+
+package zfields
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/torlangballe/zui/zcheckbox"
+	"github.com/torlangballe/zui/zcontainer"
+	"github.com/torlangballe/zui/zlabel"
+	"github.com/torlangballe/zui/ztext"
+	"github.com/torlangballe/zutil/zbool"
+	"github.com/torlangballe/zutil/zgeo"
+)
+
+var update = flag.Bool("update", false, "write golden snapshots instead of comparing with them")
+
+type headlessPerson struct {
+	Name   string `zui:"title:Full Name"`
+	Age    int
+	Active bool
+	Note   string `zui:"static"`
+}
+
+func TestFieldViewHeadless(t *testing.T) {
+	p := headlessPerson{Name: "Ada", Age: 36, Active: true, Note: "read-only"}
+	fv := FieldViewNew("person", &p, DefaultFieldViewParameters)
+	fv.Build(true)
+	fv.SetRect(zgeo.Rect{Size: zgeo.SizeD(400, 300)})
+
+	view, _, _ := fv.FindNamedViewOrInLabelized("Name")
+	name, _ := view.(*ztext.TextView)
+	if name == nil {
+		t.Fatalf("Name is %T, not a TextView", view)
+	}
+	if name.Text() != "Ada" {
+		t.Error("Name text:", name.Text())
+	}
+	// A checkbox that isn't labelized is in a stack with its title, named after the field too.
+	view, _, _ = fv.FindNamedViewOrInLabelized("Active")
+	stack, _ := view.(*zcontainer.StackView)
+	if stack == nil {
+		t.Fatalf("Active is %T, not a StackView", view)
+	}
+	view, _ = stack.FindViewWithName("Active", false)
+	active, _ := view.(*zcheckbox.CheckBox)
+	if active == nil || active.Value() != zbool.True {
+		t.Fatalf("Active is %T, not a checked CheckBox", view)
+	}
+	view, _, _ = fv.FindNamedViewOrInLabelized("Note")
+	note, _ := view.(*zlabel.Label)
+	if note == nil || note.Text() != "read-only" {
+		t.Errorf("static Note is %T, not a Label with its value", view)
+	}
+
+	name.SetText("Grace")
+	active.SetValue(zbool.False)
+	err := fv.ToData(false)
+	if err != nil {
+		t.Fatal("ToData:", err)
+	}
+	if p.Name != "Grace" || p.Active {
+		t.Errorf("ToData didn't set edited values: %+v", p)
+	}
+}
+
+func TestSnapshotGolden(t *testing.T) {
+	SnapshotWithRects = false
+	defer func() { SnapshotWithRects = true }()
+	p := headlessPerson{Name: "Ada", Age: 36, Note: "read-only"}
+	err := CompareSnapshotWithGolden(&p, DefaultFieldViewParameters, zgeo.SizeD(400, 300), "testdata/headlessPerson.txt", *update)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	p := headlessPerson{Name: "Ada", Age: 36, Note: "read-only"}
+	snap := Snapshot(&p, DefaultFieldViewParameters, zgeo.SizeD(400, 300))
+	if !strings.Contains(snap, `Name: ztext.TextView/input title="Full Name" rect=[`) {
+		t.Error("snapshot has no Name rect:\n" + snap)
+	}
+	if snap != Snapshot(&p, DefaultFieldViewParameters, zgeo.SizeD(400, 300)) {
+		t.Error("snapshot isn't stable")
+	}
+
+	goldenPath := filepath.Join(t.TempDir(), "person.txt")
+	err := CompareSnapshotWithGolden(&p, DefaultFieldViewParameters, zgeo.SizeD(400, 300), goldenPath, false)
+	if err == nil {
+		t.Error("missing golden passed")
+	}
+	err = CompareSnapshotWithGolden(&p, DefaultFieldViewParameters, zgeo.SizeD(400, 300), goldenPath, true)
+	if err != nil {
+		t.Fatal("update golden:", err)
+	}
+	err = CompareSnapshotWithGolden(&p, DefaultFieldViewParameters, zgeo.SizeD(400, 300), goldenPath, false)
+	if err != nil {
+		t.Error("same struct differs from its golden:", err)
+	}
+	err = CompareSnapshotWithGolden(&headlessRenamed{}, DefaultFieldViewParameters, zgeo.SizeD(400, 300), goldenPath, false)
+	if err == nil {
+		t.Error("different struct matched golden")
+	}
+	_, err = os.Stat(goldenPath + ".failed")
+	if err != nil {
+		t.Error("no .failed snapshot written:", err)
+	}
+}
+
+type headlessRenamed struct {
+	Name string `zui:"title:Given Name"`
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/torlangballe/zui/zstyle"
 	"github.com/torlangballe/zui/ztext"
 	"github.com/torlangballe/zui/ztextinfo"
+	"github.com/torlangballe/zui/zundo"
 	"github.com/torlangballe/zui/zview"
 	"github.com/torlangballe/zui/zwidgets"
 	"github.com/torlangballe/zui/zwindow"
@@ -75,6 +76,7 @@ type FieldViewParameters struct {
 	IsEditOnNewStruct         bool                                 // IsEditOnNewStruct when an just-created struct is being edited. Menus can have a storage key-value to set last-used option then for example
 	triggerHandlers           map[trigger]func(ap ActionPack) bool // triggerHandlers is a map of functions to call if an action occurs in this FieldView. Somewhat replacing ActionHandler
 	CreateActionMenuItemsFunc func(sid string) []zmenu.MenuedOItem // If a field with FlagIsActions is found, this func is called to create the action items.
+	UndoManager               *zundo.Manager                       // If UndoManager is set, an edit done with EditOrViewStruct is added to it. Undoing sets the struct back, and updates FieldViews showing it.
 }
 
 type ActionPack struct {
@@ -104,7 +106,6 @@ type FieldViewOwner interface {
 
 var (
 	fieldViewEdited         = map[string]time.Time{}
-	textFilters             = map[string]func(string) string{}
 	EnableLog               zlog.Enabler
 	enumEditHandlers        = map[string]func(item *zmenu.MenuedOItem, action zmenu.EditAction){}
 	fieldToTextTransformers = map[string]func(val, structure any, label *zlabel.Label){}
@@ -121,21 +122,6 @@ func init() {
 	var dl DocumentationLink
 	zreflect.DefaultTypeRegistrar.Register(dl, nil)
 	zlog.RegisterEnabler("zfields.LogGUI", &EnableLog)
-
-	RegisterTextFilter("$nowhite", func(s string) string {
-		return zstr.WhitespaceRemover.Replace(s)
-	})
-	RegisterTextFilter("$trim", strings.TrimSpace)
-	RegisterTextFilter("$lower", strings.ToLower)
-	RegisterTextFilter("$upper", strings.ToLower)
-	RegisterTextFilter("$uuid", zstr.CreateFilterFunction(zstr.IsRuneValidInUUID))
-	RegisterTextFilter("$hex", zstr.CreateFilterFunction(zstr.IsRuneHex))
-	RegisterTextFilter("$alpha", zstr.CreateFilterFunction(zstr.IsRuneASCIIAlpha))
-	RegisterTextFilter("$num", zstr.CreateFilterFunction(zstr.IsRuneASCIINumeric))
-	RegisterTextFilter("$alphanum", zstr.CreateFilterFunction(zstr.IsRuneASCIIAlphaNumeric))
-	RegisterTextFilter("$headerkey", zstr.CreateFilterFunction(zhttp.IsRuneValidForHeaderKey))
-	RegisterTextFilter("$ascii", zstr.CreateFilterFunction(zstr.IsRuneASCIIPrintable))
-
 }
 
 func RegisterFieldTransformer(name string, trans func(val, structure any, label *zlabel.Label)) {
@@ -149,14 +135,6 @@ func RegisterFieldTransformerText(name string, trans func(val, structure any) st
 	}
 }
 
-func RegisterTextFilter(name string, filter func(string) string) {
-	textFilters[name] = filter
-}
-
-func GetTextFilter(name string) func(string) string {
-	return textFilters[name]
-}
-
 func RegisterEnumEditHandler(enumName string, handler func(item *zmenu.MenuedOItem, action zmenu.EditAction)) {
 	enumEditHandlers[enumName] = handler
 }
@@ -1538,24 +1516,6 @@ func setDocumentationLink(label *zlabel.Label, rval reflect.Value) {
 	})
 }
 
-func getFilterFuncFromFilterNames(names []string, f *Field) func(string) string {
-	var funcs []func(string) string
-	for _, fname := range names {
-		fn := GetTextFilter(fname)
-		if fn == nil {
-			zlog.Error("No registered text filter for:", fname, f.FieldName)
-			continue
-		}
-		funcs = append(funcs, fn)
-	}
-	return func(s string) string {
-		for _, fn := range funcs {
-			s = fn(s)
-		}
-		return s
-	}
-}
-
 func (v *FieldView) invokeAction(ap ActionPack) {
 	if v.callTriggerHandler(ap) {
 		return
@@ -2688,12 +2648,44 @@ func EditOrViewStruct[S any](structPtr *S, isReadOnly bool, params FieldViewPara
 	EditOrViewStructSlice(&slice, isReadOnly, params, title, att, func(ok bool) (close bool) {
 		// zlog.Info("PresentOKCancelStruct:", ok, slice[0])
 		if ok {
-			*structPtr = slice[0]
+			before := *structPtr
+			after := slice[0]
+			*structPtr = after
+			if params.UndoManager != nil && !isReadOnly {
+				params.UndoManager.Add(zundo.Action{
+					Name: strings.TrimRight(title, ":…"),
+					Undo: func() {
+						*structPtr = before
+						updateFieldViewsWithData(structPtr)
+					},
+					Redo: func() {
+						*structPtr = after
+						updateFieldViewsWithData(structPtr)
+					},
+				})
+			}
 		}
 		return done(ok)
 	})
 }
 
+// updateFieldViewsWithData updates the FieldViews in all windows that show structPtr, after it is set by undo or redo.
+func updateFieldViewsWithData(structPtr any) {
+	update := func(view zview.View) bool {
+		fv := viewToFieldView(view)
+		if fv != nil && fv.data == structPtr {
+			fv.Update(nil, false, true)
+		}
+		return true
+	}
+	for _, win := range zwindow.All() {
+		for _, view := range win.ViewsStack {
+			update(view)
+			zcontainer.ViewRangeChildren(view, true, true, update)
+		}
+	}
+}
+
 func (v *FieldView) CreateStoreKeyForField(f *Field, name string) string {
 	h := v.ObjectName()
 	if f.ValueStoreKey != "" {
@@ -0,0 +1,226 @@
+package zfields
+
+import (
+	"reflect"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/torlangballe/zutil/zreflect"
+	"github.com/torlangballe/zutil/zstr"
+)
+
+// JSONSchemaVersion is the JSON Schema dialect set in the $schema of JSONSchemaForStruct's result.
+const JSONSchemaVersion = "https://json-schema.org/draft/2020-12/schema"
+
+// JSONSchema is the part of a JSON Schema that can be generated from zui tags.
+// The same schema objects are valid in OpenAPI 3.1 components, see OpenAPISchemas.
+// Things without a JSON Schema keyword are output as x-zui- extensions.
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Default              any                    `json:"default,omitempty"`
+	Const                any                    `json:"const,omitempty"`
+	ReadOnly             bool                   `json:"readOnly,omitempty"`
+	WriteOnly            bool                   `json:"writeOnly,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+	AllOf                []*JSONSchema          `json:"allOf,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	ZUIFormat            string                 `json:"x-zui-format,omitempty"`     // ZUIFormat is Field.Format if it is a named one like memory or bps, or duration.
+	ZUIMinWidth          float64                `json:"x-zui-minWidth,omitempty"`   // ZUIMinWidth is Field.MinWidth, the minimum width in pixels the field is shown with.
+	ZUIMaxWidth          float64                `json:"x-zui-maxWidth,omitempty"`   // ZUIMaxWidth is Field.MaxWidth.
+	ZUIFilters           []string               `json:"x-zui-filters,omitempty"`    // ZUIFilters are Field.Filters, which are applied to input.
+	ZUIInvalid           string                 `json:"x-zui-invalid,omitempty"`    // ZUIInvalid is past or future for times not allowed.
+	ZUIAllowEmpty        bool                   `json:"x-zui-allowEmpty,omitempty"` // ZUIAllowEmpty is set for allowempty, where a zero value is shown as Field.ZeroText.
+}
+
+var namedFormats = map[string]bool{
+	MemoryFormat:  true,
+	StorageFormat: true,
+	BPSFormat:     true,
+	HumanFormat:   true,
+	"nice":        true,
+}
+
+// JSONSchemaForStruct returns a JSON Schema for structure, a struct or pointer to one, from its fields' zui and json tags.
+// Title, Description, enums registered with SetEnum, Required and required groups, Default, widths and formats are used.
+// Marshal the result with encoding/json; properties are output sorted, so the output is stable.
+func JSONSchemaForStruct(structure any) *JSONSchema {
+	s := jsonSchemaForType(reflect.TypeOf(structure), map[reflect.Type]bool{})
+	s.Schema = JSONSchemaVersion
+	return s
+}
+
+// OpenAPISchemas returns schemas for structures keyed by their type name, for use as components/schemas in an OpenAPI 3.1 document.
+func OpenAPISchemas(structures ...any) map[string]*JSONSchema {
+	m := map[string]*JSONSchema{}
+	for _, s := range structures {
+		t := reflect.TypeOf(s)
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+		m[t.Name()] = jsonSchemaForType(t, map[reflect.Type]bool{})
+	}
+	return m
+}
+
+func jsonSchemaForType(t reflect.Type, visited map[reflect.Type]bool) *JSONSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	s := &JSONSchema{}
+	kind := zreflect.KindFromReflectKindAndType(t.Kind(), t)
+	switch kind {
+	case zreflect.KindBool:
+		s.Type = "boolean"
+	case zreflect.KindInt:
+		s.Type = "integer"
+		if t == reflect.TypeOf(time.Duration(0)) {
+			s.ZUIFormat = "duration"
+		}
+	case zreflect.KindFloat:
+		s.Type = "number"
+	case zreflect.KindString:
+		s.Type = "string"
+	case zreflect.KindTime:
+		s.Type = "string"
+		s.Format = "date-time"
+	case zreflect.KindSlice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			s.Type = "string"
+			s.Format = "byte"
+			break
+		}
+		s.Type = "array"
+		s.Items = jsonSchemaForType(t.Elem(), visited)
+	case zreflect.KindMap:
+		s.Type = "object"
+		s.AdditionalProperties = jsonSchemaForType(t.Elem(), visited)
+	case zreflect.KindStruct:
+		s.Type = "object"
+		if visited[t] { // recursive type, we don't go further
+			break
+		}
+		visited[t] = true
+		addStructProperties(s, t, visited)
+		delete(visited, t)
+	}
+	return s
+}
+
+func addStructProperties(s *JSONSchema, t reflect.Type, visited map[reflect.Type]bool) {
+	groups := map[string][]string{}
+	params := FieldParameters{IgnoreUseInAndINTags: true}
+	s.Properties = map[string]*JSONSchema{}
+	ForEachField(reflect.New(t).Interface(), params, nil, func(each FieldInfo) bool {
+		f := each.Field
+		name := f.FieldName
+		jsonVals, skip := zreflect.TagValuesForKey(each.StructField.Tag, "json")
+		if skip {
+			return true
+		}
+		if len(jsonVals) > 0 && jsonVals[0] != "" {
+			name = jsonVals[0]
+		}
+		ps := jsonSchemaForType(each.StructField.Type, visited)
+		f.setJSONSchema(ps)
+		s.Properties[name] = ps
+		switch f.Required {
+		case "":
+		case RequiredSingleValue:
+			s.Required = append(s.Required, name)
+		default:
+			groups[f.Required] = append(groups[f.Required], name)
+		}
+		return true
+	})
+	// Each required group needs one of its fields set, which is anyOf with a required for each.
+	// Several groups must all be fulfilled, so become allOf those.
+	var ids []string
+	for id := range groups {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		var anyOf []*JSONSchema
+		for _, name := range groups[id] {
+			anyOf = append(anyOf, &JSONSchema{Required: []string{name}})
+		}
+		if len(ids) == 1 {
+			s.AnyOf = anyOf
+			break
+		}
+		s.AllOf = append(s.AllOf, &JSONSchema{AnyOf: anyOf})
+	}
+}
+
+// setJSONSchema sets the parts of s that come from f's tags.
+func (f *Field) setJSONSchema(s *JSONSchema) {
+	s.Title = f.Title
+	s.Description = f.Description
+	if s.Description == "" {
+		s.Description = f.Tooltip
+	}
+	if namedFormats[f.Format] {
+		s.ZUIFormat = f.Format
+	}
+	s.ZUIMinWidth = f.MinWidth
+	s.ZUIMaxWidth = f.MaxWidth
+	s.ZUIFilters = f.Filters
+	s.ReadOnly = f.IsStatic()
+	s.WriteOnly = f.HasFlag(FlagIsPassword)
+	s.ZUIAllowEmpty = f.HasFlag(FlagAllowEmptyAsZero)
+	if zstr.StringsContain(f.Filters, "$uuid") {
+		s.Format = "uuid"
+	}
+	if f.HasFlag(FlagPastInvalid) {
+		s.ZUIInvalid = "past"
+	} else if f.HasFlag(FlagFutureInvalid) {
+		s.ZUIInvalid = "future"
+	}
+	if f.HasFlag(FlagHasDefault) {
+		s.Default = jsonSchemaValue(s.Type, f.Default)
+	}
+	enumName := f.Enum
+	if enumName == "" {
+		enumName = f.Radio
+	}
+	if enumName != "" {
+		es := s
+		if s.Type == "array" { // a slice field has a slice of enum values, so each item is one of them
+			es = s.Items
+		}
+		for _, item := range GetEnum(enumName) {
+			es.OneOf = append(es.OneOf, &JSONSchema{Const: item.Value, Title: item.Name})
+		}
+	}
+}
+
+// jsonSchemaValue converts str to the JSON type typ, returning str as-is if it doesn't parse.
+func jsonSchemaValue(typ, str string) any {
+	switch typ {
+	case "integer":
+		n, err := strconv.ParseInt(str, 10, 64)
+		if err == nil {
+			return n
+		}
+	case "number":
+		n, err := strconv.ParseFloat(str, 64)
+		if err == nil {
+			return n
+		}
+	case "boolean":
+		b, err := strconv.ParseBool(str)
+		if err == nil {
+			return b
+		}
+	}
+	return str
+}
@@ -0,0 +1,193 @@
+package zfields
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type jsonSchemaTestSub struct {
+	Name string `zui:"required"`
+}
+
+type jsonSchemaTestStruct struct {
+	Port     int           `zui:"title:Server Port,desc:Port to listen on,default:8080,minwidth:60"`
+	Ratio    float64       `zui:"default:0.5"`
+	On       bool          `zui:"default:true,tip:Turns it on"`
+	Host     string        `zui:"required,default:localhost"`
+	Email    string        `zui:"required:contact" json:"email"`
+	Phone    string        `zui:"required:contact"`
+	Mode     string        `zui:"enum:jsonschematest.modes"`
+	Modes    []string      `zui:"enum:jsonschematest.modes"`
+	Level    int           `zui:"radio:jsonschematest.levels"`
+	ID       string        `zui:"filter:$uuid,static"`
+	Secret   string        `zui:"password"`
+	Wait     time.Duration `zui:"default:5s"`
+	Memory   int64         `zui:"format:memory"`
+	Expires  time.Time     `zui:"invalid:past"`
+	Data     []byte
+	Sub      jsonSchemaTestSub
+	Subs     []jsonSchemaTestSub
+	Counts   map[string]int
+	Skipped  string `json:"-"`
+	internal string
+}
+
+func makeJSONSchemaTest(t *testing.T) *JSONSchema {
+	t.Helper()
+	SetStringBasedEnum("jsonschematest.modes", "fast", "slow")
+	SetEnumItems("jsonschematest.levels", "Low", 1, "High", 2)
+	s := JSONSchemaForStruct(&jsonSchemaTestStruct{})
+	if s.Schema != JSONSchemaVersion || s.Type != "object" {
+		t.Fatal("root:", s.Schema, s.Type)
+	}
+	return s
+}
+
+func jsonSchemaProperty(t *testing.T, s *JSONSchema, name string) *JSONSchema {
+	t.Helper()
+	p := s.Properties[name]
+	if p == nil {
+		t.Fatal("no property:", name)
+	}
+	return p
+}
+
+func TestJSONSchemaTypesAndFormats(t *testing.T) {
+	s := makeJSONSchemaTest(t)
+	tests := []struct {
+		name, typ, format, zuiFormat string
+	}{
+		{"Port", "integer", "", ""},
+		{"Ratio", "number", "", ""},
+		{"On", "boolean", "", ""},
+		{"Host", "string", "", ""},
+		{"ID", "string", "uuid", ""},
+		{"Wait", "integer", "", "duration"},
+		{"Memory", "integer", "", "memory"},
+		{"Expires", "string", "date-time", ""},
+		{"Data", "string", "byte", ""},
+		{"Sub", "object", "", ""},
+		{"Subs", "array", "", ""},
+		{"Counts", "object", "", ""},
+	}
+	for _, test := range tests {
+		p := jsonSchemaProperty(t, s, test.name)
+		if p.Type != test.typ || p.Format != test.format || p.ZUIFormat != test.zuiFormat {
+			t.Errorf("%s: %q %q %q, want %q %q %q", test.name, p.Type, p.Format, p.ZUIFormat, test.typ, test.format, test.zuiFormat)
+		}
+	}
+	for _, name := range []string{"Skipped", "internal", "Email"} {
+		if s.Properties[name] != nil {
+			t.Error("property shouldn't be there:", name)
+		}
+	}
+	if jsonSchemaProperty(t, s, "Subs").Items.Properties["Name"] == nil {
+		t.Error("slice of structs has no item properties")
+	}
+	if jsonSchemaProperty(t, s, "Counts").AdditionalProperties.Type != "integer" {
+		t.Error("map values:", s.Properties["Counts"].AdditionalProperties)
+	}
+	expires := jsonSchemaProperty(t, s, "Expires")
+	if expires.ZUIInvalid != "past" {
+		t.Error("invalid:", expires.ZUIInvalid)
+	}
+	if !jsonSchemaProperty(t, s, "ID").ReadOnly || !jsonSchemaProperty(t, s, "Secret").WriteOnly {
+		t.Error("read-only or write-only not set")
+	}
+}
+
+func TestJSONSchemaTitleDescriptionDefault(t *testing.T) {
+	s := makeJSONSchemaTest(t)
+	port := jsonSchemaProperty(t, s, "Port")
+	if port.Title != "Server Port" || port.Description != "Port to listen on" || port.ZUIMinWidth != 60 {
+		t.Errorf("port: %+v", port)
+	}
+	if on := jsonSchemaProperty(t, s, "On"); on.Description != "Turns it on" {
+		t.Error("tooltip as description:", on.Description)
+	}
+	defaults := map[string]any{
+		"Port":  int64(8080),
+		"Ratio": 0.5,
+		"On":    true,
+		"Host":  "localhost",
+		"Wait":  "5s", // a duration's default isn't an integer, so it is kept as-is
+	}
+	for name, want := range defaults {
+		if got := jsonSchemaProperty(t, s, name).Default; got != want {
+			t.Errorf("%s default: %#v, want %#v", name, got, want)
+		}
+	}
+	if got := jsonSchemaProperty(t, s, "Phone").Default; got != nil {
+		t.Error("default without default tag:", got)
+	}
+}
+
+func TestJSONSchemaRequired(t *testing.T) {
+	s := makeJSONSchemaTest(t)
+	if !reflect.DeepEqual(s.Required, []string{"Host"}) {
+		t.Error("required:", s.Required)
+	}
+	want := []*JSONSchema{{Required: []string{"email"}}, {Required: []string{"Phone"}}}
+	if !reflect.DeepEqual(s.AnyOf, want) || s.AllOf != nil {
+		t.Errorf("one group: %+v %+v", s.AnyOf, s.AllOf)
+	}
+	if sub := jsonSchemaProperty(t, s, "Sub"); !reflect.DeepEqual(sub.Required, []string{"Name"}) {
+		t.Error("sub required:", sub.Required)
+	}
+
+	type twoGroups struct {
+		A string `zui:"required:g1"`
+		B string `zui:"required:g1"`
+		C string `zui:"required:g2"`
+	}
+	s = JSONSchemaForStruct(twoGroups{})
+	wantAll := []*JSONSchema{
+		{AnyOf: []*JSONSchema{{Required: []string{"A"}}, {Required: []string{"B"}}}},
+		{AnyOf: []*JSONSchema{{Required: []string{"C"}}}},
+	}
+	if s.AnyOf != nil || !reflect.DeepEqual(s.AllOf, wantAll) {
+		t.Errorf("two groups: %+v %+v", s.AnyOf, s.AllOf)
+	}
+}
+
+func TestJSONSchemaEnums(t *testing.T) {
+	s := makeJSONSchemaTest(t)
+	modes := []*JSONSchema{{Const: "fast", Title: "fast"}, {Const: "slow", Title: "slow"}}
+	if mode := jsonSchemaProperty(t, s, "Mode"); !reflect.DeepEqual(mode.OneOf, modes) {
+		t.Errorf("enum: %+v", mode.OneOf)
+	}
+	list := jsonSchemaProperty(t, s, "Modes")
+	if list.OneOf != nil || !reflect.DeepEqual(list.Items.OneOf, modes) {
+		t.Errorf("enum slice: %+v %+v", list.OneOf, list.Items.OneOf)
+	}
+	levels := []*JSONSchema{{Const: 1, Title: "Low"}, {Const: 2, Title: "High"}}
+	if level := jsonSchemaProperty(t, s, "Level"); !reflect.DeepEqual(level.OneOf, levels) {
+		t.Errorf("radio: %+v", level.OneOf)
+	}
+}
+
+func TestJSONSchemaMarshal(t *testing.T) {
+	s := makeJSONSchemaTest(t)
+	data, err := json.Marshal(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := json.Marshal(makeJSONSchemaTest(t))
+	if string(data) != string(again) {
+		t.Error("output not stable")
+	}
+	var m map[string]any
+	json.Unmarshal(data, &m)
+	props := m["properties"].(map[string]any)
+	port := props["Port"].(map[string]any)
+	if m["$schema"] != JSONSchemaVersion || port["x-zui-minWidth"] != 60.0 || port["default"] != 8080.0 {
+		t.Error("json:", string(data))
+	}
+
+	schemas := OpenAPISchemas(&jsonSchemaTestSub{})
+	if sub := schemas["jsonSchemaTestSub"]; sub == nil || sub.Schema != "" || sub.Properties["Name"] == nil {
+		t.Errorf("openapi: %+v", schemas)
+	}
+}
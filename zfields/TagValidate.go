@@ -0,0 +1,397 @@
+package zfields
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/torlangballe/zutil/zgeo"
+	"github.com/torlangballe/zutil/zreflect"
+	"github.com/torlangballe/zutil/zstr"
+)
+
+// This file is about validating zui tags without building anything from them.
+// Field.SetFromRVal puts unknown keys in CustomFields and ignores bad values,
+// so ValidateTag and ValidateStruct are used to catch typos, and the ztaglint analyzer uses them on source code.
+
+type tagValueKind int
+
+const (
+	tagValueText      tagValueKind = iota // anything goes
+	tagValueNone                          // a flag, no value expected
+	tagValueBool                          // empty or a bool
+	tagValueNumber                        // a number
+	tagValueOptNumber                     // empty or a number
+	tagValueAlignment                     // alignment string like "left|top"
+	tagValueOptAlign                      // empty or an alignment
+	tagValueSize                          // a size like "20x30"
+	tagValueSizeOrNum                     // a size or a single number
+	tagValueChoice                        // one of choices, empty allowed if "" is in choices
+	tagValueChoices                       // bar-separated parts, each one of choices
+	tagValueEnum                          // a registered enum name
+	tagValueOptEnum                       // empty, a registered enum name, or ./LocalField
+	tagValueSort                          // bar-separated sort priority number and/or bigzero
+	tagValueImage                         // bar-separated image path and/or size
+)
+
+type tagKeyInfo struct {
+	Kind    tagValueKind
+	Choices []string
+}
+
+// tagKeys is all the keys Field.SetFromRVal understands, and what values they take.
+// It must be updated when keys are added there.
+var tagKeys = map[string]tagKeyInfo{
+	"IN":          {Kind: tagValueText},
+	"search":      {Kind: tagValueNone},
+	"noguisearch": {Kind: tagValueNone},
+	"password":    {Kind: tagValueChoice, Choices: []string{"", "existing"}},
+	"setedited":   {Kind: tagValueBool},
+	"format":      {Kind: tagValueText},
+	"vertical":    {Kind: tagValueNone},
+	"horizontal":  {Kind: tagValueNone},
+	"align":       {Kind: tagValueAlignment},
+	"celljustify": {Kind: tagValueOptAlign},
+	"justify":     {Kind: tagValueOptAlign},
+	"wrap":        {Kind: tagValueText},
+	"name":        {Kind: tagValueText},
+	"title":       {Kind: tagValueText},
+	"header":      {Kind: tagValueText},
+	"prefix":      {Kind: tagValueText},
+	"suffix":      {Kind: tagValueText},
+	"url":         {Kind: tagValueText},
+	"doc":         {Kind: tagValueText},
+	"usein":       {Kind: tagValueText},
+	"rebuild":     {Kind: tagValueNone},
+	"sep":         {Kind: tagValueText},
+	"mod":         {Kind: tagValueText},
+	"hlockable":   {Kind: tagValueChoice, Choices: []string{"", "start", "end"}},
+	"lockable":    {Kind: tagValueNone},
+	"filter":      {Kind: tagValueText},
+	"trans":       {Kind: tagValueText},
+	"count":       {Kind: tagValueNone},
+	"isuseinval":  {Kind: tagValueNone},
+	"popup":       {Kind: tagValueNone},
+	"color":       {Kind: tagValueText},
+	"bgcolor":     {Kind: tagValueText},
+	"download":    {Kind: tagValueText},
+	"zrpc":        {Kind: tagValueText},
+	"zdebug":      {Kind: tagValueNone},
+	"height":      {Kind: tagValueNumber},
+	"width":       {Kind: tagValueNumber},
+	"cols":        {Kind: tagValueNumber},
+	"rows":        {Kind: tagValueNumber},
+	"optional":    {Kind: tagValueOptNumber},
+	"widget":      {Kind: tagValueText},
+	"descending":  {Kind: tagValueSort},
+	"ascending":   {Kind: tagValueSort},
+	"actions":     {Kind: tagValueNone},
+	"noautofill":  {Kind: tagValueNone},
+	"size":        {Kind: tagValueSizeOrNum},
+	"marg":        {Kind: tagValueSize},
+	"minwidth":    {Kind: tagValueNumber},
+	"spacing":     {Kind: tagValueNumber},
+	"storekey":    {Kind: tagValueText},
+	"default":     {Kind: tagValueText},
+	"allowempty":  {Kind: tagValueNone},
+	"omitzero":    {Kind: tagValueNone},
+	"required":    {Kind: tagValueText},
+	"zerotext":    {Kind: tagValueText},
+	"maxtext":     {Kind: tagValueText},
+	"invalid":     {Kind: tagValueChoice, Choices: []string{"past", "future"}},
+	"open":        {Kind: tagValueNone},
+	"static":      {Kind: tagValueBool},
+	"fracts":      {Kind: tagValueNumber},
+	"secs":        {Kind: tagValueNone},
+	"oldsecs":     {Kind: tagValueNumber},
+	"mins":        {Kind: tagValueNone},
+	"hours":       {Kind: tagValueNone},
+	"maxwidth":    {Kind: tagValueNumber},
+	"press":       {Kind: tagValueNone},
+	"longpress":   {Kind: tagValueNone},
+	"group":       {Kind: tagValueChoices, Choices: []string{"", "titled", "skipindicator", "onframe", "single"}},
+	"frame":       {Kind: tagValueChoices, Choices: []string{"", "titled", "onframe"}},
+	"fixed":       {Kind: tagValueNone},
+	"opaque":      {Kind: tagValueNone},
+	"shadow":      {Kind: tagValueText},
+	"font":        {Kind: tagValueText},
+	"path":        {Kind: tagValueText},
+	"off":         {Kind: tagValueText},
+	"opener":      {Kind: tagValueNone},
+	"image":       {Kind: tagValueImage},
+	"himage":      {Kind: tagValueImage},
+	"radio":       {Kind: tagValueEnum},
+	"enum":        {Kind: tagValueOptEnum},
+	"notitle":     {Kind: tagValueNone},
+	"tip":         {Kind: tagValueText},
+	"desc":        {Kind: tagValueText},
+	"immediate":   {Kind: tagValueNone},
+	"upsecs":      {Kind: tagValueNumber},
+	"checker":     {Kind: tagValueNone},
+	"2clip":       {Kind: tagValueNone},
+	"fromclip":    {Kind: tagValueNone},
+	"labelize":    {Kind: tagValueChoice, Choices: []string{"", "withdesc"}},
+	"unlabled":    {Kind: tagValueNone},
+	"button":      {Kind: tagValueNone},
+	"ask":         {Kind: tagValueText},
+	"enable":      {Kind: tagValueText},
+	"disable":     {Kind: tagValueText},
+	"show":        {Kind: tagValueText},
+	"hide":        {Kind: tagValueText},
+	"placeholder": {Kind: tagValueText},
+	"dur":         {Kind: tagValueNone},
+	"since":       {Kind: tagValueNone},
+}
+
+// conflictingTagKeys are pairs of keys that undo or contradict each other if both are in a tag.
+var conflictingTagKeys = [][2]string{
+	{"vertical", "horizontal"},
+	{"ascending", "descending"},
+	{"enum", "radio"},
+	{"show", "hide"},
+	{"enable", "disable"},
+	{"static", "required"},
+	{"labelize", "unlabled"},
+}
+
+// customTagKeys are keys that end up in Field.CustomFields, and are used by widgets or others outside this package.
+var customTagKeys = map[string]bool{}
+
+// TagIssue is a problem found in a zui tag by ValidateTag or ValidateStruct.
+type TagIssue struct {
+	FieldName string // FieldName is the struct type and field name, if known
+	Key       string
+	Value     string
+	Message   string
+}
+
+func (i TagIssue) Error() string {
+	str := fmt.Sprintf("zui tag %q: %s", i.Key, i.Message)
+	if i.FieldName != "" {
+		str = i.FieldName + ": " + str
+	}
+	return str
+}
+
+// RegisterCustomTagKeys makes ValidateTag accept keys that Field.SetFromRVal doesn't know,
+// but which are read from Field.CustomFields by widgets or other packages.
+func RegisterCustomTagKeys(keys ...string) {
+	for _, k := range keys {
+		customTagKeys[k] = true
+	}
+}
+
+// IsKnownTagKey returns true if key is understood by Field.SetFromRVal or registered with RegisterCustomTagKeys.
+func IsKnownTagKey(key string) bool {
+	_, got := tagKeys[key]
+	return got || customTagKeys[key]
+}
+
+// KnownTagKeys returns all keys Field.SetFromRVal understands, sorted.
+func KnownTagKeys() []string {
+	keys := make([]string, 0, len(tagKeys))
+	for k := range tagKeys {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ValidateTag checks the keys and values of the zui tag string tag, returning any issues found.
+// enumExists is used to check enum and radio names. If it is nil, enums registered with SetEnum etc are used.
+// localFieldExists, if not nil, is used to check ./FieldName references in enum values.
+func ValidateTag(tag string, enumExists, localFieldExists func(name string) bool) []TagIssue {
+	var issues []TagIssue
+	if tag == "" {
+		return nil
+	}
+	keyVals, skip := zreflect.TagKeyValuesFromString(tag)
+	if skip {
+		return nil
+	}
+	if enumExists == nil {
+		enumExists = func(name string) bool {
+			_, got := fieldEnums[name]
+			return got
+		}
+	}
+	add := func(kv zstr.KeyValue, format string, args ...any) {
+		issues = append(issues, TagIssue{Key: kv.Key, Value: kv.Value, Message: fmt.Sprintf(format, args...)})
+	}
+	has := map[string]bool{}
+	for _, kv := range keyVals {
+		if has[kv.Key] {
+			add(kv, "key used more than once")
+		}
+		has[kv.Key] = true
+		info, got := tagKeys[kv.Key]
+		if !got {
+			if !customTagKeys[kv.Key] {
+				add(kv, "unknown key%s", similarTagKeyHint(kv.Key))
+			}
+			continue
+		}
+		msg := validateTagValue(info, kv.Value, enumExists, localFieldExists)
+		if msg != "" {
+			add(kv, "%s: %q", msg, kv.Value)
+		}
+	}
+	for _, pair := range conflictingTagKeys {
+		if has[pair[0]] && has[pair[1]] {
+			issues = append(issues, TagIssue{Key: pair[0], Message: "conflicts with key " + pair[1]})
+		}
+	}
+	return issues
+}
+
+func validateTagValue(info tagKeyInfo, val string, enumExists, localFieldExists func(name string) bool) string {
+	switch info.Kind {
+	case tagValueNone:
+		if val != "" {
+			return "takes no value"
+		}
+	case tagValueBool:
+		if val != "" && val != "true" && val != "false" && val != "1" && val != "0" {
+			return "not a bool"
+		}
+	case tagValueNumber, tagValueOptNumber:
+		if val == "" && info.Kind == tagValueOptNumber {
+			break
+		}
+		if _, err := strconv.ParseFloat(val, 64); err != nil {
+			return "not a number"
+		}
+	case tagValueAlignment, tagValueOptAlign:
+		if val == "" && info.Kind == tagValueOptAlign {
+			break
+		}
+		if zgeo.AlignmentFromString(val) == zgeo.AlignmentNone {
+			return "bad alignment"
+		}
+	case tagValueSize:
+		if _, err := zgeo.SizeFromString(val); err != nil {
+			return "bad size"
+		}
+	case tagValueSizeOrNum:
+		_, err := zgeo.SizeFromString(val)
+		_, nerr := strconv.ParseFloat(val, 64)
+		if err != nil && nerr != nil {
+			return "not a size or number"
+		}
+	case tagValueChoice:
+		if !zstr.StringsContain(info.Choices, val) {
+			return "should be one of " + strings.Join(info.Choices, ", ")
+		}
+	case tagValueChoices:
+		for _, part := range strings.Split(val, "|") {
+			if !zstr.StringsContain(info.Choices, part) {
+				return "parts should be of " + strings.Join(info.Choices, ", ")
+			}
+		}
+	case tagValueEnum, tagValueOptEnum:
+		if val == "" && info.Kind == tagValueOptEnum {
+			break
+		}
+		var local string
+		if info.Kind == tagValueOptEnum && zstr.HasPrefix(val, "./", &local) {
+			if localFieldExists != nil && !localFieldExists(local) {
+				return "no such local field"
+			}
+			break
+		}
+		if !enumExists(val) {
+			return "enum not registered"
+		}
+	case tagValueSort:
+		for _, part := range strings.Split(val, "|") {
+			if part == "" || part == "bigzero" {
+				continue
+			}
+			if _, err := strconv.Atoi(part); err != nil {
+				return "parts should be a sort priority or bigzero"
+			}
+		}
+	}
+	return ""
+}
+
+// similarTagKeyHint returns a hint of a known key that key is likely a typo of, or "".
+func similarTagKeyHint(key string) string {
+	best := ""
+	bestDist := 3
+	for k := range tagKeys {
+		d := tagKeyDistance(key, k)
+		if d < bestDist || d == bestDist && best != "" && k < best {
+			best = k
+			bestDist = d
+		}
+	}
+	if best == "" || bestDist > 2 {
+		return ""
+	}
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// tagKeyDistance is the Levenshtein distance between a and b, treating a swap of two neighbours as one edit.
+func tagKeyDistance(a, b string) int {
+	d := make([][]int, len(a)+1)
+	for i := range d {
+		d[i] = make([]int, len(b)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return d[len(a)][len(b)]
+}
+
+// ValidateStruct validates the zui tags of all fields in structure, which can be a struct, pointer to one, or a reflect.Type.
+// It goes into fields that are structs, or slices/maps/pointers of them, so a whole form's tags are checked.
+func ValidateStruct(structure any) []TagIssue {
+	t, _ := structure.(reflect.Type)
+	if t == nil {
+		t = reflect.TypeOf(structure)
+	}
+	var issues []TagIssue
+	validateStructType(t, map[reflect.Type]bool{}, &issues)
+	return issues
+}
+
+func validateStructType(t reflect.Type, visited map[reflect.Type]bool, issues *[]TagIssue) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array || t.Kind() == reflect.Map {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || visited[t] || t.PkgPath() == "time" {
+		return
+	}
+	visited[t] = true
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, got := sf.Tag.Lookup("zui")
+		if got {
+			localExists := func(name string) bool {
+				_, got := t.FieldByName(name)
+				return got
+			}
+			for _, issue := range ValidateTag(tag, nil, localExists) {
+				issue.FieldName = t.Name() + "." + sf.Name
+				*issues = append(*issues, issue)
+			}
+		}
+		validateStructType(sf.Type, visited, issues)
+	}
+}
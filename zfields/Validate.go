@@ -0,0 +1,209 @@
+package zfields
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/torlangballe/zutil/zhttp"
+	"github.com/torlangballe/zutil/zlog"
+	"github.com/torlangballe/zutil/zreflect"
+	"github.com/torlangballe/zutil/zstr"
+)
+
+// This file is about applying the zui tags that restrict values (default, filter, required, invalid, enum)
+// to a struct without any gui, so servers can check input the same way edit dialogs do.
+
+// FieldError is a problem with a field's value found by Validate.
+type FieldError struct {
+	FieldPath string // FieldPath is the struct field's name, with parents's names and slice indexes before it for nested structs: Outer.Items[2].Name
+	Title     string // Title is the field's title as shown in gui
+	Message   string
+}
+
+// ValidationErrors is the error returned by Validate if any fields are invalid.
+type ValidationErrors []FieldError
+
+var textFilters = map[string]func(string) string{}
+
+func init() {
+	RegisterTextFilter("$nowhite", func(s string) string {
+		return zstr.WhitespaceRemover.Replace(s)
+	})
+	RegisterTextFilter("$trim", strings.TrimSpace)
+	RegisterTextFilter("$lower", strings.ToLower)
+	RegisterTextFilter("$upper", strings.ToUpper)
+	RegisterTextFilter("$uuid", zstr.CreateFilterFunction(zstr.IsRuneValidInUUID))
+	RegisterTextFilter("$hex", zstr.CreateFilterFunction(zstr.IsRuneHex))
+	RegisterTextFilter("$alpha", zstr.CreateFilterFunction(zstr.IsRuneASCIIAlpha))
+	RegisterTextFilter("$num", zstr.CreateFilterFunction(zstr.IsRuneASCIINumeric))
+	RegisterTextFilter("$alphanum", zstr.CreateFilterFunction(zstr.IsRuneASCIIAlphaNumeric))
+	RegisterTextFilter("$headerkey", zstr.CreateFilterFunction(zhttp.IsRuneValidForHeaderKey))
+	RegisterTextFilter("$ascii", zstr.CreateFilterFunction(zstr.IsRuneASCIIPrintable))
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Title, e.Message)
+}
+
+func (e ValidationErrors) Error() string {
+	var parts []string
+	for _, fe := range e {
+		parts = append(parts, fe.Error())
+	}
+	return strings.Join(parts, "; ")
+}
+
+// ForField returns the error for the field with fieldPath, or nil.
+func (e ValidationErrors) ForField(fieldPath string) *FieldError {
+	for i, fe := range e {
+		if fe.FieldPath == fieldPath {
+			return &e[i]
+		}
+	}
+	return nil
+}
+
+// RegisterTextFilter registers a filter by name, for use in the filter tag: zui:"filter:$lower|myfilter".
+func RegisterTextFilter(name string, filter func(string) string) {
+	textFilters[name] = filter
+}
+
+func GetTextFilter(name string) func(string) string {
+	return textFilters[name]
+}
+
+func getFilterFuncFromFilterNames(names []string, f *Field) func(string) string {
+	var funcs []func(string) string
+	for _, fname := range names {
+		fn := GetTextFilter(fname)
+		if fn == nil {
+			zlog.Error("No registered text filter for:", fname, f.FieldName)
+			continue
+		}
+		funcs = append(funcs, fn)
+	}
+	return func(s string) string {
+		for _, fn := range funcs {
+			s = fn(s)
+		}
+		return s
+	}
+}
+
+// Validate applies the zui tags of structPtr's fields that restrict values, as FieldView does when editing:
+// Zero fields with a default tag get the default, unless allowempty is set, where zero is a valid value.
+// String fields are run through their filters.
+// Fields with required must be non-zero, or for a required group, at least one in the group must be.
+// Times with invalid:past or invalid:future can't be before/after now.
+// Fields with an enum must have a value from it, or zero if allowempty is set.
+// Nested structs and slices of structs are validated too.
+// A ValidationErrors with an entry per invalid field is returned, or nil if all are valid.
+func Validate(structPtr any) error {
+	return ValidateWithParameters(structPtr, FieldParameters{})
+}
+
+// ValidateWithParameters is like Validate, but params can set UseInValues etc as when building the FieldView for structPtr.
+func ValidateWithParameters(structPtr any, params FieldParameters) error {
+	zlog.Assert(reflect.ValueOf(structPtr).Kind() == reflect.Pointer, reflect.TypeOf(structPtr))
+	var errs ValidationErrors
+	validateStruct(structPtr, params, "", &errs)
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+func validateStruct(structPtr any, params FieldParameters, path string, errs *ValidationErrors) {
+	type group struct {
+		titles  []string
+		first   string
+		fulfill bool
+	}
+	var groups []string
+	groupMap := map[string]*group{}
+	ForEachField(structPtr, params, nil, func(each FieldInfo) bool {
+		f := each.Field
+		rval := each.ReflectValue
+		fpath := path + f.FieldName
+		addErr := func(format string, args ...any) {
+			*errs = append(*errs, FieldError{FieldPath: fpath, Title: f.TitleOrName(), Message: fmt.Sprintf(format, args...)})
+		}
+		allowEmpty := f.HasFlag(FlagAllowEmptyAsZero)
+		if rval.IsZero() && f.HasFlag(FlagHasDefault) && f.Default != "" && !allowEmpty && rval.CanAddr() {
+			zstr.SetStringToAny(rval.Addr().Interface(), f.Default)
+		}
+		if len(f.Filters) != 0 && rval.Kind() == reflect.String && rval.CanSet() {
+			for _, name := range f.Filters {
+				if GetTextFilter(name) == nil {
+					addErr("no text filter registered named %s", name)
+				}
+			}
+			rval.SetString(getFilterFuncFromFilterNames(f.Filters, f)(rval.String()))
+		}
+		zero := rval.IsZero()
+		switch f.Required {
+		case "":
+		case RequiredSingleValue:
+			if zero {
+				addErr("can't be empty")
+			}
+		default:
+			g := groupMap[f.Required]
+			if g == nil {
+				g = &group{first: fpath}
+				groupMap[f.Required] = g
+				groups = append(groups, f.Required)
+			}
+			g.titles = append(g.titles, f.TitleOrName())
+			g.fulfill = g.fulfill || !zero
+		}
+		if !zero && f.Kind == zreflect.KindTime && f.HasFlag(FlagPastInvalid|FlagFutureInvalid) {
+			t, _ := rval.Interface().(time.Time)
+			since := time.Since(t)
+			if f.HasFlag(FlagPastInvalid) && since > 0 {
+				addErr("can't be in the past")
+			} else if f.HasFlag(FlagFutureInvalid) && since < 0 {
+				addErr("can't be in the future")
+			}
+		}
+		if f.Enum != "" && !(zero && allowEmpty) && rval.Kind() != reflect.Slice {
+			enum, got := fieldEnums[f.Enum]
+			if got && enum.FindValue(rval.Interface()) == nil {
+				addErr("%v is not a valid option", rval.Interface())
+			}
+		}
+		validateNested(rval, f, params, fpath, errs)
+		return true
+	})
+	for _, id := range groups {
+		g := groupMap[id]
+		if !g.fulfill {
+			*errs = append(*errs, FieldError{FieldPath: g.first, Title: g.titles[0], Message: "one of " + strings.Join(g.titles, ", ") + " must be set"})
+		}
+	}
+}
+
+func validateNested(rval reflect.Value, f *Field, params FieldParameters, fpath string, errs *ValidationErrors) {
+	switch f.Kind {
+	case zreflect.KindStruct:
+		if rval.CanAddr() {
+			validateStruct(rval.Addr().Interface(), params, fpath+".", errs)
+		}
+	case zreflect.KindSlice:
+		for i := 0; i < rval.Len(); i++ {
+			e := rval.Index(i)
+			if e.Kind() == reflect.Pointer {
+				if e.IsNil() {
+					continue
+				}
+				e = e.Elem()
+			}
+			if e.Kind() != reflect.Struct || e.Type() == reflect.TypeOf(time.Time{}) {
+				return
+			}
+			validateStruct(e.Addr().Interface(), params, fmt.Sprintf("%s[%d].", fpath, i), errs)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package zfields
+
+import (
+	"testing"
+	"time"
+)
+
+type validateTestItem struct {
+	Name string `zui:"required"`
+}
+
+type validateTestStruct struct {
+	Port     int       `zui:"default:8080"`
+	Retries  int       `zui:"default:3,allowempty"`
+	Code     string    `zui:"filter:$trim|$upper"`
+	Name     string    `zui:"required"`
+	Email    string    `zui:"required:contact"`
+	Phone    string    `zui:"required:contact"`
+	Expires  time.Time `zui:"invalid:past"`
+	Born     time.Time `zui:"invalid:future"`
+	Mode     string    `zui:"enum:validatetest.modes"`
+	Level    string    `zui:"enum:validatetest.modes,allowempty"`
+	Sub      validateTestItem
+	Items    []validateTestItem
+	Pointers []*validateTestItem
+}
+
+func makeValidTestStruct() validateTestStruct {
+	SetStringBasedEnum("validatetest.modes", "fast", "slow")
+	return validateTestStruct{
+		Name:     "bob",
+		Phone:    "123",
+		Expires:  time.Now().Add(time.Hour),
+		Born:     time.Now().Add(-time.Hour),
+		Mode:     "fast",
+		Sub:      validateTestItem{"a"},
+		Items:    []validateTestItem{{"b"}},
+		Pointers: []*validateTestItem{nil, {"c"}},
+	}
+}
+
+func TestValidateValid(t *testing.T) {
+	s := makeValidTestStruct()
+	s.Code = "  ab-c "
+	err := Validate(&s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Port != 8080 {
+		t.Error("default not set:", s.Port)
+	}
+	if s.Retries != 0 {
+		t.Error("default set on allowempty:", s.Retries)
+	}
+	if s.Code != "AB-C" {
+		t.Errorf("filters not applied: %q", s.Code)
+	}
+	s.Port = 22
+	Validate(&s)
+	if s.Port != 22 {
+		t.Error("default replaced a value:", s.Port)
+	}
+}
+
+func TestValidateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(s *validateTestStruct)
+		path    string
+		message string
+	}{
+		{"required", func(s *validateTestStruct) { s.Name = "" }, "Name", "can't be empty"},
+		{"group", func(s *validateTestStruct) { s.Phone = "" }, "Email", "one of Email, Phone must be set"},
+		{"past", func(s *validateTestStruct) { s.Expires = time.Now().Add(-time.Hour) }, "Expires", "can't be in the past"},
+		{"future", func(s *validateTestStruct) { s.Born = time.Now().Add(time.Hour) }, "Born", "can't be in the future"},
+		{"enum", func(s *validateTestStruct) { s.Mode = "slowest" }, "Mode", "slowest is not a valid option"},
+		{"empty enum", func(s *validateTestStruct) { s.Mode = "" }, "Mode", " is not a valid option"},
+		{"enum allowempty", func(s *validateTestStruct) { s.Level = "x" }, "Level", "x is not a valid option"},
+		{"nested", func(s *validateTestStruct) { s.Sub.Name = "" }, "Sub.Name", "can't be empty"},
+		{"slice", func(s *validateTestStruct) { s.Items = append(s.Items, validateTestItem{}) }, "Items[1].Name", "can't be empty"},
+		{"pointer slice", func(s *validateTestStruct) { s.Pointers[1].Name = "" }, "Pointers[1].Name", "can't be empty"},
+	}
+	for _, test := range tests {
+		s := makeValidTestStruct()
+		test.change(&s)
+		err := Validate(&s)
+		verrs, _ := err.(ValidationErrors)
+		if len(verrs) != 1 {
+			t.Errorf("%s: expected one error, got %v", test.name, err)
+			continue
+		}
+		if verrs[0].FieldPath != test.path || verrs[0].Message != test.message {
+			t.Errorf("%s: got %s %q, want %s %q", test.name, verrs[0].FieldPath, verrs[0].Message, test.path, test.message)
+		}
+		if verrs.ForField(test.path) == nil {
+			t.Error(test.name, "ForField didn't find:", test.path)
+		}
+	}
+}
+
+func TestValidateAllowEmptyEnum(t *testing.T) {
+	s := makeValidTestStruct()
+	s.Level = ""
+	if err := Validate(&s); err != nil {
+		t.Error("empty allowempty enum:", err)
+	}
+}
+
+func TestValidateUnknownFilter(t *testing.T) {
+	type withFilter struct {
+		Code string `zui:"filter:$nosuchfilter"`
+	}
+	var s withFilter
+	verrs, _ := Validate(&s).(ValidationErrors)
+	if len(verrs) != 1 || verrs[0].FieldPath != "Code" {
+		t.Error("expected error for unknown filter:", verrs)
+	}
+}
@@ -0,0 +1,897 @@
+package zfields
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/torlangballe/zutil/zbool"
+	"github.com/torlangballe/zutil/zlog"
+	"github.com/torlangballe/zutil/zreflect"
+	"github.com/torlangballe/zutil/zstr"
+)
+
+// This file is about making a command-line interface from a struct.
+// ParseCommandArgsToStructFields and GetCommandArgsHelpForStructFields use it in an older style, see CLI.pointersAreOptions.
+// Fields are --long options, named from the field name in kebab-case, or with a "short:x" tag also -x.
+// Fields with an "arg" tag are positional arguments instead, a slice one taking all the rest.
+// Struct fields, and pointers to structs, are subcommands, their fields options and arguments of it.
+// Values not given as arguments are looked up in the environment, then in a config file, then in the default tag.
+// Fields with an enum tag only accept names or values of the enum. After parsing, Validate checks required etc.
+
+// CLI makes a command-line interface for a struct, see Parse and Usage.
+type CLI struct {
+	Name       string    // Name of the program in help and completion scripts, the base of os.Args[0] if empty
+	EnvPrefix  string    // If EnvPrefix is set, all options can be set in environment variables named EnvPrefix + COMMAND_OPTION. Fields with an "env:NAME" tag always can
+	ConfigPath string    // If ConfigPath is set, options not given as arguments or in the environment are read from it, see parseCLIConfig
+	Output     io.Writer // Output is where ParseOrExit writes help, completion scripts and errors, os.Stderr if nil
+
+	// pointersAreOptions makes pointer fields --options and other fields positional arguments that must be given unless they have a default or allowempty tag.
+	// Names are the lower-case title or field name, and a bool with a one-letter name is also -x.
+	pointersAreOptions bool
+}
+
+type cliOption struct {
+	field     *Field
+	rval      reflect.Value
+	long      string
+	short     string
+	env       string
+	key       string // key is the normalized config file key, with the subcommand path before the name
+	isBool    bool
+	valueName string
+}
+
+type cliCommand struct {
+	name        string
+	description string
+	rval        reflect.Value // rval is the command's struct value, addressable
+	setSelected func()        // setSelected sets a pointer field to rval when the command is selected
+	path        []string
+	options     []*cliOption
+	args        []*cliOption
+	fields      []*cliOption // fields are the options and args in the order of the struct
+	subs        []*cliCommand
+	subNames    []string // subNames are the field names of subs, so they are skipped when validating
+}
+
+// ErrHelp is returned by Parse when -h, --help or help is given. Usage has the help text.
+var ErrHelp = errors.New("help requested")
+
+func init() {
+	RegisterCustomTagKeys("short", "env", "arg")
+}
+
+func (c *CLI) name() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return filepath.Base(os.Args[0])
+}
+
+// kebabName makes a name like MaxCount into max-count.
+func kebabName(name string) string {
+	return strings.ToLower(zstr.PadCamelCase(name, "-"))
+}
+
+func normalizeCLIKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(key))
+}
+
+func (c *CLI) makeCommand(name string, rval reflect.Value, path []string) *cliCommand {
+	cmd := &cliCommand{name: name, rval: rval, path: path}
+	ForEachField(rval.Addr().Interface(), FieldParameters{}, nil, func(each FieldInfo) bool {
+		f := each.Field
+		fval := each.ReflectValue
+		if f.FieldName == "Description" {
+			cmd.description = f.Description
+			return true
+		}
+		if f.HasFlag(FlagIsButton) || f.HasFlag(FlagIsStatic) {
+			return true
+		}
+		t := fval.Type()
+		if t.Kind() == reflect.Pointer && t.Elem().Kind() == reflect.Struct && t.Elem() != reflect.TypeOf(time.Time{}) {
+			sval := fval
+			if fval.IsNil() {
+				sval = reflect.New(t.Elem())
+			}
+			sub := c.makeCommand(kebabName(f.FieldName), sval.Elem(), append(append([]string{}, path...), kebabName(f.FieldName)))
+			sub.setSelected = func() {
+				fval.Set(sval)
+			}
+			c.addSub(cmd, sub, f)
+			return true
+		}
+		if t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{}) {
+			sub := c.makeCommand(kebabName(f.FieldName), fval, append(append([]string{}, path...), kebabName(f.FieldName)))
+			c.addSub(cmd, sub, f)
+			return true
+		}
+		if !isCLIKind(t) {
+			return true
+		}
+		o := &cliOption{field: f, rval: fval, long: kebabName(f.FieldName)}
+		o.short = f.CustomFields["short"]
+		et := t
+		if et.Kind() == reflect.Pointer {
+			et = et.Elem()
+		}
+		o.isBool = (et.Kind() == reflect.Bool)
+		_, isArg := f.CustomFields["arg"]
+		if c.pointersAreOptions {
+			o.long = strings.ToLower(f.FieldName)
+			if f.Title != "" {
+				o.long = strings.ToLower(f.Title)
+			}
+			isArg = (t.Kind() != reflect.Pointer)
+			if o.isBool && !isArg && len(o.long) == 1 {
+				o.short = o.long
+			}
+		}
+		o.key = normalizeCLIKey(strings.Join(append(append([]string{}, path...), o.long), "."))
+		o.env = f.CustomFields["env"]
+		if o.env == "" && c.EnvPrefix != "" {
+			o.env = c.EnvPrefix + strings.ToUpper(strings.ReplaceAll(strings.Join(append(append([]string{}, path...), o.long), "_"), "-", "_"))
+		}
+		o.valueName = cliValueName(f, et)
+		if isArg {
+			cmd.args = append(cmd.args, o)
+		} else {
+			cmd.options = append(cmd.options, o)
+		}
+		cmd.fields = append(cmd.fields, o)
+		return true
+	})
+	return cmd
+}
+
+func (c *CLI) addSub(cmd, sub *cliCommand, f *Field) {
+	if f.Description != "" {
+		sub.description = f.Description
+	} else if sub.description == "" {
+		sub.description = f.Tooltip
+	}
+	cmd.subs = append(cmd.subs, sub)
+	cmd.subNames = append(cmd.subNames, f.FieldName)
+}
+
+// isCLIKind returns true if values of t can be set from command-line strings.
+func isCLIKind(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool, reflect.Float32, reflect.Float64,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+	return false
+}
+
+func cliValueName(f *Field, t reflect.Type) string {
+	if f.Enum != "" {
+		return strings.Join(enumNames(f.Enum), "|")
+	}
+	if t == reflect.TypeOf(time.Duration(0)) {
+		return "duration"
+	}
+	if t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return fmt.Sprint(zreflect.KindFromReflectKindAndType(t.Kind(), t))
+}
+
+func enumNames(enum string) []string {
+	var names []string
+	for _, item := range GetEnum(enum) {
+		names = append(names, item.Name)
+	}
+	return names
+}
+
+func (cmd *cliCommand) findSub(name string) *cliCommand {
+	for _, s := range cmd.subs {
+		if s.name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// findOption finds an option by long or short name in the commands, the selected one last.
+func findOption(cmds []*cliCommand, name string, short bool) *cliOption {
+	for i := len(cmds) - 1; i >= 0; i-- {
+		for _, o := range cmds[i].options {
+			if (short && o.short == name) || (!short && o.long == name) {
+				return o
+			}
+		}
+	}
+	return nil
+}
+
+// Parse sets the fields of structPtr from args, which don't include the program name.
+// It returns the path of subcommands selected, and ErrHelp if help was asked for.
+func (c *CLI) Parse(structPtr any, args []string) (commandPath []string, err error) {
+	root := c.makeCommand(c.name(), reflect.ValueOf(structPtr).Elem(), nil)
+	cmds := []*cliCommand{root}
+	given := map[*cliOption][]string{}
+	var positionals []string
+	onlyArgs := false
+	for i := 0; i < len(args); i++ {
+		a := args[i]
+		cmd := cmds[len(cmds)-1]
+		if onlyArgs || a == "-" || !strings.HasPrefix(a, "-") {
+			if !onlyArgs && len(positionals) == 0 {
+				if sub := cmd.findSub(a); sub != nil {
+					cmds = append(cmds, sub)
+					continue
+				}
+				if a == "help" {
+					return cmdPath(cmds), ErrHelp
+				}
+			}
+			positionals = append(positionals, a)
+			continue
+		}
+		if a == "--" {
+			onlyArgs = true
+			continue
+		}
+		if a == "-h" || a == "--help" {
+			return cmdPath(cmds), ErrHelp
+		}
+		var name, value string
+		if zstr.HasPrefix(a, "--", &name) {
+			hasValue := strings.Contains(name, "=")
+			if hasValue {
+				name = zstr.HeadUntilWithRest(name, "=", &value)
+			}
+			o := findOption(cmds, name, false)
+			if o == nil {
+				return cmdPath(cmds), zlog.NewError("unknown option", "--"+name)
+			}
+			if !hasValue {
+				if o.isBool {
+					value = "true"
+				} else {
+					if i+1 >= len(args) {
+						return cmdPath(cmds), zlog.NewError("no value for", "--"+name)
+					}
+					i++
+					value = args[i]
+				}
+			}
+			given[o] = append(given[o], value)
+			continue
+		}
+		shorts := []rune(a[1:])
+		for j, r := range shorts {
+			o := findOption(cmds, string(r), true)
+			if o == nil {
+				return cmdPath(cmds), zlog.NewError("unknown option", "-"+string(r))
+			}
+			if o.isBool {
+				given[o] = append(given[o], "true")
+				continue
+			}
+			value = string(shorts[j+1:])
+			if value == "" {
+				if i+1 >= len(args) {
+					return cmdPath(cmds), zlog.NewError("no value for", "-"+string(r))
+				}
+				i++
+				value = args[i]
+			}
+			given[o] = append(given[o], value)
+			break
+		}
+	}
+	for _, cmd := range cmds {
+		if cmd.setSelected != nil {
+			cmd.setSelected()
+		}
+	}
+	sel := cmds[len(cmds)-1]
+	for _, o := range sel.args {
+		if len(positionals) == 0 {
+			break
+		}
+		if o.rval.Kind() == reflect.Slice {
+			given[o] = positionals
+			positionals = nil
+			break
+		}
+		given[o] = positionals[:1]
+		positionals = positionals[1:]
+	}
+	if len(positionals) != 0 {
+		return cmdPath(cmds), zlog.NewError("unexpected argument", positionals[0])
+	}
+	if c.pointersAreOptions {
+		for _, o := range sel.args {
+			if len(given[o]) == 0 && !o.field.HasFlag(FlagHasDefault) && !o.field.HasFlag(FlagAllowEmptyAsZero) {
+				return cmdPath(cmds), zlog.NewError("no argument for", o.long)
+			}
+		}
+	}
+	config := map[string][]string{}
+	if c.ConfigPath != "" {
+		config, err = readCLIConfig(c.ConfigPath)
+		if err != nil {
+			return cmdPath(cmds), err
+		}
+	}
+	for _, cmd := range cmds {
+		for _, o := range append(append([]*cliOption{}, cmd.options...), cmd.args...) {
+			err = c.setOption(o, given[o], config[o.key])
+			if err != nil {
+				return cmdPath(cmds), err
+			}
+		}
+	}
+	var verrs ValidationErrors
+	for _, cmd := range cmds {
+		params := FieldParameters{SkipFieldNames: cmd.subNames}
+		verr := ValidateWithParameters(cmd.rval.Addr().Interface(), params)
+		if verr != nil {
+			verrs = append(verrs, verr.(ValidationErrors)...)
+		}
+	}
+	if len(verrs) != 0 {
+		return cmdPath(cmds), verrs
+	}
+	return cmdPath(cmds), nil
+}
+
+func cmdPath(cmds []*cliCommand) []string {
+	return cmds[len(cmds)-1].path
+}
+
+// setOption sets o's field from the first of arguments, its environment variable, config values and default tag that has a value.
+func (c *CLI) setOption(o *cliOption, args, config []string) error {
+	vals := args
+	from := "argument"
+	if len(vals) == 0 && o.env != "" {
+		if env, got := os.LookupEnv(o.env); got {
+			vals = []string{env}
+			from = "environment variable " + o.env
+		}
+	}
+	if len(vals) == 0 && len(config) != 0 {
+		vals = config
+		from = "config " + o.key
+	}
+	if len(vals) == 0 && o.field.HasFlag(FlagHasDefault) && o.rval.IsZero() {
+		vals = []string{o.field.Default}
+		from = "default"
+	}
+	if len(vals) == 0 {
+		return nil
+	}
+	rval := o.rval
+	if rval.Kind() == reflect.Pointer {
+		rval.Set(reflect.New(rval.Type().Elem()))
+		rval = rval.Elem()
+	}
+	if rval.Kind() == reflect.Slice {
+		rval.Set(reflect.MakeSlice(rval.Type(), 0, len(vals)))
+		if len(vals) == 1 && (args == nil || o.field.StringSep != "") {
+			vals = splitCLIList(vals[0], o.field.StringSep)
+		}
+		for _, v := range vals {
+			e := reflect.New(rval.Type().Elem()).Elem()
+			err := setCLIValue(v, o.field, e)
+			if err != nil {
+				return zlog.NewError("--"+o.long, "from", from+":", err)
+			}
+			rval.Set(reflect.Append(rval, e))
+		}
+		return nil
+	}
+	err := setCLIValue(vals[len(vals)-1], o.field, rval)
+	if err != nil {
+		return zlog.NewError("--"+o.long, "from", from+":", err)
+	}
+	return nil
+}
+
+// splitCLIList splits a list from the environment or a config file at sep, or commas if sep is empty.
+func splitCLIList(str, sep string) []string {
+	if sep == "" {
+		sep = ","
+	}
+	var parts []string
+	for _, p := range strings.Split(str, sep) {
+		p = strings.TrimSpace(p)
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return parts
+}
+
+// SetValueFromString sets rval to str as it is set from the command line for f;
+// as a name or value of its enum, a duration like 5s, an RFC3339 time, or a number, bool or string.
+func SetValueFromString(f *Field, rval reflect.Value, str string) error {
+	return setCLIValue(str, f, rval)
+}
+
+// setCLIValue sets rval to str, which must be the name or value of f's enum if it has one.
+func setCLIValue(str string, f *Field, rval reflect.Value) error {
+	if f.Enum != "" {
+		enum := GetEnum(f.Enum)
+		for _, item := range enum {
+			if strings.EqualFold(item.Name, str) || fmt.Sprint(item.Value) == str {
+				v := reflect.ValueOf(item.Value)
+				if !v.Type().ConvertibleTo(rval.Type()) {
+					return zlog.NewError("enum", f.Enum, "value", item.Value, "can't be a", rval.Type())
+				}
+				rval.Set(v.Convert(rval.Type()))
+				return nil
+			}
+		}
+		if len(enum) != 0 {
+			return zlog.NewError(strconv.Quote(str), "isn't one of", strings.Join(enumNames(f.Enum), ", "))
+		}
+	}
+	switch rval.Interface().(type) {
+	case time.Duration:
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return err
+		}
+		rval.SetInt(int64(d))
+		return nil
+	case time.Time:
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return err
+		}
+		rval.Set(reflect.ValueOf(t))
+		return nil
+	}
+	switch rval.Kind() {
+	case reflect.String:
+		rval.SetString(str)
+	case reflect.Bool:
+		b, err := zbool.FromStringWithError(str)
+		if err != nil {
+			return err
+		}
+		rval.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(str, 10, rval.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rval.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(str, 10, rval.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rval.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(str, rval.Type().Bits())
+		if err != nil {
+			return err
+		}
+		rval.SetFloat(n)
+	default:
+		return zlog.NewError("unsupported type", rval.Type())
+	}
+	return nil
+}
+
+// readCLIConfig reads a config file as JSON if it starts with {, or else as key=value lines, see parseCLIConfig.
+func readCLIConfig(fpath string) (map[string][]string, error) {
+	data, err := os.ReadFile(fpath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return map[string][]string{}, nil
+		}
+		return nil, err
+	}
+	config, err := parseCLIConfig(string(data))
+	if err != nil {
+		return nil, zlog.NewError(fpath+":", err)
+	}
+	return config, nil
+}
+
+// parseCLIConfig parses a config as JSON, with objects for subcommands, or as lines of key=value or key: value.
+// [section] lines and YAML-like indentation under a "key:" line put subcommand names before keys,
+// and "- value" lines are items of a list. # and ; start comments.
+// Keys are normalized, so MaxCount, max-count and max_count are the same.
+func parseCLIConfig(text string) (map[string][]string, error) {
+	config := map[string][]string{}
+	if strings.HasPrefix(strings.TrimSpace(text), "{") {
+		var m map[string]any
+		err := json.Unmarshal([]byte(text), &m)
+		if err != nil {
+			return nil, err
+		}
+		addJSONConfig(config, "", m)
+		return config, nil
+	}
+	type level struct {
+		indent int
+		prefix string
+	}
+	var levels []level
+	var section, lastKey string
+	for i, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, ";") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]") {
+			section = strings.TrimSpace(trimmed[1 : len(trimmed)-1])
+			levels = nil
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		for len(levels) != 0 && levels[len(levels)-1].indent >= indent {
+			levels = levels[:len(levels)-1]
+		}
+		prefix := section
+		if len(levels) != 0 {
+			prefix = levels[len(levels)-1].prefix
+		}
+		var item string
+		if zstr.HasPrefix(trimmed, "- ", &item) {
+			if lastKey == "" {
+				return nil, zlog.NewError("list item without key on line", i+1)
+			}
+			config[lastKey] = append(config[lastKey], unquoteCLIValue(item))
+			continue
+		}
+		ie := strings.IndexAny(trimmed, "=:")
+		if ie == -1 {
+			return nil, zlog.NewError("no = or : on line", i+1, "in", strconv.Quote(trimmed))
+		}
+		key := strings.TrimSpace(trimmed[:ie])
+		value := strings.TrimSpace(trimmed[ie+1:])
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		lastKey = normalizeCLIKey(key)
+		if value == "" && trimmed[ie] == ':' { // a YAML-like parent of indented keys or list items
+			levels = append(levels, level{indent: indent, prefix: key})
+			continue
+		}
+		config[lastKey] = append(config[lastKey], unquoteCLIValue(value))
+	}
+	return config, nil
+}
+
+func unquoteCLIValue(str string) string {
+	if len(str) >= 2 && (str[0] == '"' && str[len(str)-1] == '"' || str[0] == '\'' && str[len(str)-1] == '\'') {
+		return str[1 : len(str)-1]
+	}
+	return str
+}
+
+func addJSONConfig(config map[string][]string, prefix string, m map[string]any) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch val := v.(type) {
+		case map[string]any:
+			addJSONConfig(config, key, val)
+		case []any:
+			for _, e := range val {
+				config[normalizeCLIKey(key)] = append(config[normalizeCLIKey(key)], jsonConfigString(e))
+			}
+		default:
+			config[normalizeCLIKey(key)] = []string{jsonConfigString(val)}
+		}
+	}
+}
+
+func jsonConfigString(v any) string {
+	if f, is := v.(float64); is {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// ParseOrExit parses os.Args for structPtr with Parse. It writes help and exits with 0 if asked for,
+// or writes the error and usage and exits with 2 if parsing fails.
+// --completion <bash|zsh|fish> writes a completion script for the shell and exits.
+func (c *CLI) ParseOrExit(structPtr any) (commandPath []string) {
+	out := c.Output
+	if out == nil {
+		out = os.Stderr
+	}
+	args := os.Args[1:]
+	if len(args) == 2 && args[0] == "--completion" {
+		script, err := c.CompletionScript(structPtr, args[1])
+		if err != nil {
+			fmt.Fprintln(out, err)
+			os.Exit(2)
+		}
+		fmt.Fprint(os.Stdout, script)
+		os.Exit(0)
+	}
+	path, err := c.Parse(structPtr, args)
+	if err == ErrHelp {
+		fmt.Fprint(out, c.Usage(structPtr, path))
+		os.Exit(0)
+	}
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		fmt.Fprint(out, c.Usage(structPtr, path))
+		os.Exit(2)
+	}
+	return path
+}
+
+// Usage returns the help text for the subcommand at commandPath of structPtr, with its subcommands, arguments and options.
+// Options of parent commands can be given too, and are listed as global options.
+func (c *CLI) Usage(structPtr any, commandPath []string) string {
+	root := c.makeCommand(c.name(), reflect.ValueOf(structPtr).Elem(), nil)
+	cmds := []*cliCommand{root}
+	for _, name := range commandPath {
+		sub := cmds[len(cmds)-1].findSub(name)
+		if sub == nil {
+			break
+		}
+		cmds = append(cmds, sub)
+	}
+	cmd := cmds[len(cmds)-1]
+	var str strings.Builder
+	line := "Usage: " + strings.Join(append([]string{c.name()}, cmd.path...), " ") + " [options]"
+	if len(cmd.subs) != 0 {
+		line += " <command>"
+	}
+	for _, a := range cmd.args {
+		name := "<" + a.long + ">"
+		if a.rval.Kind() == reflect.Slice {
+			name += "..."
+		}
+		if a.field.Required == "" {
+			name = "[" + name + "]"
+		}
+		line += " " + name
+	}
+	str.WriteString(line + "\n")
+	if cmd.description != "" {
+		str.WriteString("\n" + cmd.description + "\n")
+	}
+	var rows []zstr.KeyValue
+	for _, s := range cmd.subs {
+		rows = append(rows, zstr.KeyValue{Key: s.name, Value: s.description})
+	}
+	writeCLIRows(&str, "Commands", rows)
+	rows = nil
+	for _, a := range cmd.args {
+		rows = append(rows, zstr.KeyValue{Key: "<" + a.long + ">", Value: c.optionInfo(a)})
+	}
+	writeCLIRows(&str, "Arguments", rows)
+	rows = []zstr.KeyValue{{Key: "-h, --help", Value: "Show this help"}}
+	for _, o := range cmd.options {
+		rows = append(rows, c.optionRow(o))
+	}
+	writeCLIRows(&str, "Options", rows)
+	rows = nil
+	for _, pc := range cmds[:len(cmds)-1] {
+		for _, o := range pc.options {
+			rows = append(rows, c.optionRow(o))
+		}
+	}
+	writeCLIRows(&str, "Global options", rows)
+	return str.String()
+}
+
+func (c *CLI) optionRow(o *cliOption) zstr.KeyValue {
+	key := "    --" + o.long
+	if o.short != "" {
+		key = "-" + o.short + ", --" + o.long
+	}
+	if !o.isBool {
+		key += " <" + o.valueName + ">"
+	}
+	return zstr.KeyValue{Key: key, Value: c.optionInfo(o)}
+}
+
+func (c *CLI) optionInfo(o *cliOption) string {
+	info := o.field.Description
+	if info == "" {
+		info = o.field.Tooltip
+	}
+	var extra []string
+	if o.field.HasFlag(FlagHasDefault) {
+		extra = append(extra, "default: "+o.field.Default)
+	}
+	if o.field.Required != "" {
+		extra = append(extra, "required")
+	}
+	if o.env != "" {
+		extra = append(extra, "env: "+o.env)
+	}
+	if len(extra) != 0 {
+		info = zstr.Concat(" ", info, "("+strings.Join(extra, ", ")+")")
+	}
+	return info
+}
+
+func writeCLIRows(str *strings.Builder, title string, rows []zstr.KeyValue) {
+	if len(rows) == 0 {
+		return
+	}
+	var width int
+	for _, r := range rows {
+		width = max(width, len(r.Key))
+	}
+	str.WriteString("\n" + title + ":\n")
+	for _, r := range rows {
+		fmt.Fprintf(str, "  %-*s  %s\n", width, r.Key, r.Value)
+	}
+}
+
+// CompletionScript returns a script that completes subcommands, options and enum values of structPtr's command line
+// for shell, which is bash, zsh or fish. It is typically sourced from the shell's startup file.
+func (c *CLI) CompletionScript(structPtr any, shell string) (string, error) {
+	root := c.makeCommand(c.name(), reflect.ValueOf(structPtr).Elem(), nil)
+	var cmds []*cliCommand
+	var add func(cmd *cliCommand)
+	add = func(cmd *cliCommand) {
+		cmds = append(cmds, cmd)
+		for _, s := range cmd.subs {
+			add(s)
+		}
+	}
+	add(root)
+	switch shell {
+	case "bash":
+		return c.bashCompletion(cmds), nil
+	case "zsh":
+		return "autoload -U +X bashcompinit && bashcompinit\n" + c.bashCompletion(cmds), nil
+	case "fish":
+		return c.fishCompletion(cmds), nil
+	}
+	return "", zlog.NewError("no completion for shell", strconv.Quote(shell)+", only bash, zsh and fish")
+}
+
+func shellFuncName(name string) string {
+	return "_" + strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// cmdOptions returns all options cmd can take, with those of its parents.
+func cmdOptions(cmds []*cliCommand, cmd *cliCommand) []*cliOption {
+	var options []*cliOption
+	for _, c := range cmds {
+		if len(c.path) <= len(cmd.path) && slices.Equal(c.path, cmd.path[:len(c.path)]) {
+			options = append(options, c.options...)
+		}
+	}
+	return options
+}
+
+func (c *CLI) bashCompletion(cmds []*cliCommand) string {
+	fname := shellFuncName(c.name())
+	var str strings.Builder
+	fmt.Fprintf(&str, "%s() {\n", fname)
+	str.WriteString("\tlocal cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\" path=\"\" i\n")
+	str.WriteString("\tfor ((i=1; i<COMP_CWORD; i++)); do\n\t\tcase \"$path/${COMP_WORDS[i]}\" in\n")
+	for _, cmd := range cmds[1:] {
+		fmt.Fprintf(&str, "\t\t%q) path=\"$path/${COMP_WORDS[i]}\" ;;\n", "/"+strings.Join(cmd.path, "/"))
+	}
+	str.WriteString("\t\tesac\n\tdone\n\tcase \"$path|$prev\" in\n")
+	for _, cmd := range cmds {
+		for _, o := range cmdOptions(cmds, cmd) {
+			if o.field.Enum == "" {
+				continue
+			}
+			pattern := fmt.Sprintf("%q", pathKeyForShell(cmd)+"|--"+o.long)
+			if o.short != "" {
+				pattern += fmt.Sprintf("|%q", pathKeyForShell(cmd)+"|-"+o.short)
+			}
+			fmt.Fprintf(&str, "\t%s) COMPREPLY=($(compgen -W %q -- \"$cur\")); return ;;\n", pattern, strings.Join(enumNames(o.field.Enum), " "))
+		}
+	}
+	str.WriteString("\tesac\n\tcase \"$path\" in\n")
+	for _, cmd := range cmds {
+		words := []string{"--help"}
+		for _, s := range cmd.subs {
+			words = append(words, s.name)
+		}
+		for _, o := range cmdOptions(cmds, cmd) {
+			words = append(words, "--"+o.long)
+			if o.short != "" {
+				words = append(words, "-"+o.short)
+			}
+		}
+		fmt.Fprintf(&str, "\t%q) COMPREPLY=($(compgen -W %q -- \"$cur\")) ;;\n", pathKeyForShell(cmd), strings.Join(words, " "))
+	}
+	str.WriteString("\tesac\n}\n")
+	fmt.Fprintf(&str, "complete -o default -F %s %s\n", fname, c.name())
+	return str.String()
+}
+
+func pathKeyForShell(cmd *cliCommand) string {
+	if len(cmd.path) == 0 {
+		return ""
+	}
+	return "/" + strings.Join(cmd.path, "/")
+}
+
+func (c *CLI) fishCompletion(cmds []*cliCommand) string {
+	var str strings.Builder
+	name := c.name()
+	allSubs := map[string]bool{}
+	for _, cmd := range cmds[1:] {
+		allSubs[cmd.name] = true
+	}
+	var subNames []string
+	for n := range allSubs {
+		subNames = append(subNames, n)
+	}
+	sort.Strings(subNames)
+	// condition is true if the last subcommand on the line is cmd's
+	condition := func(cmd *cliCommand) string {
+		if len(cmd.path) == 0 {
+			if len(subNames) == 0 {
+				return ""
+			}
+			return "not __fish_seen_subcommand_from " + strings.Join(subNames, " ")
+		}
+		return "__fish_seen_subcommand_from " + cmd.name
+	}
+	for _, cmd := range cmds {
+		cond := condition(cmd)
+		if cond != "" {
+			cond = fmt.Sprintf(" -n %q", cond)
+		}
+		for _, s := range cmd.subs {
+			fmt.Fprintf(&str, "complete -c %s -f%s -a %s -d %q\n", name, cond, s.name, s.description)
+		}
+		for _, o := range cmd.options {
+			line := fmt.Sprintf("complete -c %s%s -l %s", name, cond, o.long)
+			if cmd == cmds[0] { // global options can be given after subcommands too
+				line = fmt.Sprintf("complete -c %s -l %s", name, o.long)
+			}
+			if o.short != "" {
+				line += " -s " + o.short
+			}
+			if !o.isBool {
+				line += " -r"
+			}
+			if o.field.Enum != "" {
+				line += fmt.Sprintf(" -xa %q", strings.Join(enumNames(o.field.Enum), " "))
+			}
+			info := o.field.Description
+			if info == "" {
+				info = o.field.Tooltip
+			}
+			if info != "" {
+				line += fmt.Sprintf(" -d %q", info)
+			}
+			str.WriteString(line + "\n")
+		}
+	}
+	return str.String()
+}
@@ -0,0 +1,235 @@
+package zfields
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type cliTestServe struct {
+	Port int    `zui:"default:8080,short:p"`
+	Dir  string `zui:"arg"`
+}
+
+type cliTestCopy struct {
+	Force bool     `zui:"short:f"`
+	Files []string `zui:"arg"`
+}
+
+type cliTestRoot struct {
+	Verbose bool          `zui:"short:v"`
+	Count   int           `zui:"short:n"`
+	Output  string        `zui:"short:o"`
+	Mode    string        `zui:"enum:clitest.modes,default:fast"`
+	Wait    time.Duration `zui:"default:5s"`
+	Tags    []string      `zui:"env:CLITEST_TAGS"`
+	Serve   *cliTestServe
+	Copy    cliTestCopy
+}
+
+func parseCLITest(t *testing.T, c *CLI, args ...string) (cliTestRoot, []string, error) {
+	t.Helper()
+	SetStringBasedEnum("clitest.modes", "fast", "slow")
+	var r cliTestRoot
+	path, err := c.Parse(&r, args)
+	return r, path, err
+}
+
+func TestCLIOptions(t *testing.T) {
+	r, path, err := parseCLITest(t, &CLI{}, "-vn3", "-oout.txt", "--mode=slow", "--wait", "2m", "--tags", "a", "--tags=b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 0 || !r.Verbose || r.Count != 3 || r.Output != "out.txt" || r.Mode != "slow" || r.Wait != 2*time.Minute {
+		t.Errorf("options: %v %+v", path, r)
+	}
+	if !reflect.DeepEqual(r.Tags, []string{"a", "b"}) {
+		t.Error("repeated option:", r.Tags)
+	}
+	r, _, err = parseCLITest(t, &CLI{}, "-v", "-n", "7", "--output", "-")
+	if err != nil || !r.Verbose || r.Count != 7 || r.Output != "-" {
+		t.Errorf("separate values: %v %+v", err, r)
+	}
+	r, _, err = parseCLITest(t, &CLI{})
+	if err != nil || r.Mode != "fast" || r.Wait != 5*time.Second || r.Serve != nil {
+		t.Errorf("defaults: %v %+v", err, r)
+	}
+	for _, args := range [][]string{{"--nope"}, {"-x"}, {"-vx"}, {"--count"}, {"--count", "many"}, {"stray"}} {
+		if _, _, err := parseCLITest(t, &CLI{}, args...); err == nil {
+			t.Error("expected error for:", args)
+		}
+	}
+}
+
+func TestCLIEnum(t *testing.T) {
+	r, _, err := parseCLITest(t, &CLI{}, "--mode", "SLOW")
+	if err != nil || r.Mode != "slow" {
+		t.Errorf("enum name: %v %q", err, r.Mode)
+	}
+	_, _, err = parseCLITest(t, &CLI{}, "--mode", "slowest")
+	if err == nil || !strings.Contains(err.Error(), "isn't one of fast, slow") {
+		t.Error("enum not rejected:", err)
+	}
+}
+
+func TestCLISubcommands(t *testing.T) {
+	r, path, err := parseCLITest(t, &CLI{}, "-v", "serve", "-p", "9000", "--count=2", "www")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(path, " ") != "serve" || r.Serve == nil || r.Serve.Port != 9000 || r.Serve.Dir != "www" {
+		t.Errorf("serve: %v %+v", path, r.Serve)
+	}
+	if !r.Verbose || r.Count != 2 {
+		t.Errorf("global options: %+v", r)
+	}
+	r, _, err = parseCLITest(t, &CLI{}, "serve")
+	if err != nil || r.Serve.Port != 8080 {
+		t.Errorf("sub default: %v %+v", err, r.Serve)
+	}
+	r, path, err = parseCLITest(t, &CLI{}, "copy", "-f", "a", "--", "-b", "serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(path, " ") != "copy" || !r.Copy.Force || !reflect.DeepEqual(r.Copy.Files, []string{"a", "-b", "serve"}) || r.Serve != nil {
+		t.Errorf("copy: %v %+v", path, r)
+	}
+	if _, _, err := parseCLITest(t, &CLI{}, "serve", "-f"); err == nil {
+		t.Error("option of a sibling command accepted")
+	}
+	_, path, err = parseCLITest(t, &CLI{}, "serve", "--help")
+	if err != ErrHelp || strings.Join(path, " ") != "serve" {
+		t.Error("help:", path, err)
+	}
+}
+
+func TestCLIPrecedence(t *testing.T) {
+	config := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(config, []byte("count = 4\noutput: config.txt\nwait=1s\n[serve]\nport = 81\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &CLI{EnvPrefix: "CLITEST_", ConfigPath: config}
+	t.Setenv("CLITEST_OUTPUT", "env.txt")
+	t.Setenv("CLITEST_SERVE_PORT", "82")
+	t.Setenv("CLITEST_TAGS", "x, y")
+	r, _, err := parseCLITest(t, c, "--wait", "3s", "serve")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Wait != 3*time.Second || r.Output != "env.txt" || r.Count != 4 || r.Mode != "fast" {
+		t.Errorf("argument, env, config, default: %+v", r)
+	}
+	if r.Serve.Port != 82 {
+		t.Error("sub env:", r.Serve.Port)
+	}
+	if !reflect.DeepEqual(r.Tags, []string{"x", "y"}) {
+		t.Error("env list:", r.Tags)
+	}
+	os.Unsetenv("CLITEST_SERVE_PORT")
+	r, _, _ = parseCLITest(t, c, "serve")
+	if r.Serve.Port != 81 {
+		t.Error("sub config:", r.Serve.Port)
+	}
+	t.Setenv("CLITEST_COUNT", "lots")
+	if _, _, err = parseCLITest(t, c); err == nil || !strings.Contains(err.Error(), "environment variable CLITEST_COUNT") {
+		t.Error("bad env value:", err)
+	}
+}
+
+func TestParseCLIConfig(t *testing.T) {
+	text := `# comment
+max-count = 3
+Name: "bob"
+; another
+list:
+  - a
+  - 'b'
+serve:
+  port: 80
+  Dirs:
+    - x
+[copy]
+force=true
+`
+	got, err := parseCLIConfig(text)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{
+		"maxcount":   {"3"},
+		"name":       {"bob"},
+		"list":       {"a", "b"},
+		"serve.port": {"80"},
+		"serve.dirs": {"x"},
+		"copy.force": {"true"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	got, err = parseCLIConfig(`{"max_count": 3, "serve": {"port": 80.5}, "list": ["a", true]}`)
+	want = map[string][]string{"maxcount": {"3"}, "serve.port": {"80.5"}, "list": {"a", "true"}}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("json: %v %v", err, got)
+	}
+	for _, bad := range []string{"- item", "novalue", "{bad json"} {
+		if _, err := parseCLIConfig(bad); err == nil {
+			t.Errorf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestCLIUsage(t *testing.T) {
+	SetStringBasedEnum("clitest.modes", "fast", "slow")
+	var r cliTestRoot
+	c := &CLI{Name: "prog", EnvPrefix: "CLITEST_"}
+	usage := c.Usage(&r, []string{"serve"})
+	for _, want := range []string{"Usage: prog serve [options] [<dir>]", "-p, --port <int>", "(default: 8080, env: CLITEST_SERVE_PORT)", "Global options:", "--mode <fast|slow>"} {
+		if !strings.Contains(usage, want) {
+			t.Errorf("usage has no %q:\n%s", want, usage)
+		}
+	}
+}
+
+type cliTestOldStyle struct {
+	Description string `zui:"desc:Copies things"`
+	Source      string
+	Dest        string `zui:"default:out"`
+	Extra       []int  `zui:"allowempty"`
+	Q           *bool
+	Level       *int `zui:"title:lvl"`
+}
+
+func TestParseCommandArgsToStructFields(t *testing.T) {
+	var s cliTestOldStyle
+	err := ParseCommandArgsToStructFields([]string{"-q", "src", "--lvl", "3", "dst", "1", "2"}, reflect.ValueOf(&s))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Source != "src" || s.Dest != "dst" || !reflect.DeepEqual(s.Extra, []int{1, 2}) || s.Q == nil || !*s.Q || s.Level == nil || *s.Level != 3 {
+		t.Errorf("%+v", s)
+	}
+	s = cliTestOldStyle{}
+	err = ParseCommandArgsToStructFields([]string{"src"}, reflect.ValueOf(&s))
+	if err != nil || s.Dest != "out" || s.Q != nil || s.Level != nil {
+		t.Errorf("defaults: %v %+v", err, s)
+	}
+	if err := ParseCommandArgsToStructFields(nil, reflect.ValueOf(&s)); err == nil {
+		t.Error("missing argument accepted")
+	}
+	help := GetCommandArgsHelpForStructFields(cliTestOldStyle{})
+	var keys []string
+	for _, h := range help {
+		keys = append(keys, h.Key)
+	}
+	want := []string{"Description", "source", "[dest]", "[extra]", "[-q]", "[--lvl <int>]"}
+	if !reflect.DeepEqual(keys, want) {
+		t.Errorf("help keys: %v, want %v", keys, want)
+	}
+	if help[0].Value != "Copies things" || help[2].Value != "(default: out)" {
+		t.Error("help values:", help)
+	}
+}
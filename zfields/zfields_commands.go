@@ -3,13 +3,7 @@ package zfields
 import (
 	"fmt"
 	"reflect"
-	"strconv"
-	"strings"
 
-	"github.com/torlangballe/zutil/zbool"
-	"github.com/torlangballe/zutil/zlog"
-	"github.com/torlangballe/zutil/zreflect"
-	"github.com/torlangballe/zutil/zslices"
 	"github.com/torlangballe/zutil/zstr"
 )
 
@@ -25,168 +19,53 @@ func GetDescriptionFromZUIField(structure any) string {
 	return desc
 }
 
+// GetCommandArgsHelpForStructFields returns a help row for each field of s, and its Description first if it has one.
+// Pointer fields are [--options], other fields positional arguments, see ParseCommandArgsToStructFields.
 func GetCommandArgsHelpForStructFields(s any) []zstr.KeyValue {
+	rval := reflect.ValueOf(s)
+	if rval.Kind() != reflect.Pointer {
+		pval := reflect.New(rval.Type())
+		pval.Elem().Set(rval)
+		rval = pval
+	}
+	c := &CLI{pointersAreOptions: true}
+	cmd := c.makeCommand(c.name(), rval.Elem(), nil)
 	var args []zstr.KeyValue
-	ForEachField(s, FieldParameters{}, nil, func(each FieldInfo) bool {
-		kind := zreflect.KindFromReflectKindAndType(each.ReflectValue.Kind(), each.ReflectValue.Type())
-		isPointer := (kind == zreflect.KindPointer)
-		name := each.Field.FieldName
-		if name == "Description" && each.Field.Description != "" {
-			arg := zstr.KeyValue{Key: "Description", Value: each.Field.Description}
-			args = append(args, arg)
-			return true
-		}
-		if each.Field.Title != "" {
-			name = each.Field.Title
-		}
-		name = strings.ToLower(name)
+	if cmd.description != "" {
+		args = append(args, zstr.KeyValue{Key: "Description", Value: cmd.description})
+	}
+	for _, o := range cmd.fields {
 		var arg zstr.KeyValue
-		if isPointer {
-			eval := reflect.New(each.ReflectValue.Type().Elem()).Elem()
-			// zlog.Info("GetCommandArgsHelpForStructFields:", eval, each.Field.Title, each.Field.Name)
-			kind := zreflect.KindFromReflectKindAndType(eval.Kind(), eval.Type())
-			if len(name) == 1 && kind == zreflect.KindBool {
-				arg.Key = fmt.Sprintf("[-%s]", name)
+		if o.rval.Kind() == reflect.Pointer {
+			if o.short != "" {
+				arg.Key = "[-" + o.short + "]"
 			} else {
-				arg.Key = fmt.Sprintf("[--%s <%s>]", name, kind)
+				arg.Key = fmt.Sprintf("[--%s <%s>]", o.long, o.valueName)
 			}
 		} else {
-			arg.Key = fmt.Sprintf("%s", name)
-			if each.Field.Flags&FlagAllowEmptyAsZero != 0 {
+			arg.Key = o.long
+			if o.field.HasFlag(FlagAllowEmptyAsZero) {
 				arg.Key = "[" + arg.Key + "]"
 			}
 		}
-		var info string
-		info = each.Field.Description
-		if info == "" {
-			info = each.Field.Tooltip
-		}
-		if each.Field.HasFlag(FlagHasDefault) {
-			info = zstr.Concat(".", info, " default: "+each.Field.Default)
+		if o.field.HasFlag(FlagHasDefault) {
 			arg.Key = "[" + arg.Key + "]"
 		}
-		arg.Value = info
+		arg.Value = c.optionInfo(o)
 		args = append(args, arg)
-		return true
-	})
+	}
 	return args
 }
 
+// ParseCommandArgsToStructFields sets the fields of the struct rval is, or points to, from args with a CLI.
+// Fields that aren't pointers are positional arguments in order, a slice one taking the rest,
+// and must be given unless they have a default or allowempty tag.
+// Pointer fields are options, given as --name value, or for a bool with a one-letter name as -x.
 func ParseCommandArgsToStructFields(args []string, rval reflect.Value) error {
-	var err error
-	var hasAllowEmpty bool
-	ForEachField(rval.Interface(), FieldParameters{}, nil, func(each FieldInfo) bool {
-		// kind := zreflect.KindFromReflectKindAndType(each.ReflectValue.Kind(), each.ReflectValue.Type())
-		kind := each.ReflectValue.Kind()
-		isPointer := (kind == reflect.Pointer)
-		name := each.Field.FieldName
-		if name == "Description" {
-			return true
-		}
-		name = strings.ToLower(name)
-		if each.Field.Title != "" {
-			name = each.Field.Title
-		}
-		if isPointer {
-			if len(args) == 0 {
-				return false
-			}
-			for i, a := range args {
-				if kind == reflect.Bool && a == "-"+name {
-					each.ReflectValue.SetBool(true)
-					zslices.RemoveAt(&args, i)
-					break
-				}
-				zlog.Info("ParseCommandArgsToStructFields:", a, each.ReflectValue.Type(), name)
-				if a == "--"+name {
-					i++
-					a = args[i]
-					each.ReflectValue.Set(reflect.New(each.ReflectValue.Type().Elem())) // we make it point to an new'ed instance
-					err = setStrToRVal(a, each.Field, each.ReflectValue.Elem(), name)
-					if err != nil {
-						zlog.OnError(err)
-						return false
-					}
-					zslices.RemoveAt(&args, i)
-					break
-				}
-			}
-			return true
-		}
-		var arg string
-		if len(args) == 0 {
-			if each.Field.HasFlag(FlagHasDefault) {
-				arg = each.Field.Default
-			} else {
-				if each.Field.Flags&FlagAllowEmptyAsZero != 0 {
-					if !hasAllowEmpty {
-						return true
-					}
-					hasAllowEmpty = true
-				}
-				err = zlog.NewError("no argument for", name)
-				return false
-			}
-		} else {
-			arg = zstr.ExtractFirstString(&args)
-		}
-		isSlice := each.ReflectValue.Kind() == reflect.Slice
-		for {
-			serr := setStrToRVal(arg, each.Field, each.ReflectValue, name)
-			if serr != nil {
-				if isSlice {
-					break
-				}
-				err = serr
-				return true
-			}
-			if !isSlice {
-				break
-			}
-			arg = zstr.ExtractFirstString(&args)
-			if arg == "" {
-				break
-			}
-		}
-		return true
-	})
-	return err
-}
-
-func setStrToRVal(arg string, f *Field, rval reflect.Value, name string) error {
-	var sliceVal reflect.Value
-	if rval.Kind() == reflect.Slice {
-		sliceVal = rval
-		rval = zslices.MakeAnElementOfSliceRValType(sliceVal)
-	}
-	kind := zreflect.KindFromReflectKindAndType(rval.Kind(), rval.Type())
-
-	switch kind {
-	case zreflect.KindString:
-		rval.SetString(arg)
-	case zreflect.KindBool:
-		b, err := zbool.FromStringWithError(arg)
-		if err != nil {
-			return err
-		}
-		rval.SetBool(b)
-	case zreflect.KindInt:
-		n, err := strconv.ParseInt(arg, 10, 64)
-		if err != nil {
-			return err
-		}
-		rval.SetInt(n)
-	case zreflect.KindFloat:
-		r, err := strconv.ParseFloat(arg, 64)
-		if err != nil {
-			return err
-		}
-		rval.SetFloat(r)
-	default:
-		return zlog.NewError("unsupported arg type:", kind, "for", name, "argument")
+	if rval.Kind() == reflect.Pointer {
+		rval = rval.Elem()
 	}
-	if sliceVal.IsValid() {
-		zslices.RValAddAtEnd(sliceVal.Addr(), rval)
-	}
-	return nil // never gets here
+	c := &CLI{pointersAreOptions: true}
+	_, err := c.Parse(rval.Addr().Interface(), args)
+	return err
 }
@@ -0,0 +1,424 @@
+package zfields
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// This file is about showing a struct as a plain HTML form, and setting it from the form when posted,
+// for pages that must work without loading the wasm gui, like kiosks or status pages fetched with curl.
+// It uses the same zui tags as FieldView: editable fields are inputs, static ones text, bools checkboxes,
+// enums selects, nested structs fieldsets and slices of structs tables with a row of inputs per struct.
+// Inputs are named with the field's path as in FieldError.FieldPath, so errors from Validate are shown at their field.
+
+// HTMLForm renders a struct as an HTML form, see HTML and ParseHTMLForm.
+type HTMLForm struct {
+	Action      string           // Action is the URL the form is posted to, the page's own if empty
+	SubmitTitle string           // SubmitTitle is the title of the submit button, "Save" if empty. There is no button if Params.AllStatic is set
+	Message     string           // Message is shown above the fields, for example that the form was saved
+	Params      FieldParameters  // Params are used when getting the struct's fields, as for a FieldView
+	Errors      ValidationErrors // Errors are shown at their fields, typically from a failed ParseHTMLForm
+	CSRFToken   string           // CSRFToken is put in a hidden input named HTMLCSRFTokenName if set, for the server to check it is posted back
+}
+
+// HTMLCSRFTokenName is the name of the hidden input with HTMLForm.CSRFToken.
+const HTMLCSRFTokenName = "zcsrftoken"
+
+// HTML returns structPtr as a <form> element, with a class of zfield, zdesc, zerror etc on parts so it can be styled.
+func (h *HTMLForm) HTML(structPtr any) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, `<form class="zform" method="post" action="%s">`+"\n", html.EscapeString(h.Action))
+	if h.CSRFToken != "" {
+		fmt.Fprintf(&b, `<input type="hidden" name="%s" value="%s">`+"\n", HTMLCSRFTokenName, html.EscapeString(h.CSRFToken))
+	}
+	if h.Message != "" {
+		fmt.Fprintf(&b, `<div class="zmessage">%s</div>`+"\n", html.EscapeString(h.Message))
+	}
+	var unplaced []string
+	for _, e := range h.Errors {
+		if e.FieldPath == "" {
+			unplaced = append(unplaced, e.Error())
+		}
+	}
+	if len(unplaced) != 0 {
+		fmt.Fprintf(&b, `<div class="zerror">%s</div>`+"\n", html.EscapeString(strings.Join(unplaced, "; ")))
+	}
+	h.writeStruct(&b, reflect.ValueOf(structPtr).Elem(), "")
+	if !h.Params.AllStatic {
+		title := h.SubmitTitle
+		if title == "" {
+			title = "Save"
+		}
+		fmt.Fprintf(&b, `<button type="submit">%s</button>`+"\n", html.EscapeString(title))
+	}
+	b.WriteString("</form>\n")
+	return b.String()
+}
+
+func (h *HTMLForm) isStatic(f *Field) bool {
+	return h.Params.AllStatic || f.IsStatic()
+}
+
+// htmlFieldKind is how a field is shown in an HTML form, or htmlSkip if it isn't.
+type htmlFieldKind int
+
+const (
+	htmlSkip htmlFieldKind = iota
+	htmlValue
+	htmlStruct
+	htmlTable
+)
+
+// htmlKind returns how a field of rval is shown, and rval with pointers to structs followed.
+func htmlKind(f *Field, rval reflect.Value) (htmlFieldKind, reflect.Value) {
+	if f.HasFlag(FlagIsButton) {
+		return htmlSkip, rval
+	}
+	t := rval.Type()
+	if t.Kind() == reflect.Pointer && isHTMLStruct(t.Elem()) {
+		if rval.IsNil() {
+			return htmlSkip, rval
+		}
+		rval = rval.Elem()
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Map, reflect.Func, reflect.Chan, reflect.Interface:
+		return htmlSkip, rval
+	case reflect.Slice:
+		e := t.Elem()
+		if e.Kind() == reflect.Pointer {
+			e = e.Elem()
+		}
+		if isHTMLStruct(e) {
+			return htmlTable, rval
+		}
+	}
+	if isHTMLStruct(t) {
+		return htmlStruct, rval
+	}
+	return htmlValue, rval
+}
+
+func isHTMLStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{})
+}
+
+func (h *HTMLForm) writeStruct(b *strings.Builder, rval reflect.Value, path string) {
+	ForEachField(rval.Addr().Interface(), h.Params, nil, func(each FieldInfo) bool {
+		f := each.Field
+		name := path + f.FieldName
+		kind, v := htmlKind(f, each.ReflectValue)
+		switch kind {
+		case htmlStruct:
+			fmt.Fprintf(b, `<fieldset class="zstruct"><legend>%s</legend>`+"\n", html.EscapeString(f.TitleOrName()))
+			h.writeStruct(b, v, name+".")
+			b.WriteString("</fieldset>\n")
+		case htmlTable:
+			h.writeTable(b, f, v, name)
+		case htmlValue:
+			b.WriteString(`<div class="zfield">`)
+			if !f.HasFlag(FlagNoTitle) {
+				fmt.Fprintf(b, `<label for="%s">%s</label> `, html.EscapeString(name), html.EscapeString(f.TitleOrName()))
+			}
+			h.writeValue(b, f, v, name)
+			if f.Description != "" {
+				fmt.Fprintf(b, ` <span class="zdesc">%s</span>`, html.EscapeString(f.Description))
+			}
+			h.writeError(b, name)
+			b.WriteString("</div>\n")
+		}
+		return true
+	})
+}
+
+func (h *HTMLForm) writeError(b *strings.Builder, name string) {
+	fe := h.Errors.ForField(name)
+	if fe != nil {
+		fmt.Fprintf(b, ` <span class="zerror">%s</span>`, html.EscapeString(fe.Message))
+	}
+}
+
+// writeTable writes a slice of structs as a table with a column per field, as a SliceGridView does.
+// Nested structs and slices aren't shown in the table.
+func (h *HTMLForm) writeTable(b *strings.Builder, f *Field, rval reflect.Value, name string) {
+	params := h.Params
+	params.UseInValues = append(append([]string{}, params.UseInValues...), RowUseInSpecialName)
+	row := *h
+	row.Params = params
+	fmt.Fprintf(b, `<fieldset class="ztable"><legend>%s</legend>`+"\n", html.EscapeString(f.TitleOrName()))
+	if f.Description != "" {
+		fmt.Fprintf(b, `<div class="zdesc">%s</div>`+"\n", html.EscapeString(f.Description))
+	}
+	b.WriteString("<table>\n<tr>")
+	e := reflect.New(rval.Type().Elem())
+	if e.Elem().Kind() == reflect.Pointer {
+		e = reflect.New(e.Elem().Type().Elem())
+	}
+	ForEachField(e.Interface(), params, nil, func(each FieldInfo) bool {
+		if kind, _ := htmlKind(each.Field, each.ReflectValue); kind == htmlValue {
+			fmt.Fprintf(b, "<th>%s</th>", html.EscapeString(each.Field.TitleOrName()))
+		}
+		return true
+	})
+	b.WriteString("</tr>\n")
+	for i := 0; i < rval.Len(); i++ {
+		e := rval.Index(i)
+		if e.Kind() == reflect.Pointer {
+			if e.IsNil() {
+				continue
+			}
+			e = e.Elem()
+		}
+		b.WriteString("<tr>")
+		ForEachField(e.Addr().Interface(), params, nil, func(each FieldInfo) bool {
+			kind, v := htmlKind(each.Field, each.ReflectValue)
+			if kind != htmlValue {
+				return true
+			}
+			cname := fmt.Sprintf("%s[%d].%s", name, i, each.Field.FieldName)
+			b.WriteString("<td>")
+			row.writeValue(b, each.Field, v, cname)
+			row.writeError(b, cname)
+			b.WriteString("</td>")
+			return true
+		})
+		b.WriteString("</tr>\n")
+	}
+	b.WriteString("</table>\n</fieldset>\n")
+}
+
+// writeValue writes rval as text if it is static, otherwise as an input named name.
+func (h *HTMLForm) writeValue(b *strings.Builder, f *Field, rval reflect.Value, name string) {
+	ename := html.EscapeString(name)
+	if h.isStatic(f) {
+		fmt.Fprintf(b, `<span class="zstatic" id="%s">%s</span>`, ename, html.EscapeString(htmlStaticText(f, rval)))
+		return
+	}
+	attrs := fmt.Sprintf(`id="%s" name="%s"`, ename, ename)
+	if f.Tooltip != "" {
+		attrs += fmt.Sprintf(` title="%s"`, html.EscapeString(f.Tooltip))
+	}
+	if f.Required == RequiredSingleValue {
+		attrs += " required"
+	}
+	if f.Placeholder != "" {
+		attrs += fmt.Sprintf(` placeholder="%s"`, html.EscapeString(f.Placeholder))
+	}
+	v := rval
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			v = reflect.Zero(v.Type().Elem())
+		} else {
+			v = v.Elem()
+		}
+	}
+	if v.Kind() == reflect.Bool {
+		// An unchecked checkbox isn't posted, so a hidden false before it is, and ParseHTMLForm uses the last value.
+		checked := ""
+		if v.Bool() {
+			checked = " checked"
+		}
+		fmt.Fprintf(b, `<input type="hidden" name="%s" value="false"><input type="checkbox" %s value="true"%s>`, ename, attrs, checked)
+		return
+	}
+	if f.Enum != "" && v.Kind() != reflect.Slice {
+		enum := GetEnum(f.Enum)
+		fmt.Fprintf(b, `<select %s>`, attrs)
+		if f.HasFlag(FlagAllowEmptyAsZero) || enum.FindValue(v.Interface()) == nil {
+			b.WriteString(`<option value=""></option>`)
+		}
+		for _, item := range enum {
+			str := fmt.Sprint(item.Value)
+			selected := ""
+			if str == fmt.Sprint(v.Interface()) {
+				selected = " selected"
+			}
+			fmt.Fprintf(b, `<option value="%s"%s>%s</option>`, html.EscapeString(str), selected, html.EscapeString(item.Name))
+		}
+		b.WriteString("</select>")
+		return
+	}
+	text := htmlValueText(f, rval)
+	if f.HasFlag(FlagIsPassword) {
+		// The password isn't sent to the browser; an empty one is left as it was by ParseHTMLForm.
+		fmt.Fprintf(b, `<input type="password" %s autocomplete="off">`, attrs)
+		return
+	}
+	if f.Rows > 1 && v.Kind() == reflect.String {
+		fmt.Fprintf(b, `<textarea %s rows="%d">%s</textarea>`, attrs, f.Rows, html.EscapeString(text))
+		return
+	}
+	itype := "text"
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if v.Type() != reflect.TypeOf(time.Duration(0)) {
+			itype = "number"
+		}
+	case reflect.Float32, reflect.Float64:
+		itype = "number"
+		attrs += ` step="any"`
+	}
+	fmt.Fprintf(b, `<input type="%s" %s value="%s">`, itype, attrs, html.EscapeString(text))
+}
+
+// htmlValueText is the text of rval in an input, as ParseHTMLForm reads it.
+func htmlValueText(f *Field, rval reflect.Value) string {
+	if rval.Kind() == reflect.Pointer {
+		if rval.IsNil() {
+			return ""
+		}
+		rval = rval.Elem()
+	}
+	if f.HasFlag(FlagAllowEmptyAsZero) && rval.IsZero() {
+		return ""
+	}
+	switch v := rval.Interface().(type) {
+	case time.Duration:
+		return v.String()
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	}
+	if rval.Kind() == reflect.Slice {
+		var parts []string
+		for i := 0; i < rval.Len(); i++ {
+			parts = append(parts, htmlValueText(f, rval.Index(i)))
+		}
+		return strings.Join(parts, htmlListSeparator(f))
+	}
+	return fmt.Sprint(rval.Interface())
+}
+
+// htmlStaticText is rval as text when static, using the zerotext, prefix and suffix tags and enum names.
+func htmlStaticText(f *Field, rval reflect.Value) string {
+	if f.HasFlag(FlagIsPassword) {
+		return "••••••"
+	}
+	if rval.Kind() == reflect.Pointer {
+		if rval.IsNil() {
+			return f.ZeroText
+		}
+		rval = rval.Elem()
+	}
+	if rval.IsZero() && f.ZeroText != "" {
+		return f.ZeroText
+	}
+	if rval.Kind() == reflect.Bool {
+		if rval.Bool() {
+			return "yes"
+		}
+		return "no"
+	}
+	if f.Enum != "" && rval.Kind() != reflect.Slice {
+		if item := GetEnum(f.Enum).FindValue(rval.Interface()); item != nil {
+			return item.Name
+		}
+	}
+	if t, is := rval.Interface().(time.Time); is && !t.IsZero() {
+		return t.Local().Format("2006-01-02 15:04:05")
+	}
+	str := htmlValueText(f, rval)
+	if str == "" {
+		return ""
+	}
+	return f.Prefix + str + f.Suffix
+}
+
+func htmlListSeparator(f *Field) string {
+	if f.StringSep != "" {
+		return f.StringSep
+	}
+	return ","
+}
+
+// ParseHTMLForm sets structPtr from values posted from a form made with HTMLForm.HTML with the same params,
+// then validates it with ValidateWithParameters.
+// Static fields and fields not in values are left as they are, as are passwords posted empty.
+// Values that can't be parsed are returned as a ValidationErrors, as are invalid fields.
+func ParseHTMLForm(structPtr any, values url.Values, params FieldParameters) error {
+	var errs ValidationErrors
+	h := HTMLForm{Params: params}
+	h.parseStruct(reflect.ValueOf(structPtr).Elem(), "", values, &errs)
+	if len(errs) != 0 {
+		return errs
+	}
+	return ValidateWithParameters(structPtr, params)
+}
+
+func (h *HTMLForm) parseStruct(rval reflect.Value, path string, values url.Values, errs *ValidationErrors) {
+	ForEachField(rval.Addr().Interface(), h.Params, nil, func(each FieldInfo) bool {
+		f := each.Field
+		name := path + f.FieldName
+		kind, v := htmlKind(f, each.ReflectValue)
+		switch kind {
+		case htmlStruct:
+			h.parseStruct(v, name+".", values, errs)
+		case htmlTable:
+			row := *h
+			row.Params.UseInValues = append(append([]string{}, h.Params.UseInValues...), RowUseInSpecialName)
+			for i := 0; i < v.Len(); i++ {
+				e := v.Index(i)
+				if e.Kind() == reflect.Pointer {
+					if e.IsNil() {
+						continue
+					}
+					e = e.Elem()
+				}
+				row.parseStruct(e, fmt.Sprintf("%s[%d].", name, i), values, errs)
+			}
+		case htmlValue:
+			posted, got := values[name]
+			if !got || len(posted) == 0 || h.isStatic(f) {
+				return true
+			}
+			err := setHTMLValue(f, v, posted[len(posted)-1])
+			if err != nil {
+				*errs = append(*errs, FieldError{FieldPath: name, Title: f.TitleOrName(), Message: err.Error()})
+			}
+		}
+		return true
+	})
+}
+
+// setHTMLValue sets rval from the posted str. Empty sets pointers to nil, and slices and allowempty fields to zero.
+func setHTMLValue(f *Field, rval reflect.Value, str string) error {
+	if rval.Kind() != reflect.String || f.Rows <= 1 {
+		str = strings.TrimSpace(str)
+	}
+	if f.HasFlag(FlagIsPassword) && str == "" {
+		return nil
+	}
+	if rval.Kind() == reflect.Pointer {
+		if str == "" {
+			rval.SetZero()
+			return nil
+		}
+		rval.Set(reflect.New(rval.Type().Elem()))
+		rval = rval.Elem()
+	}
+	if str == "" && (rval.Kind() == reflect.Slice || rval.Kind() != reflect.String && f.HasFlag(FlagAllowEmptyAsZero)) {
+		rval.SetZero()
+		return nil
+	}
+	if rval.Kind() == reflect.Slice && rval.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(rval.Type(), 0, 0)
+		for _, part := range splitCLIList(str, htmlListSeparator(f)) {
+			e := reflect.New(rval.Type().Elem()).Elem()
+			err := setCLIValue(part, f, e)
+			if err != nil {
+				return err
+			}
+			slice = reflect.Append(slice, e)
+		}
+		rval.Set(slice)
+		return nil
+	}
+	return setCLIValue(str, f, rval)
+}
@@ -0,0 +1,140 @@
+package zfields
+
+import (
+	"html"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type htmlTestItem struct {
+	Name  string
+	Count int
+}
+
+type htmlTestConfig struct {
+	ID       int    `zui:"static"`
+	Name     string `zui:"title:Full Name"`
+	Password string `zui:"password"`
+	On       bool
+	Mode     string `zui:"enum:htmltest.modes"`
+	Wait     time.Duration
+	Tags     []string
+	Items    []htmlTestItem
+}
+
+var (
+	htmlInputRegex  = regexp.MustCompile(`<input ([^>]*)>`)
+	htmlSelectRegex = regexp.MustCompile(`<select ([^>]*)>(.*?)</select>`)
+	htmlOptionRegex = regexp.MustCompile(`<option value="([^"]*)"( selected)?>`)
+	htmlAttrRegex   = regexp.MustCompile(`(\w+)="([^"]*)"`)
+)
+
+// htmlPostedValues returns what a browser would post for the form in shtml, if nothing is changed.
+func htmlPostedValues(shtml string) url.Values {
+	values := url.Values{}
+	for _, m := range htmlInputRegex.FindAllStringSubmatch(shtml, -1) {
+		attrs := map[string]string{}
+		for _, a := range htmlAttrRegex.FindAllStringSubmatch(m[1], -1) {
+			attrs[a[1]] = html.UnescapeString(a[2])
+		}
+		if attrs["type"] == "checkbox" && !strings.Contains(m[1], " checked") {
+			continue
+		}
+		values.Add(attrs["name"], attrs["value"])
+	}
+	for _, m := range htmlSelectRegex.FindAllStringSubmatch(shtml, -1) {
+		name := htmlAttrRegex.FindStringSubmatch(m[1])
+		for _, o := range htmlOptionRegex.FindAllStringSubmatch(m[2], -1) {
+			if o[2] != "" {
+				values.Set(html.UnescapeString(name[2]), html.UnescapeString(o[1]))
+			}
+		}
+	}
+	return values
+}
+
+func makeHTMLTestConfig() htmlTestConfig {
+	SetStringBasedEnum("htmltest.modes", "fast", "slow")
+	return htmlTestConfig{
+		ID:       3,
+		Name:     "bob",
+		Password: "secret",
+		On:       true,
+		Mode:     "fast",
+		Wait:     time.Second,
+		Tags:     []string{"a", "b"},
+		Items:    []htmlTestItem{{"x", 1}, {"y", 2}},
+	}
+}
+
+func TestHTMLFormRoundTrip(t *testing.T) {
+	c := makeHTMLTestConfig()
+	form := HTMLForm{CSRFToken: "tok<en>"}
+	shtml := form.HTML(&c)
+	if strings.Contains(shtml, "secret") {
+		t.Error("password sent in form")
+	}
+	values := htmlPostedValues(shtml)
+	if values.Get(HTMLCSRFTokenName) != "tok<en>" {
+		t.Error("no csrf token:", values[HTMLCSRFTokenName])
+	}
+	if values.Has("ID") {
+		t.Error("static field has input")
+	}
+
+	got := c
+	err := ParseHTMLForm(&got, values, FieldParameters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, c) {
+		t.Errorf("unchanged post changed struct:\n%+v\n%+v", got, c)
+	}
+
+	values["On"] = []string{"false"} // only the hidden input is posted for an unchecked checkbox
+	values.Set("Mode", "slow")
+	values.Set("Password", "new")
+	values.Set("Items[1].Count", "5")
+	values.Set("Tags", "q, r")
+	values.Set("ID", "9")
+	err = ParseHTMLForm(&got, values, FieldParameters{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := c
+	want.On = false
+	want.Mode = "slow"
+	want.Password = "new"
+	want.Tags = []string{"q", "r"}
+	want.Items = []htmlTestItem{{"x", 1}, {"y", 5}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("edited post:\n%+v\nwant\n%+v", got, want)
+	}
+
+	shtml = (&HTMLForm{}).HTML(&got)
+	values = htmlPostedValues(shtml)
+	if values.Get("On") != "false" || values.Get("Mode") != "slow" || values.Get("Items[1].Count") != "5" {
+		t.Error("rendered edit not posted back:", values)
+	}
+}
+
+func TestHTMLFormErrors(t *testing.T) {
+	c := makeHTMLTestConfig()
+	values := url.Values{"Wait": {"soon"}, "Items[0].Count": {"x"}, "Name": {"al"}}
+	err := ParseHTMLForm(&c, values, FieldParameters{})
+	verrs, _ := err.(ValidationErrors)
+	if len(verrs) != 2 || verrs.ForField("Wait") == nil || verrs.ForField("Items[0].Count") == nil {
+		t.Fatal("expected errors for Wait and Items[0].Count:", err)
+	}
+	if c.Wait != time.Second || c.Items[0].Count != 1 {
+		t.Errorf("bad values changed struct: %+v", c)
+	}
+	shtml := (&HTMLForm{Errors: verrs}).HTML(&c)
+	if strings.Count(shtml, `class="zerror"`) != 2 {
+		t.Error("errors not shown at their fields:", shtml)
+	}
+}
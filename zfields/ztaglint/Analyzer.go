@@ -0,0 +1,154 @@
+// Package ztaglint is a go vet-style analyzer that checks zui struct tags,
+// using zfields.ValidateTag with the same keys Field.SetFromRVal understands.
+// It reports unknown keys, bad values, conflicting keys and enum names never registered with zfields.SetEnum and friends.
+// Only enums registered in a package or its imports are seen, so use -enums=false if they are registered elsewhere.
+// Run it with the ztaglint command, or as a vet tool: go vet -vettool=$(which ztaglint) ./...
+package ztaglint
+
+import (
+	"go/ast"
+	"go/constant"
+	"go/types"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/torlangballe/zui/zfields"
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+	"golang.org/x/tools/go/types/typeutil"
+)
+
+const zfieldsPath = "github.com/torlangballe/zui/zfields"
+
+// enumRegisterFuncs are the zfields functions that register an enum with its name as first argument.
+var enumRegisterFuncs = map[string]bool{
+	"SetEnum":            true,
+	"SetEnumItems":       true,
+	"SetEnumIntRange":    true,
+	"SetStringBasedEnum": true,
+	"SetAnyToEnum":       true,
+	"AppendEnumItem":     true,
+}
+
+// enumsFact is exported for each package that registers enums, so packages importing it can use them.
+type enumsFact struct {
+	Names   []string
+	Dynamic bool // Dynamic is set if an enum is registered with a non-constant name, so all names are possible
+}
+
+func (*enumsFact) AFact() {}
+
+func (f *enumsFact) String() string {
+	str := "enums(" + strings.Join(f.Names, ", ") + ")"
+	if f.Dynamic {
+		str += " dynamic"
+	}
+	return str
+}
+
+var checkEnums bool
+
+var Analyzer = &analysis.Analyzer{
+	Name:      "ztaglint",
+	Doc:       "check zui struct tags for unknown keys, bad values, conflicting keys and unregistered enums",
+	Run:       run,
+	Requires:  []*analysis.Analyzer{inspect.Analyzer},
+	FactTypes: []analysis.Fact{new(enumsFact)},
+}
+
+func init() {
+	Analyzer.Flags.BoolVar(&checkEnums, "enums", true, "report enum and radio names not registered in the package or its imports")
+}
+
+func run(pass *analysis.Pass) (any, error) {
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	enums := map[string]bool{}
+	dynamic := registeredEnums(pass, insp, enums)
+	for _, pf := range pass.AllPackageFacts() {
+		ef, _ := pf.Fact.(*enumsFact)
+		if ef == nil {
+			continue
+		}
+		dynamic = dynamic || ef.Dynamic
+		for _, n := range ef.Names {
+			enums[n] = true
+		}
+	}
+	enumExists := func(name string) bool {
+		return !checkEnums || dynamic || enums[name]
+	}
+	insp.Preorder([]ast.Node{(*ast.StructType)(nil)}, func(n ast.Node) {
+		st := n.(*ast.StructType)
+		localExists := func(name string) bool {
+			for _, field := range st.Fields.List {
+				for _, fn := range field.Names {
+					if fn.Name == name {
+						return true
+					}
+				}
+				if len(field.Names) == 0 && embeddedName(field.Type) == name {
+					return true
+				}
+			}
+			return false
+		}
+		for _, field := range st.Fields.List {
+			if field.Tag == nil {
+				continue
+			}
+			str, err := strconv.Unquote(field.Tag.Value)
+			if err != nil {
+				continue
+			}
+			tag, got := reflect.StructTag(str).Lookup("zui")
+			if !got {
+				continue
+			}
+			for _, issue := range zfields.ValidateTag(tag, enumExists, localExists) {
+				pass.Reportf(field.Tag.Pos(), "%s", issue.Error())
+			}
+		}
+	})
+	return nil, nil
+}
+
+// registeredEnums adds names of enums registered in pass's package to enums, exporting them as a fact.
+// It returns true if any are registered with a non-constant name.
+func registeredEnums(pass *analysis.Pass, insp *inspector.Inspector, enums map[string]bool) (dynamic bool) {
+	fact := &enumsFact{}
+	insp.Preorder([]ast.Node{(*ast.CallExpr)(nil)}, func(n ast.Node) {
+		call := n.(*ast.CallExpr)
+		fn, _ := typeutil.Callee(pass.TypesInfo, call).(*types.Func)
+		if fn == nil || fn.Pkg() == nil || fn.Pkg().Path() != zfieldsPath || !enumRegisterFuncs[fn.Name()] || len(call.Args) == 0 {
+			return
+		}
+		tv := pass.TypesInfo.Types[call.Args[0]]
+		if tv.Value == nil || tv.Value.Kind() != constant.String {
+			fact.Dynamic = true
+			return
+		}
+		name := constant.StringVal(tv.Value)
+		if !enums[name] {
+			fact.Names = append(fact.Names, name)
+		}
+		enums[name] = true
+	})
+	if len(fact.Names) != 0 || fact.Dynamic {
+		pass.ExportPackageFact(fact)
+	}
+	return fact.Dynamic
+}
+
+func embeddedName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.StarExpr:
+		return embeddedName(t.X)
+	case *ast.SelectorExpr:
+		return t.Sel.Name
+	}
+	return ""
+}
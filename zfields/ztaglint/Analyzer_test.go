@@ -0,0 +1,23 @@
+package ztaglint
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+// The packages in testdata/src use a stand-in zfields package with the enum registering functions.
+// tags imports registers, dynamicuser imports dynamic, and nothing imports elsewhere.
+
+func TestAnalyzer(t *testing.T) {
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "tags", "dynamicuser")
+}
+
+func TestAnalyzerWithoutEnums(t *testing.T) {
+	err := Analyzer.Flags.Set("enums", "false")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer Analyzer.Flags.Set("enums", "true")
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "noenums")
+}
@@ -0,0 +1,14 @@
+// Command ztaglint checks zui struct tags in the packages given, like go vet does for other tags.
+//
+//	ztaglint ./...
+//	go vet -vettool=$(which ztaglint) ./...
+package main
+
+import (
+	"github.com/torlangballe/zui/zfields/ztaglint"
+	"golang.org/x/tools/go/analysis/singlechecker"
+)
+
+func main() {
+	singlechecker.Main(ztaglint.Analyzer)
+}
@@ -0,0 +1,8 @@
+// Package dynamic registers enums with names only known when running, so any enum name might exist.
+package dynamic
+
+import "github.com/torlangballe/zui/zfields"
+
+func Register(name string) {
+	zfields.SetStringBasedEnum(name, "x")
+}
@@ -0,0 +1,9 @@
+// Package dynamicuser imports a package that registers enums with non-constant names, so no enum names are reported.
+package dynamicuser
+
+import _ "dynamic"
+
+type Settings struct {
+	Anything string `zui:"enum:anything"`
+	Width    int    `zui:"widht:3"` // want `zui tag "widht": unknown key \(did you mean "width"\?\)`
+}
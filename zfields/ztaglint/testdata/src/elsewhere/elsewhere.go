@@ -0,0 +1,8 @@
+// Package elsewhere registers an enum, but isn't imported by the packages using it.
+package elsewhere
+
+import "github.com/torlangballe/zui/zfields"
+
+func init() {
+	zfields.SetStringBasedEnum("elsewhere", "a", "b")
+}
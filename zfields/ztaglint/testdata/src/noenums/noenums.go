@@ -0,0 +1,8 @@
+// Package noenums is checked with -enums=false, so enums registered in packages it doesn't import aren't reported.
+package noenums
+
+type Settings struct {
+	Elsewhere string `zui:"enum:elsewhere"`
+	Radio     string `zui:"radio:missing"`
+	Width     int    `zui:"widht:3"` // want `zui tag "widht": unknown key \(did you mean "width"\?\)`
+}
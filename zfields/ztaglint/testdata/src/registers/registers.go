@@ -0,0 +1,10 @@
+// Package registers registers an enum that packages importing it can use.
+package registers
+
+import "github.com/torlangballe/zui/zfields"
+
+const ColorsEnum = "colors"
+
+func init() {
+	zfields.SetStringBasedEnum(ColorsEnum, "red", "green")
+}
@@ -0,0 +1,31 @@
+package tags // want package:`enums\(local\)`
+
+import (
+	"github.com/torlangballe/zui/zfields"
+	_ "registers"
+)
+
+func init() {
+	zfields.SetEnumItems("local")
+}
+
+type Embedded struct{}
+
+type Settings struct {
+	Embedded
+	Color     string `zui:"enum:colors"`
+	Local     string `zui:"enum:local"`
+	Other     string `zui:"enum:./Local"`
+	Inherited string `zui:"enum:./Embedded"`
+	NoLocal   string `zui:"enum:./Nope"`         // want `zui tag "enum": no such local field: "./Nope"`
+	Radio     string `zui:"radio:missing"`       // want `zui tag "radio": enum not registered: "missing"`
+	Width     int    `zui:"widht:3"`             // want `zui tag "widht": unknown key \(did you mean "width"\?\)`
+	Height    int    `zui:"height:tall"`         // want `zui tag "height": not a number: "tall"`
+	Twice     int    `zui:"width:3,width:4"`     // want `zui tag "width": key used more than once`
+	Layout    bool   `zui:"vertical,horizontal"` // want `zui tag "vertical": conflicts with key horizontal`
+	Json      string `json:"json"`
+
+	// An enum registered in a package this one doesn't import is reported, as the analyzer only sees imported packages.
+	// Such enums must be registered in an imported package, or checked with -enums=false.
+	Elsewhere string `zui:"enum:elsewhere"` // want `zui tag "enum": enum not registered: "elsewhere"`
+}
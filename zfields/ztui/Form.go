@@ -0,0 +1,812 @@
+//go:build !js
+
+// Package ztui shows and edits structs as forms in a terminal, for tools run on servers without a browser.
+// It uses the same zui tags as zfields.FieldView: titles, descriptions, static fields, enums, passwords, allowempty and so on.
+// Strings and numbers are text inputs, bools checkboxes, enums menus chosen with the arrow keys,
+// nested structs are indented sections, and slices of structs are tables whose rows are edited in a form of their own.
+// Edits are written to the struct when saved with ctrl-s, then checked with zfields.Validate, as ToData does.
+package ztui
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/torlangballe/zui/zfields"
+	"github.com/torlangballe/zutil/zdict"
+)
+
+type rowKind int
+
+const (
+	rowStatic      rowKind = iota // a read-only value
+	rowHeader                     // the title of a nested struct or table
+	rowText                       // a text input for strings, numbers, durations and slices of them
+	rowCheckbox                   // a bool
+	rowEnum                       // a value chosen from an enum
+	rowTableHeader                // the column titles of a table
+	rowTableRow                   // a struct in a slice, pressing enter edits it in a form of its own
+)
+
+type row struct {
+	kind      rowKind
+	field     *zfields.Field
+	rval      reflect.Value
+	label     string
+	indent    int
+	path      string // path is the field's path as in zfields.FieldError.FieldPath
+	text      []rune // text is the edited text of a rowText
+	cursor    int
+	checked   bool
+	enum      zdict.Items
+	enumIndex int
+	table     *table
+	index     int           // index is the row's element in table
+	pending   reflect.Value // pending is a table row's element edited in its own form, set on save
+	changed   bool
+}
+
+type table struct {
+	columns []*zfields.Field
+	widths  []int
+}
+
+// Form is a struct shown in a terminal, see EditStruct.
+type Form struct {
+	Title     string
+	ReadOnly  bool                    // ReadOnly shows all fields as static, as zfields.FieldParameters.AllStatic does
+	Params    zfields.FieldParameters // Params are used when getting the struct's fields, as for a FieldView
+	structPtr any
+	rows      []*row
+	focus     int // focus is the index of the row being edited, or -1 if none can be
+	scroll    int
+	status    string
+	isError   bool
+}
+
+const (
+	maxLabelWidth  = 30
+	maxColumnWidth = 24
+	defaultWidth   = 80
+	defaultHeight  = 24
+)
+
+const (
+	escReset     = "\x1b[0m"
+	escBold      = "\x1b[1m"
+	escDim       = "\x1b[2m"
+	escUnderline = "\x1b[4m"
+	escReverse   = "\x1b[7m"
+	escRed       = "\x1b[31m"
+)
+
+// NewForm makes a form for structPtr, which is only changed when the form is saved.
+func NewForm(structPtr any, title string) *Form {
+	f := &Form{structPtr: structPtr, Title: title}
+	return f
+}
+
+// EditStruct shows structPtr in a form on the terminal until it is saved with ctrl-s or canceled with escape.
+// It returns true if it was saved and is valid, when structPtr is set to the edited values.
+func EditStruct[S any](structPtr *S, title string) (ok bool, err error) {
+	t, err := OpenTerminal()
+	if err != nil {
+		return false, err
+	}
+	defer t.Close()
+	edit := deepCopy(reflect.ValueOf(structPtr).Elem()).Interface().(S) // a deep copy, so slices etc in structPtr aren't changed if canceled
+	f := NewForm(&edit, title)
+	ok, err = f.Run(t)
+	if ok {
+		*structPtr = edit
+	}
+	return ok, err
+}
+
+// ViewStruct shows structPtr read-only on the terminal until escape or enter is pressed.
+func ViewStruct(structPtr any, title string) error {
+	t, err := OpenTerminal()
+	if err != nil {
+		return err
+	}
+	defer t.Close()
+	f := NewForm(structPtr, title)
+	f.ReadOnly = true
+	_, err = f.Run(t)
+	return err
+}
+
+func (f *Form) build() {
+	f.rows = nil
+	f.addRows(reflect.ValueOf(f.structPtr).Elem(), 0, "")
+	f.focus = -1
+	f.moveFocus(1)
+}
+
+func (f *Form) isStatic(field *zfields.Field) bool {
+	return f.ReadOnly || f.Params.AllStatic || field.IsStatic()
+}
+
+func (f *Form) addRows(rval reflect.Value, indent int, path string) {
+	zfields.ForEachField(rval.Addr().Interface(), f.Params, nil, func(each zfields.FieldInfo) bool {
+		field := each.Field
+		v := each.ReflectValue
+		if field.HasFlag(zfields.FlagIsButton) {
+			return true
+		}
+		r := &row{field: field, rval: v, indent: indent, path: path + field.FieldName}
+		if !field.HasFlag(zfields.FlagNoTitle) {
+			r.label = field.TitleOrName()
+		}
+		t := v.Type()
+		if t.Kind() == reflect.Pointer && isStruct(t.Elem()) {
+			if v.IsNil() {
+				return true
+			}
+			v = v.Elem()
+			t = t.Elem()
+		}
+		switch {
+		case isStruct(t):
+			r.kind = rowHeader
+			f.rows = append(f.rows, r)
+			f.addRows(v, indent+1, r.path+".")
+		case t.Kind() == reflect.Slice && isStruct(elemStructType(t)):
+			f.addTable(r)
+		case t.Kind() == reflect.Map || t.Kind() == reflect.Func || t.Kind() == reflect.Chan || t.Kind() == reflect.Interface:
+			return true
+		case f.isStatic(field):
+			r.kind = rowStatic
+			r.text = []rune(staticText(field, v))
+			f.rows = append(f.rows, r)
+		case t.Kind() == reflect.Bool:
+			r.kind = rowCheckbox
+			r.checked = v.Bool()
+			f.rows = append(f.rows, r)
+		case field.Enum != "" && t.Kind() != reflect.Slice:
+			r.kind = rowEnum
+			r.enum = zfields.GetEnum(field.Enum)
+			r.enumIndex = -1
+			for i, item := range r.enum {
+				if reflect.DeepEqual(item.Value, v.Interface()) || fmt.Sprint(item.Value) == fmt.Sprint(v.Interface()) {
+					r.enumIndex = i
+					break
+				}
+			}
+			f.rows = append(f.rows, r)
+		default:
+			r.kind = rowText
+			r.text = []rune(valueText(field, v))
+			r.cursor = len(r.text)
+			f.rows = append(f.rows, r)
+		}
+		return true
+	})
+}
+
+func isStruct(t reflect.Type) bool {
+	return t.Kind() == reflect.Struct && t != reflect.TypeOf(time.Time{})
+}
+
+// elemStructType is the type of the elements of slice type t, or what they point to.
+func elemStructType(t reflect.Type) reflect.Type {
+	e := t.Elem()
+	if e.Kind() == reflect.Pointer {
+		return e.Elem()
+	}
+	return e
+}
+
+// addTable adds a header, column titles and a row for each element of the slice of structs in r.
+func (f *Form) addTable(r *row) {
+	r.kind = rowHeader
+	r.label = fmt.Sprintf("%s (%d)", r.label, r.rval.Len())
+	f.rows = append(f.rows, r)
+	tab := &table{}
+	params := f.Params
+	params.UseInValues = append(append([]string{}, params.UseInValues...), zfields.RowUseInSpecialName)
+	elem := reflect.New(elemStructType(r.rval.Type()))
+	zfields.ForEachField(elem.Interface(), params, nil, func(each zfields.FieldInfo) bool {
+		t := each.ReflectValue.Type()
+		if isStruct(t) || t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.String || each.Field.HasFlag(zfields.FlagIsButton) {
+			return true
+		}
+		tab.columns = append(tab.columns, each.Field)
+		tab.widths = append(tab.widths, min(maxColumnWidth, utf8.RuneCountInString(each.Field.TitleOrName())))
+		return true
+	})
+	f.rows = append(f.rows, &row{kind: rowTableHeader, table: tab, indent: r.indent + 1})
+	for i := 0; i < r.rval.Len(); i++ {
+		tr := &row{kind: rowTableRow, table: tab, indent: r.indent + 1, index: i, field: r.field}
+		tr.rval = r.rval.Index(i)
+		tr.path = fmt.Sprintf("%s[%d]", r.path, i)
+		tr.label = fmt.Sprintf("%s %d", r.field.TitleOrName(), i+1)
+		for c, cell := range f.tableCells(tr) {
+			tab.widths[c] = max(tab.widths[c], min(maxColumnWidth, utf8.RuneCountInString(cell)))
+		}
+		f.rows = append(f.rows, tr)
+	}
+}
+
+// element returns the struct of a table row, the edited one if it was edited in its own form.
+func (r *row) element() reflect.Value {
+	if r.pending.IsValid() {
+		return r.pending
+	}
+	if r.rval.Kind() == reflect.Pointer {
+		return r.rval.Elem()
+	}
+	return r.rval
+}
+
+func (f *Form) tableCells(r *row) []string {
+	cells := make([]string, len(r.table.columns))
+	e := r.element()
+	if !e.IsValid() {
+		return cells
+	}
+	params := f.Params
+	params.UseInValues = append(append([]string{}, params.UseInValues...), zfields.RowUseInSpecialName)
+	zfields.ForEachField(e.Addr().Interface(), params, nil, func(each zfields.FieldInfo) bool {
+		for c, col := range r.table.columns {
+			if col.FieldName == each.Field.FieldName {
+				cells[c] = staticText(each.Field, each.ReflectValue)
+			}
+		}
+		return true
+	})
+	return cells
+}
+
+// valueText is the text of rval for editing.
+func valueText(field *zfields.Field, rval reflect.Value) string {
+	if rval.Kind() == reflect.Pointer {
+		if rval.IsNil() {
+			return ""
+		}
+		rval = rval.Elem()
+	}
+	if field.HasFlag(zfields.FlagAllowEmptyAsZero) && rval.IsZero() {
+		return ""
+	}
+	switch v := rval.Interface().(type) {
+	case time.Duration:
+		return v.String()
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	}
+	if rval.Kind() == reflect.Slice {
+		var parts []string
+		for i := 0; i < rval.Len(); i++ {
+			parts = append(parts, valueText(field, rval.Index(i)))
+		}
+		return strings.Join(parts, listSeparator(field)+" ")
+	}
+	if field.Enum != "" {
+		if item := zfields.GetEnum(field.Enum).FindValue(rval.Interface()); item != nil {
+			return item.Name
+		}
+	}
+	return fmt.Sprint(rval.Interface())
+}
+
+// staticText is the text of rval when it is read-only, with zerotext, prefix and suffix tags used.
+func staticText(field *zfields.Field, rval reflect.Value) string {
+	if field.HasFlag(zfields.FlagIsPassword) {
+		return "••••••"
+	}
+	if rval.Kind() == reflect.Bool {
+		if rval.Bool() {
+			return "yes"
+		}
+		return "no"
+	}
+	if rval.IsZero() && field.ZeroText != "" {
+		return field.ZeroText
+	}
+	if t, is := rval.Interface().(time.Time); is && !t.IsZero() {
+		return t.Local().Format("2006-01-02 15:04:05")
+	}
+	str := valueText(field, rval)
+	if str == "" {
+		return ""
+	}
+	return field.Prefix + str + field.Suffix
+}
+
+func listSeparator(field *zfields.Field) string {
+	if field.StringSep != "" {
+		return field.StringSep
+	}
+	return ","
+}
+
+func (r *row) isFocusable() bool {
+	switch r.kind {
+	case rowText, rowCheckbox, rowEnum, rowTableRow:
+		return true
+	}
+	return false
+}
+
+// moveFocus moves the focus to the next focusable row in dir, if there is one.
+func (f *Form) moveFocus(dir int) {
+	for i := f.focus + dir; i >= 0 && i < len(f.rows); i += dir {
+		if f.rows[i].isFocusable() {
+			f.focus = i
+			return
+		}
+	}
+}
+
+// ToData writes the edited values to the struct, then validates it with zfields.Validate.
+// Values that can't be parsed, or aren't valid, are returned as zfields.ValidationErrors.
+func (f *Form) ToData() error {
+	var errs zfields.ValidationErrors
+	for _, r := range f.rows {
+		if !r.changed {
+			continue
+		}
+		err := f.setRowValue(r)
+		if err != nil {
+			errs = append(errs, zfields.FieldError{FieldPath: r.path, Title: r.field.TitleOrName(), Message: err.Error()})
+		}
+	}
+	if len(errs) != 0 {
+		return errs
+	}
+	err := zfields.ValidateWithParameters(f.structPtr, f.Params)
+	if err != nil {
+		return err
+	}
+	for _, r := range f.rows {
+		r.changed = false
+	}
+	return nil
+}
+
+func (f *Form) setRowValue(r *row) error {
+	rval := r.rval
+	switch r.kind {
+	case rowCheckbox:
+		rval.SetBool(r.checked)
+	case rowEnum:
+		if r.enumIndex == -1 {
+			rval.SetZero()
+			return nil
+		}
+		v := reflect.ValueOf(r.enum[r.enumIndex].Value)
+		if !v.Type().ConvertibleTo(rval.Type()) {
+			return fmt.Errorf("%v can't be a %v", v.Interface(), rval.Type())
+		}
+		rval.Set(v.Convert(rval.Type()))
+	case rowTableRow:
+		if rval.Kind() == reflect.Pointer {
+			rval = rval.Elem()
+		}
+		rval.Set(r.pending)
+	case rowText:
+		str := strings.TrimSpace(string(r.text))
+		if rval.Kind() == reflect.Pointer {
+			if str == "" {
+				rval.SetZero()
+				return nil
+			}
+			rval.Set(reflect.New(rval.Type().Elem()))
+			rval = rval.Elem()
+		}
+		if str == "" && (r.field.HasFlag(zfields.FlagAllowEmptyAsZero) || rval.Kind() == reflect.Slice) {
+			rval.SetZero()
+			return nil
+		}
+		if rval.Kind() == reflect.Slice && rval.Type().Elem().Kind() != reflect.Uint8 {
+			slice := reflect.MakeSlice(rval.Type(), 0, 0)
+			for _, part := range strings.Split(str, listSeparator(r.field)) {
+				e := reflect.New(rval.Type().Elem()).Elem()
+				err := zfields.SetValueFromString(r.field, e, strings.TrimSpace(part))
+				if err != nil {
+					return err
+				}
+				slice = reflect.Append(slice, e)
+			}
+			rval.Set(slice)
+			return nil
+		}
+		return zfields.SetValueFromString(r.field, rval, str)
+	}
+	return nil
+}
+
+// Run shows the form on t and handles keys until it is saved or canceled, returning true if saved.
+// Tab and the arrow keys move between fields, space toggles checkboxes,
+// left and right choose in enums, enter edits a table row, ctrl-s saves and escape cancels.
+func (f *Form) Run(t *Terminal) (saved bool, err error) {
+	f.build()
+	for {
+		t.updateSize()
+		f.render(t.Out, t.Width, t.Height)
+		k, err := t.readKey()
+		if err != nil {
+			return false, err
+		}
+		done, saved := f.handleKey(t, k)
+		if done {
+			return saved, nil
+		}
+	}
+}
+
+func (f *Form) setStatus(str string, isError bool) {
+	f.status = str
+	f.isError = isError
+}
+
+// handleKey handles k, returning done if the form should close, and saved if it was saved.
+func (f *Form) handleKey(t *Terminal, k key) (done, saved bool) {
+	f.setStatus("", false)
+	switch k.code {
+	case keyEscape, keyCancel:
+		return true, false
+	case keySave:
+		if f.ReadOnly {
+			return true, false
+		}
+		err := f.ToData()
+		if err != nil {
+			f.showError(err)
+			return false, false
+		}
+		return true, true
+	case keyUp, keyBackTab:
+		f.moveFocus(-1)
+		f.scrollBy(-1)
+		return false, false
+	case keyDown, keyTab:
+		f.moveFocus(1)
+		f.scrollBy(1)
+		return false, false
+	case keyPageUp, keyPageDown:
+		dir := 1
+		if k.code == keyPageUp {
+			dir = -1
+		}
+		for i := 0; i < max(1, t.Height-4); i++ {
+			f.moveFocus(dir)
+			f.scrollBy(dir)
+		}
+		return false, false
+	}
+	if f.focus == -1 {
+		return k.code == keyEnter, false
+	}
+	r := f.rows[f.focus]
+	switch r.kind {
+	case rowCheckbox:
+		if k.code == keyRune && k.r == ' ' || k.code == keyLeft || k.code == keyRight {
+			r.checked = !r.checked
+			r.changed = true
+		}
+	case rowEnum:
+		dir := 0
+		switch {
+		case k.code == keyLeft:
+			dir = -1
+		case k.code == keyRight, k.code == keyRune && k.r == ' ':
+			dir = 1
+		}
+		if dir != 0 && len(r.enum) != 0 {
+			r.enumIndex = (r.enumIndex + dir + len(r.enum)) % len(r.enum)
+			r.changed = true
+		}
+	case rowTableRow:
+		if k.code == keyEnter {
+			f.editTableRow(t, r)
+			return false, false
+		}
+	case rowText:
+		r.editText(k)
+	}
+	if k.code == keyEnter {
+		f.moveFocus(1)
+	}
+	return false, false
+}
+
+// scrollBy scrolls to reveal rows without focusable ones, when there are none to move the focus to.
+func (f *Form) scrollBy(dir int) {
+	if f.focus == -1 {
+		f.scroll = max(0, f.scroll+dir)
+	}
+}
+
+func (r *row) editText(k key) {
+	switch k.code {
+	case keyRune:
+		if k.r == 0 {
+			return
+		}
+		r.text = append(r.text[:r.cursor], append([]rune{k.r}, r.text[r.cursor:]...)...)
+		r.cursor++
+	case keyBackspace:
+		if r.cursor == 0 {
+			return
+		}
+		r.text = append(r.text[:r.cursor-1], r.text[r.cursor:]...)
+		r.cursor--
+	case keyDelete:
+		if r.cursor == len(r.text) {
+			return
+		}
+		r.text = append(r.text[:r.cursor], r.text[r.cursor+1:]...)
+	case keyLeft:
+		r.cursor = max(0, r.cursor-1)
+		return
+	case keyRight:
+		r.cursor = min(len(r.text), r.cursor+1)
+		return
+	case keyHome:
+		r.cursor = 0
+		return
+	case keyEnd:
+		r.cursor = len(r.text)
+		return
+	default:
+		return
+	}
+	r.changed = true
+}
+
+// editTableRow edits a copy of a table row's struct in a form of its own, which is set when this form is saved.
+func (f *Form) editTableRow(t *Terminal, r *row) {
+	e := r.element()
+	if !e.IsValid() {
+		return
+	}
+	edit := reflect.New(e.Type())
+	edit.Elem().Set(deepCopy(e))
+	sub := NewForm(edit.Interface(), f.Title+" › "+r.label)
+	sub.ReadOnly = f.ReadOnly || f.isStatic(r.field)
+	sub.Params = f.Params
+	saved, _ := sub.Run(t)
+	if saved {
+		r.pending = edit.Elem()
+		r.changed = true
+		for c, cell := range f.tableCells(r) {
+			r.table.widths[c] = max(r.table.widths[c], min(maxColumnWidth, utf8.RuneCountInString(cell)))
+		}
+	}
+}
+
+// showError shows err in the status line, and focuses the first field it is about.
+func (f *Form) showError(err error) {
+	f.setStatus(err.Error(), true)
+	verrs, _ := err.(zfields.ValidationErrors)
+	if len(verrs) == 0 {
+		return
+	}
+	f.setStatus(verrs[0].Error(), true)
+	for i, r := range f.rows {
+		if r.isFocusable() && (r.path == verrs[0].FieldPath || r.kind == rowTableRow && strings.HasPrefix(verrs[0].FieldPath, r.path+".")) {
+			f.focus = i
+			return
+		}
+	}
+}
+
+func (f *Form) labelWidth() int {
+	var w int
+	for _, r := range f.rows {
+		if r.kind != rowHeader && r.kind != rowTableHeader && r.kind != rowTableRow {
+			w = max(w, r.indent*2+utf8.RuneCountInString(r.label))
+		}
+	}
+	return min(w, maxLabelWidth)
+}
+
+// render draws the form in a terminal of width and height, with the focused row scrolled into view.
+func (f *Form) render(out io.Writer, width, height int) {
+	if width == 0 || height == 0 {
+		width, height = defaultWidth, defaultHeight
+	}
+	var b strings.Builder
+	b.WriteString("\x1b[?25l\x1b[H\x1b[2J")
+	b.WriteString(escBold + truncate(f.Title, width) + escReset + "\r\n")
+	bodyHeight := max(1, height-4)
+	if f.focus != -1 {
+		if f.focus < f.scroll {
+			f.scroll = f.focus
+		}
+		if f.focus >= f.scroll+bodyHeight {
+			f.scroll = f.focus - bodyHeight + 1
+		}
+	}
+	f.scroll = max(0, min(f.scroll, len(f.rows)-bodyHeight))
+	labelWidth := f.labelWidth()
+	cursorLine, cursorCol := -1, 0
+	for i := f.scroll; i < len(f.rows) && i < f.scroll+bodyHeight; i++ {
+		r := f.rows[i]
+		b.WriteString("\r\n")
+		line, col := f.renderRow(r, i == f.focus, labelWidth, width)
+		b.WriteString(line)
+		if i == f.focus && r.kind == rowText {
+			cursorLine = i - f.scroll + 3
+			cursorCol = col
+		}
+	}
+	for i := len(f.rows) - f.scroll; i < bodyHeight; i++ {
+		b.WriteString("\r\n")
+	}
+	b.WriteString("\r\n\r\n")
+	if f.status != "" {
+		col := escDim
+		if f.isError {
+			col = escRed
+		}
+		b.WriteString(col + truncate(f.status, width) + escReset)
+	} else {
+		b.WriteString(escDim + truncate(f.help(), width) + escReset)
+	}
+	if cursorLine != -1 {
+		fmt.Fprintf(&b, "\x1b[%d;%dH\x1b[?25h", cursorLine, cursorCol+1)
+	}
+	io.WriteString(out, b.String())
+}
+
+// help is the status line when there is no message; the focused field's description, or what the keys do.
+func (f *Form) help() string {
+	if f.focus != -1 {
+		field := f.rows[f.focus].field
+		if field.Description != "" {
+			return field.Description
+		}
+		if field.Tooltip != "" {
+			return field.Tooltip
+		}
+	}
+	if f.ReadOnly {
+		return "↑↓ move · enter/esc close"
+	}
+	return "↑↓ move · space toggle · ←→ choose · enter edit row · ^S save · esc cancel"
+}
+
+// renderRow returns the line for r, and the column the text cursor is at if it is a text input.
+func (f *Form) renderRow(r *row, focused bool, labelWidth, width int) (line string, cursorCol int) {
+	indent := strings.Repeat("  ", r.indent)
+	switch r.kind {
+	case rowHeader:
+		return indent + escBold + truncate(r.label, width-len(indent)) + escReset, 0
+	case rowTableHeader:
+		var cells []string
+		for c, col := range r.table.columns {
+			cells = append(cells, pad(truncate(col.TitleOrName(), r.table.widths[c]), r.table.widths[c]))
+		}
+		return indent + escUnderline + truncate(strings.Join(cells, "  "), width-len(indent)) + escReset, 0
+	case rowTableRow:
+		var cells []string
+		for c, cell := range f.tableCells(r) {
+			cells = append(cells, pad(truncate(cell, r.table.widths[c]), r.table.widths[c]))
+		}
+		str := truncate(strings.Join(cells, "  "), width-len(indent))
+		if focused {
+			return indent + escReverse + str + escReset, 0
+		}
+		return indent + str, 0
+	}
+	label := pad(truncate(indent+r.label, labelWidth), labelWidth)
+	if focused {
+		label = escReverse + label + escReset
+	}
+	col := labelWidth + 1
+	avail := max(1, width-col)
+	var value string
+	switch r.kind {
+	case rowStatic:
+		value = escDim + truncate(string(r.text), avail) + escReset
+	case rowCheckbox:
+		value = "[ ]"
+		if r.checked {
+			value = "[x]"
+		}
+	case rowEnum:
+		name := ""
+		if r.enumIndex != -1 {
+			name = r.enum[r.enumIndex].Name
+		}
+		if focused {
+			value = truncate("◂ "+name+" ▸", avail)
+		} else {
+			value = truncate(name, avail)
+		}
+	case rowText:
+		text := r.text
+		if r.field.HasFlag(zfields.FlagIsPassword) {
+			text = []rune(strings.Repeat("•", len(text)))
+		}
+		start := 0
+		if r.cursor >= avail {
+			start = r.cursor - avail + 1 // scroll long texts so the cursor is visible
+		}
+		end := min(len(text), start+avail)
+		value = string(text[start:end])
+		if focused {
+			value = escUnderline + pad(value, min(avail, max(20, len(text)+1))) + escReset
+		}
+		cursorCol = col + r.cursor - start
+	}
+	return label + " " + value, cursorCol
+}
+
+// truncate shortens str to at most width runes, ending it with an ellipsis if it was cut.
+// deepCopy returns a copy of rval, with what its exported pointers, slices and maps refer to copied too.
+// Interfaces and unexported fields are copied as they are.
+func deepCopy(rval reflect.Value) reflect.Value {
+	switch rval.Kind() {
+	case reflect.Pointer:
+		if rval.IsNil() {
+			return rval
+		}
+		n := reflect.New(rval.Type().Elem())
+		n.Elem().Set(deepCopy(rval.Elem()))
+		return n
+	case reflect.Slice:
+		if rval.IsNil() {
+			return rval
+		}
+		n := reflect.MakeSlice(rval.Type(), rval.Len(), rval.Len())
+		for i := 0; i < rval.Len(); i++ {
+			n.Index(i).Set(deepCopy(rval.Index(i)))
+		}
+		return n
+	case reflect.Map:
+		if rval.IsNil() {
+			return rval
+		}
+		n := reflect.MakeMapWithSize(rval.Type(), rval.Len())
+		iter := rval.MapRange()
+		for iter.Next() {
+			n.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return n
+	case reflect.Array, reflect.Struct:
+		n := reflect.New(rval.Type()).Elem()
+		n.Set(rval)
+		if rval.Kind() == reflect.Array {
+			for i := 0; i < rval.Len(); i++ {
+				n.Index(i).Set(deepCopy(rval.Index(i)))
+			}
+			return n
+		}
+		for i := 0; i < rval.NumField(); i++ {
+			if n.Field(i).CanSet() {
+				n.Field(i).Set(deepCopy(rval.Field(i)))
+			}
+		}
+		return n
+	}
+	return rval
+}
+
+func truncate(str string, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	runes := []rune(str)
+	if len(runes) <= width {
+		return str
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+func pad(str string, width int) string {
+	n := utf8.RuneCountInString(str)
+	if n >= width {
+		return str
+	}
+	return str + strings.Repeat(" ", width-n)
+}
@@ -0,0 +1,171 @@
+//go:build !js
+
+package ztui
+
+import (
+	"bufio"
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/torlangballe/zui/zfields"
+)
+
+type tuiItem struct {
+	Name  string
+	Count int
+}
+
+type tuiConfig struct {
+	ID    int    `zui:"static"`
+	Name  string `zui:"title:Full Name"`
+	On    bool
+	Mode  string `zui:"enum:tui.modes"`
+	Wait  time.Duration
+	Tags  []string
+	Limit *int
+	Items []tuiItem
+}
+
+func newTestForm(c *tuiConfig) *Form {
+	zfields.SetStringBasedEnum("tui.modes", "fast", "slow")
+	f := NewForm(c, "Config")
+	f.build()
+	return f
+}
+
+func findRow(t *testing.T, f *Form, path string) *row {
+	for _, r := range f.rows {
+		if r.path == path {
+			return r
+		}
+	}
+	t.Fatal("no row for", path)
+	return nil
+}
+
+func TestSetRowValue(t *testing.T) {
+	c := tuiConfig{Mode: "fast", Limit: new(int)}
+	f := newTestForm(&c)
+
+	r := findRow(t, f, "On")
+	r.checked = true
+	if err := f.setRowValue(r); err != nil || !c.On {
+		t.Error("checkbox:", c.On, err)
+	}
+	r = findRow(t, f, "Mode")
+	r.enumIndex = 1
+	if err := f.setRowValue(r); err != nil || c.Mode != "slow" {
+		t.Error("enum:", c.Mode, err)
+	}
+	r.enumIndex = -1
+	if err := f.setRowValue(r); err != nil || c.Mode != "" {
+		t.Error("no enum:", c.Mode, err)
+	}
+	r = findRow(t, f, "Tags")
+	r.text = []rune(" a, b ")
+	if err := f.setRowValue(r); err != nil || !reflect.DeepEqual(c.Tags, []string{"a", "b"}) {
+		t.Error("slice:", c.Tags, err)
+	}
+	r.text = nil
+	if err := f.setRowValue(r); err != nil || c.Tags != nil {
+		t.Error("empty slice:", c.Tags, err)
+	}
+	r = findRow(t, f, "Limit")
+	r.text = []rune("7")
+	if err := f.setRowValue(r); err != nil || c.Limit == nil || *c.Limit != 7 {
+		t.Error("pointer:", c.Limit, err)
+	}
+	r.text = nil
+	if err := f.setRowValue(r); err != nil || c.Limit != nil {
+		t.Error("empty pointer:", c.Limit, err)
+	}
+	r = findRow(t, f, "Wait")
+	r.text = []rune("soon")
+	if err := f.setRowValue(r); err == nil {
+		t.Error("bad duration should fail")
+	}
+}
+
+func TestToData(t *testing.T) {
+	c := tuiConfig{ID: 3, Name: "bob", Wait: time.Second, Items: []tuiItem{{"x", 1}, {"y", 2}}}
+	f := newTestForm(&c)
+
+	r := findRow(t, f, "Name")
+	r.text = []rune("al")
+	r.changed = true
+	r = findRow(t, f, "Wait")
+	r.text = []rune("2m")
+	r.changed = true
+	r = findRow(t, f, "Items[1]")
+	r.pending = reflect.ValueOf(tuiItem{"z", 5})
+	r.changed = true
+	if err := f.ToData(); err != nil {
+		t.Fatal(err)
+	}
+	want := tuiConfig{ID: 3, Name: "al", Wait: 2 * time.Minute, Items: []tuiItem{{"x", 1}, {"z", 5}}}
+	if !reflect.DeepEqual(c, want) {
+		t.Errorf("got %+v, want %+v", c, want)
+	}
+	for _, r := range f.rows {
+		if r.changed {
+			t.Error("row still changed after ToData:", r.path)
+		}
+	}
+
+	r = findRow(t, f, "Wait")
+	r.text = []rune("soon")
+	r.changed = true
+	err := f.ToData()
+	verrs, _ := err.(zfields.ValidationErrors)
+	if len(verrs) != 1 || verrs[0].FieldPath != "Wait" {
+		t.Fatal("expected error for Wait:", err)
+	}
+	if c.Wait != 2*time.Minute {
+		t.Error("bad value changed Wait:", c.Wait)
+	}
+}
+
+func TestDeepCopy(t *testing.T) {
+	c := tuiConfig{Tags: []string{"a"}, Limit: new(int), Items: []tuiItem{{"x", 1}}}
+	edit := deepCopy(reflect.ValueOf(c)).Interface().(tuiConfig)
+	edit.Tags[0] = "b"
+	*edit.Limit = 5
+	edit.Items[0].Name = "y"
+	if c.Tags[0] != "a" || *c.Limit != 0 || c.Items[0].Name != "x" {
+		t.Errorf("copy shares with original: %+v", c)
+	}
+}
+
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []key
+	}{
+		{"ab", []key{{code: keyRune, r: 'a'}, {code: keyRune, r: 'b'}}},
+		{"é\r", []key{{code: keyRune, r: 'é'}, {code: keyEnter}}},
+		{"\t\x7f\x13\x03", []key{{code: keyTab}, {code: keyBackspace}, {code: keySave}, {code: keyCancel}}},
+		{"\x1b[A\x1b[B\x1b[C\x1b[D", []key{{code: keyUp}, {code: keyDown}, {code: keyRight}, {code: keyLeft}}},
+		{"\x1bOH\x1b[4~\x1b[3~\x1b[Z", []key{{code: keyHome}, {code: keyEnd}, {code: keyDelete}, {code: keyBackTab}}},
+		{"\x1b[5~\x1b[6~\x1b[1;5C", []key{{code: keyPageUp}, {code: keyPageDown}, {code: keyRight}}},
+		{"\x1b[99~x", []key{{code: keyRune}, {code: keyRune, r: 'x'}}},
+		{"\x1b", []key{{code: keyEscape}}},
+	}
+	for _, test := range tests {
+		term := &Terminal{In: bufio.NewReader(bytes.NewReader([]byte(test.in)))}
+		for i, want := range test.want {
+			got, err := term.readKey()
+			if err != nil {
+				t.Fatalf("%q key %d: %v", test.in, i, err)
+			}
+			if got != want {
+				t.Errorf("%q key %d: got %+v, want %+v", test.in, i, got, want)
+			}
+		}
+		_, err := term.readKey()
+		if err == nil {
+			t.Errorf("%q: expected end of input", test.in)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+//go:build !js
+
+package ztui
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+type keyCode int
+
+const (
+	keyRune keyCode = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyHome
+	keyEnd
+	keyPageUp
+	keyPageDown
+	keyTab
+	keyBackTab
+	keyEnter
+	keyBackspace
+	keyDelete
+	keyEscape
+	keySave   // ctrl-s
+	keyCancel // ctrl-c
+)
+
+type key struct {
+	code keyCode
+	r    rune
+}
+
+// Terminal is where forms are drawn and keys read from, normally os.Stdin and os.Stdout in raw mode.
+type Terminal struct {
+	In     *bufio.Reader
+	Out    io.Writer
+	Width  int
+	Height int
+	fd     int
+	state  *term.State
+}
+
+// OpenTerminal puts the terminal of os.Stdin in raw mode, so keys are read as they are typed, and switches to its alternate screen.
+// Close restores it.
+func OpenTerminal() (*Terminal, error) {
+	t := &Terminal{In: bufio.NewReader(os.Stdin), Out: os.Stdout, fd: int(os.Stdin.Fd())}
+	state, err := term.MakeRaw(t.fd)
+	if err != nil {
+		return nil, err
+	}
+	t.state = state
+	t.updateSize()
+	io.WriteString(t.Out, "\x1b[?1049h")
+	return t, nil
+}
+
+func (t *Terminal) updateSize() {
+	if t.state == nil {
+		return
+	}
+	w, h, err := term.GetSize(t.fd)
+	if err == nil {
+		t.Width = w
+		t.Height = h
+	}
+}
+
+// Close leaves the alternate screen and restores the terminal's mode.
+func (t *Terminal) Close() {
+	io.WriteString(t.Out, "\x1b[?25h\x1b[?1049l")
+	if t.state != nil {
+		term.Restore(t.fd, t.state)
+	}
+}
+
+// readKey reads a key, decoding the escape sequences of arrow and other keys.
+// A lone escape is the escape key; sequences arrive in one read, so a escape with nothing buffered after it is alone.
+func (t *Terminal) readKey() (key, error) {
+	b, err := t.In.ReadByte()
+	if err != nil {
+		return key{}, err
+	}
+	switch b {
+	case '\r', '\n':
+		return key{code: keyEnter}, nil
+	case '\t':
+		return key{code: keyTab}, nil
+	case 0x7f, 0x08:
+		return key{code: keyBackspace}, nil
+	case 0x01:
+		return key{code: keyHome}, nil
+	case 0x05:
+		return key{code: keyEnd}, nil
+	case 0x13:
+		return key{code: keySave}, nil
+	case 0x03:
+		return key{code: keyCancel}, nil
+	case 0x1b:
+		if t.In.Buffered() == 0 {
+			return key{code: keyEscape}, nil
+		}
+		return t.readEscapeSequence()
+	}
+	if b < 0x20 {
+		return key{code: keyRune}, nil // other control keys are ignored as a zero rune
+	}
+	if b < utf8.RuneSelf {
+		return key{code: keyRune, r: rune(b)}, nil
+	}
+	t.In.UnreadByte()
+	r, _, err := t.In.ReadRune()
+	return key{code: keyRune, r: r}, err
+}
+
+func (t *Terminal) readEscapeSequence() (key, error) {
+	b, err := t.In.ReadByte()
+	if err != nil {
+		return key{}, err
+	}
+	if b != '[' && b != 'O' {
+		return key{code: keyEscape}, nil
+	}
+	var param []byte
+	for {
+		c, err := t.In.ReadByte()
+		if err != nil {
+			return key{}, err
+		}
+		if c >= '0' && c <= '9' || c == ';' {
+			param = append(param, c)
+			continue
+		}
+		switch c {
+		case 'A':
+			return key{code: keyUp}, nil
+		case 'B':
+			return key{code: keyDown}, nil
+		case 'C':
+			return key{code: keyRight}, nil
+		case 'D':
+			return key{code: keyLeft}, nil
+		case 'H':
+			return key{code: keyHome}, nil
+		case 'F':
+			return key{code: keyEnd}, nil
+		case 'Z':
+			return key{code: keyBackTab}, nil
+		case '~':
+			switch string(param) {
+			case "1", "7":
+				return key{code: keyHome}, nil
+			case "4", "8":
+				return key{code: keyEnd}, nil
+			case "3":
+				return key{code: keyDelete}, nil
+			case "5":
+				return key{code: keyPageUp}, nil
+			case "6":
+				return key{code: keyPageDown}, nil
+			}
+		}
+		return key{code: keyRune}, nil // unknown sequences are ignored
+	}
+}
@@ -253,10 +253,10 @@ func (v *HorBlocksView) createAndSetView(i int) {
 	}
 	// zlog.Info("createAndSetView:", i, len(v.queuedGetViews))
 	s := v.viewSize
-	style := view.Native().JSStyle()
-	style.Set("position", "relative")
-	style.Set("min-width", fmt.Sprintf("%fpx", s.W))
-	style.Set("min-height", fmt.Sprintf("%fpx", s.H))
+	nv := view.Native()
+	nv.SetJSStyle("position", "relative")
+	nv.SetJSStyle("min-width", fmt.Sprintf("%fpx", s.W))
+	nv.SetJSStyle("min-height", fmt.Sprintf("%fpx", s.H))
 	if view == nil {
 		return
 	}
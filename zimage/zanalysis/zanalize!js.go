@@ -7,6 +7,7 @@ import (
 	"image"
 	"slices"
 	"sort"
+	"sync"
 
 	"github.com/torlangballe/zutil/zgeo"
 	"github.com/torlangballe/zutil/zmath"
@@ -21,6 +22,9 @@ type SimpleAnalytics struct {
 	BlockFrequency zgeo.IRect
 	BlockAmount    float64
 	BlockBetter    float64
+	BandingAmount  float64     // BandingAmount is set if ImageInfo.MeasureBandingAndNoise, see BandingAmount()
+	NoiseLevel     float64     // NoiseLevel is set if ImageInfo.MeasureBandingAndNoise, see NoiseLevel()
+	ContentFrame   zgeo.IRect  // ContentFrame is the picture inside black bars if ImageInfo.DetectBars, or empty
 	DebugImage     image.Image `json:"-"`
 }
 
@@ -48,14 +52,26 @@ type ImageInfo struct {
 	DebugImageBackgroundOnly bool
 	DebugImage               *image.RGBA
 	DebugImageBlockFreq      zgeo.IRect
+	Workers                  int        // Workers is how many goroutines Analyze and AnalyzeSquares use. 0 or 1 is serial.
+	DetectBars               bool       // DetectBars makes Analyze and AnalyzeSquares find black letterbox/pillarbox bars first, and only analyze the picture inside them.
+	BarMaxLuma               float64    // BarMaxLuma is the brightest luma (0-255) of pixels in a black bar.
+	MeasureBandingAndNoise   bool       // MeasureBandingAndNoise makes Analyze measure gradient banding and noise level within LimitFrame too.
+	ContentFrame             zgeo.IRect // ContentFrame is the picture inside black bars found with DetectBars, or empty. LimitFrame is left as is.
+	frame                    zgeo.IRect // frame is what was last analyzed; LimitFrame, or with DetectBars the part of it inside bars
+	bandingAmount            float64
+	noiseLevel               float64
 }
 
-const blockMax = 32
+const (
+	blockMax    = 32
+	minBandRows = 16 // minBandRows is the fewest rows a band is given when analyzing concurrently
+)
 
 func (s *SimpleAnalytics) PrintInfo() {
 	fmt.Print("zanalize: blur:", s.BlurAmount)
 	fmt.Print(" flat:", s.FlatsAmount)
 	fmt.Print(" edges:", s.EdgesAmount)
+	fmt.Print(" banding:", s.BandingAmount, " noise:", s.NoiseLevel)
 	fmt.Println(" bfreq:", s.BlockFrequency.Size, "boff:", s.BlockFrequency.Pos, "bamount:", s.BlockAmount, "bbetter:", s.BlockBetter)
 }
 
@@ -64,6 +80,7 @@ func NewImageInfo() *ImageInfo {
 	info.BlurMinThreshold = 0.001
 	info.BlurMaxThreshold = 0.005
 	info.EdgeMinThreshold = 0.074 // 0.074 gave best results of 81 test image pairs
+	info.BarMaxLuma = 24
 	return info
 }
 
@@ -238,33 +255,99 @@ func getBlockFrequencyAndOffset(print bool, counts []count, frame zgeo.IRect) (f
 	return best.freq, best.offset, best.amount, better
 }
 
+// Analyze counts blur, flats and edges of img, within LimitFrame if set.
+// If Workers > 1 and there is no DebugImage, the rows are split in bands analyzed concurrently,
+// and their counts merged in order, so the result is the same as analyzing serially.
+// With DetectBars, only the part of it inside black bars is analyzed, and ContentFrame is set to the picture inside them.
+// If LimitFrame is all in the bars, nothing is analyzed, and SimpleAnalytics are zero.
 func (info *ImageInfo) Analyze(img image.Image) {
-	goRect := img.Bounds()
-	var oldRow []zgeo.Color = nil
 	info.Size = zgeo.RectFromGoRect(img.Bounds()).Size.ISize()
-
 	info.vCounts = make([]count, info.Size.H)
 	info.hCounts = make([]count, info.Size.W)
+	info.frame = info.LimitFrame
+	if info.DetectBars {
+		info.ContentFrame = info.FindContentFrame(img)
+		info.frame = intersectFrames(info.LimitFrame, info.ContentFrame)
+		if info.isFrameOutsideContent() {
+			info.bandingAmount = 0
+			info.noiseLevel = 0
+			return
+		}
+	}
+	if info.MeasureBandingAndNoise {
+		info.bandingAmount = BandingAmount(img, info.frame)
+		info.noiseLevel = NoiseLevel(img, info.frame)
+	}
 
-	blue := zgeo.ColorBlue.GoColor()
-	magenta := zgeo.ColorMagenta.GoColor()
-	yellow := zgeo.ColorYellow.GoColor()
-	orange := zgeo.ColorOrange.GoColor()
-	darkYellow := zgeo.ColorYellow.Mixed(zgeo.ColorBlack, 0.5).GoColor()
-	row := make([]zgeo.Color, int(goRect.Max.X))
+	goRect := img.Bounds()
 	sx := 0
 	sy := 0
 	ex := goRect.Max.X
 	ey := goRect.Max.Y
-	if info.LimitFrame.Size.W != 0 {
-		sx = info.LimitFrame.Pos.X
-		sy = info.LimitFrame.Pos.Y
-		ex = sx + info.LimitFrame.Size.W
-		ey = sy + info.LimitFrame.Size.H
+	if info.frame.Size.W != 0 {
+		sx = info.frame.Pos.X
+		sy = info.frame.Pos.Y
+		ex = sx + info.frame.Size.W
+		ey = sy + info.frame.Size.H
 	}
 	// if !info.DebugImageBackgroundOnly {
 	// zlog.Info("Analyse:", sx, sy, ex, ey")
 	// }
+	bands := min(info.Workers, (ey-sy)/minBandRows)
+	if bands <= 1 || info.DebugImage != nil {
+		info.analyzeRows(img, sx, ex, sy, ey, sy, info.hCounts)
+		return
+	}
+	// Each band counts its columns in its own hCounts, as they run down all rows. vCounts are per row, so are shared.
+	bandHCounts := make([][]count, bands)
+	var wg sync.WaitGroup
+	for b := range bands {
+		bandHCounts[b] = make([]count, info.Size.W)
+		y0 := sy + (ey-sy)*b/bands
+		y1 := sy + (ey-sy)*(b+1)/bands
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info.analyzeRows(img, sx, ex, y0, y1, sy, bandHCounts[b])
+		}()
+	}
+	wg.Wait()
+	for _, hCounts := range bandHCounts {
+		for x := range info.hCounts {
+			info.hCounts[x].merge(hCounts[x])
+		}
+	}
+}
+
+// merge adds the counts of a band below c's, joining edges that run across the border between them.
+func (c *count) merge(below count) {
+	c.blur += below.blur
+	c.flats += below.flats
+	c.edgePoints += below.edgePoints
+	lengths := below.perpLengths
+	if len(c.perpLengths) != 0 && len(lengths) != 0 && c.perpLengths[len(c.perpLengths)-1].Max == lengths[0].Min {
+		c.perpLengths[len(c.perpLengths)-1].Max = lengths[0].Max
+		lengths = lengths[1:]
+	}
+	c.perpLengths = append(c.perpLengths, lengths...)
+}
+
+// analyzeRows analyzes rows sy to ey, comparing the first with the row above if it isn't frameY, the top of the frame.
+func (info *ImageInfo) analyzeRows(img image.Image, sx, ex, sy, ey, frameY int, hCounts []count) {
+	var oldRow []zgeo.Color = nil
+
+	blue := zgeo.ColorBlue.GoColor()
+	magenta := zgeo.ColorMagenta.GoColor()
+	yellow := zgeo.ColorYellow.GoColor()
+	orange := zgeo.ColorOrange.GoColor()
+	darkYellow := zgeo.ColorYellow.Mixed(zgeo.ColorBlack, 0.5).GoColor()
+	row := make([]zgeo.Color, int(img.Bounds().Max.X))
+	if sy > frameY {
+		oldRow = make([]zgeo.Color, len(row))
+		for x := sx; x < ex; x++ {
+			oldRow[x] = zgeo.ColorFromGo(img.At(x, sy-1))
+		}
+	}
 	for y := sy; y < ey; y++ {
 		// zlog.Info("Y:", y)
 		clear(row)
@@ -272,9 +355,8 @@ func (info *ImageInfo) Analyze(img image.Image) {
 		for x := sx; x < ex; x++ {
 			goCol := img.At(x, y)
 			col := zgeo.ColorFromGo(goCol)
-			dark := col.Mixed(zgeo.ColorBlack, 0.6)
-			dotCol := dark.GoColor()
 			if info.DebugImage != nil {
+				dotCol := col.Mixed(zgeo.ColorBlack, 0.6).GoColor()
 				if isXYOnBlockFrequency(x, y, info.DebugImageBlockFreq) {
 					dotCol = darkYellow
 				}
@@ -283,8 +365,8 @@ func (info *ImageInfo) Analyze(img image.Image) {
 			row[x] = col
 			if oldCol.Valid {
 				hdiff := float64(col.Difference(oldCol))
-				info.setDiff(x, hdiff, info.hCounts)
-				v1 := info.setVertEdge(y, hdiff, &info.hCounts[x], info.Size.H)
+				info.setDiff(x, hdiff, hCounts)
+				v1 := info.setVertEdge(y, hdiff, &hCounts[x], info.Size.H)
 				if v1 != 0 && info.DebugImage != nil {
 					col := blue
 					if isXOnBlockFrequency(x, info.DebugImageBlockFreq) {
@@ -323,7 +405,6 @@ func (info *ImageInfo) Analyze(img image.Image) {
 		}
 		oldRow = slices.Clone(row)
 	}
-
 }
 
 func (info *ImageInfo) AnalyzeSquares(img image.Image, squareSize int, minArea, minBlockAmount, minBlockBetter float64) (debugImage image.Image, areaCoverage, amount, better float64, freq zgeo.IRect, blocky bool) {
@@ -333,45 +414,50 @@ func (info *ImageInfo) AnalyzeSquares(img image.Image, squareSize int, minArea,
 		better float64
 	}
 	s := zgeo.RectFromGoRect(img.Bounds()).Size.ISize()
-	sx := s.W % int(squareSize) / 2
-	sy := s.H % int(squareSize) / 2
-	sx = zmath.RoundToMod(sx, blockMax)
-	sy = zmath.RoundToMod(sy, blockMax)
+	content := zgeo.IRectForXYWH(0, 0, s.W, s.H)
+	if info.DetectBars {
+		info.ContentFrame = info.FindContentFrame(img)
+		content = info.ContentFrame
+	}
+	sx := squareStart(content.Pos.X, content.Size.W, squareSize)
+	sy := squareStart(content.Pos.Y, content.Size.H, squareSize)
 	dbImage := image.NewRGBA(img.Bounds())
 	var squareTotal, squareCount float64
 	var amountAdd, betterAdd float64
 	var freqs []zgeo.IRect
-	yc := 0
-	for y := sy; y <= s.H-squareSize; y += squareSize {
-		// zlog.Info("Y:", yc)
-		xc := 0
-		for x := sx; x <= s.W-squareSize; x += squareSize {
-			squareTotal++
-			info.LimitFrame = zgeo.RectFromXYWH(float64(x), float64(y), float64(squareSize), float64(squareSize)).IRect()
-			info.DebugImage = nil
-			// now := time.Now()
-			info.Analyze(img)
-			// next := time.Since(now)
-			a := info.SimpleAnalytics(false)
-			// zlog.Info("Time:", next, time.Since(now))
-			info.DebugImage = dbImage
-			if a.BlockFrequency.Size.W != 0 && a.BlockBetter > minBlockBetter && a.BlockAmount > minBlockAmount {
-				// zlog.Info("A:", xc, yc, a.BlockAmount, a.BlockBetter, a.BlockFrequency)
-				info.DebugImageBackgroundOnly = false
-				info.DebugImageBlockFreq = a.BlockFrequency
-				amountAdd += a.BlockAmount
-				betterAdd += a.BlockBetter
-				freqs = append(freqs, a.BlockFrequency)
-				squareCount++
-			} else {
-				// zlog.Info("B: [", xc, yc, "]", a.BlockAmount, a.BlockBetter, a.BlockFrequency)
-				info.DebugImageBlockFreq = zgeo.IRect{}
-				info.DebugImageBackgroundOnly = true
-			}
-			info.Analyze(img)
-			xc++
+	var frames []zgeo.IRect
+	detectBars := info.DetectBars
+	limitFrame := info.LimitFrame
+	info.DetectBars = false // squares are analyzed with their own LimitFrame, inside the content
+	defer func() {
+		info.DetectBars = detectBars
+		info.LimitFrame = limitFrame
+	}()
+	for y := sy; y <= content.Pos.Y+content.Size.H-squareSize; y += squareSize {
+		for x := sx; x <= content.Pos.X+content.Size.W-squareSize; x += squareSize {
+			frames = append(frames, zgeo.RectFromXYWH(float64(x), float64(y), float64(squareSize), float64(squareSize)).IRect())
+		}
+	}
+	analytics := info.analyzeFrames(img, frames)
+	for i, frame := range frames {
+		squareTotal++
+		info.LimitFrame = frame
+		a := analytics[i]
+		info.DebugImage = dbImage
+		if a.BlockFrequency.Size.W != 0 && a.BlockBetter > minBlockBetter && a.BlockAmount > minBlockAmount {
+			// zlog.Info("A:", frame, a.BlockAmount, a.BlockBetter, a.BlockFrequency)
+			info.DebugImageBackgroundOnly = false
+			info.DebugImageBlockFreq = a.BlockFrequency
+			amountAdd += a.BlockAmount
+			betterAdd += a.BlockBetter
+			freqs = append(freqs, a.BlockFrequency)
+			squareCount++
+		} else {
+			// zlog.Info("B:", frame, a.BlockAmount, a.BlockBetter, a.BlockFrequency)
+			info.DebugImageBlockFreq = zgeo.IRect{}
+			info.DebugImageBackgroundOnly = true
 		}
-		yc++
+		info.Analyze(img)
 	}
 	sorted, _ := zslices.SortByFrequency(freqs)
 	if len(sorted) != 0 {
@@ -385,6 +471,59 @@ func (info *ImageInfo) AnalyzeSquares(img image.Image, squareSize int, minArea,
 	return dbImage, area, amount, better, freq, blocky
 }
 
+// squareStart is where squares of squareSize start in a picture at pos with length, centered and on a block boundary inside it.
+func squareStart(pos, length, squareSize int) int {
+	start := zmath.RoundToMod(pos+length%squareSize/2, blockMax)
+	if start < pos {
+		start += blockMax
+	}
+	return start
+}
+
+// isFrameOutsideContent returns true if DetectBars left nothing to analyze, as LimitFrame is all in the bars.
+// An empty frame is otherwise the whole image, so Analyze and SimpleAnalytics check this first.
+func (info *ImageInfo) isFrameOutsideContent() bool {
+	return info.DetectBars && (info.frame.Size.W <= 0 || info.frame.Size.H <= 0)
+}
+
+// intersectFrames returns the part of frame inside content, or content if frame is empty.
+func intersectFrames(frame, content zgeo.IRect) zgeo.IRect {
+	if frame.Size.W == 0 {
+		return content
+	}
+	r := image.Rect(frame.Pos.X, frame.Pos.Y, frame.Pos.X+frame.Size.W, frame.Pos.Y+frame.Size.H)
+	r = r.Intersect(image.Rect(content.Pos.X, content.Pos.Y, content.Pos.X+content.Size.W, content.Pos.Y+content.Size.H))
+	return zgeo.IRectForXYWH(r.Min.X, r.Min.Y, r.Dx(), r.Dy())
+}
+
+// analyzeFrames gets SimpleAnalytics for each of frames in img, using up to info.Workers goroutines.
+func (info *ImageInfo) analyzeFrames(img image.Image, frames []zgeo.IRect) []SimpleAnalytics {
+	analytics := make([]SimpleAnalytics, len(frames))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range max(1, min(info.Workers, len(frames))) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fi := *info
+			fi.DebugImage = nil
+			fi.Workers = 1
+			fi.DetectBars = false
+			for i := range next {
+				fi.LimitFrame = frames[i]
+				fi.Analyze(img)
+				analytics[i] = fi.SimpleAnalytics(false)
+			}
+		}()
+	}
+	for i := range frames {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return analytics
+}
+
 func (info *ImageInfo) BlurAmount() zgeo.Pos {
 	var w, h int
 	sum := info.Size.W * info.Size.H
@@ -452,7 +591,10 @@ func (info *ImageInfo) BlockFrequency(print bool, frame zgeo.IRect) (freq zgeo.I
 }
 
 func (info *ImageInfo) SimpleAnalytics(print bool) SimpleAnalytics {
-	freq, amount, better := info.BlockFrequency(print, info.LimitFrame)
+	if info.isFrameOutsideContent() {
+		return SimpleAnalytics{Size: info.Size, ContentFrame: info.ContentFrame}
+	}
+	freq, amount, better := info.BlockFrequency(print, info.frame)
 	// zlog.Info("Simple:", info.LimitFrame, area)
 	return SimpleAnalytics{
 		Size:           info.Size,
@@ -462,5 +604,8 @@ func (info *ImageInfo) SimpleAnalytics(print bool) SimpleAnalytics {
 		BlockFrequency: freq,
 		BlockAmount:    amount.Average(),
 		BlockBetter:    better.Average(),
+		BandingAmount:  info.bandingAmount,
+		NoiseLevel:     info.noiseLevel,
+		ContentFrame:   info.ContentFrame,
 	}
 }
@@ -0,0 +1,187 @@
+package zanalysis
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/torlangballe/zutil/zgeo"
+)
+
+// makeTestFrame makes a frame with a gradient, 8x8 blocks and noise, so it has blur, flats and edges crossing band borders.
+func makeTestFrame(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	r := rand.New(rand.NewSource(1))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := uint8(x * 255 / w)
+			if (x/8+y/8)%5 == 0 {
+				v = 200
+			}
+			if r.Intn(10) == 0 {
+				v += uint8(r.Intn(40))
+			}
+			img.Set(x, y, color.RGBA{v, uint8(y * 255 / h), v / 2, 255})
+		}
+	}
+	return img
+}
+
+func TestAnalyzeConcurrentSameAsSerial(t *testing.T) {
+	img := makeTestFrame(320, 241)
+	for _, limit := range []zgeo.IRect{{}, zgeo.IRectForXYWH(32, 17, 200, 150)} {
+		serial := NewImageInfo()
+		serial.LimitFrame = limit
+		serial.Analyze(img)
+		for _, workers := range []int{2, 3, 8} {
+			concurrent := NewImageInfo()
+			concurrent.LimitFrame = limit
+			concurrent.Workers = workers
+			concurrent.Analyze(img)
+			if !reflect.DeepEqual(serial.hCounts, concurrent.hCounts) || !reflect.DeepEqual(serial.vCounts, concurrent.vCounts) {
+				t.Error("counts differ with workers:", workers, limit)
+			}
+			if fmt.Sprintf("%+v", serial.SimpleAnalytics(false)) != fmt.Sprintf("%+v", concurrent.SimpleAnalytics(false)) { // Sprintf so NaN equals NaN
+				t.Error("analytics differ with workers:", workers, limit)
+			}
+		}
+	}
+}
+
+func TestAnalyzeSquaresConcurrentSameAsSerial(t *testing.T) {
+	img := makeTestFrame(256, 256)
+	serial := NewImageInfo()
+	sDebug, sArea, sAmount, sBetter, sFreq, sBlocky := serial.AnalyzeSquares(img, 64, 0.1, 0.1, 1.1)
+	concurrent := NewImageInfo()
+	concurrent.Workers = 4
+	cDebug, cArea, cAmount, cBetter, cFreq, cBlocky := concurrent.AnalyzeSquares(img, 64, 0.1, 0.1, 1.1)
+	s := fmt.Sprint(sArea, sAmount, sBetter, sFreq, sBlocky)
+	c := fmt.Sprint(cArea, cAmount, cBetter, cFreq, cBlocky)
+	if s != c {
+		t.Error("squares differ:", s, c)
+	}
+	if !reflect.DeepEqual(sDebug, cDebug) {
+		t.Error("debug images differ")
+	}
+}
+
+// makeBarredFrame makes a test frame with black bars barW wide at the sides and barH high at the top and bottom.
+func makeBarredFrame(w, h, barW, barH int) *image.RGBA {
+	img := makeTestFrame(w, h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if x < barW || x >= w-barW || y < barH || y >= h-barH {
+				img.Set(x, y, color.RGBA{8, 8, 8, 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestFindContentFrame(t *testing.T) {
+	black := image.NewRGBA(image.Rect(0, 0, 320, 240))
+	tests := []struct {
+		name string
+		img  image.Image
+		want zgeo.IRect
+	}{
+		{"letter and pillarbox", makeBarredFrame(320, 240, 40, 30), zgeo.IRectForXYWH(40, 30, 240, 180)},
+		{"letterbox", makeBarredFrame(320, 240, 0, 30), zgeo.IRectForXYWH(0, 30, 320, 180)},
+		{"pillarbox", makeBarredFrame(320, 240, 40, 0), zgeo.IRectForXYWH(40, 0, 240, 240)},
+		{"too thin", makeBarredFrame(320, 240, 2, 2), zgeo.IRectForXYWH(0, 0, 320, 240)},
+		{"none", makeTestFrame(320, 240), zgeo.IRectForXYWH(0, 0, 320, 240)},
+		{"all black", black, zgeo.IRectForXYWH(0, 0, 320, 240)},
+	}
+	for _, test := range tests {
+		got := NewImageInfo().FindContentFrame(test.img)
+		if got != test.want {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+}
+
+func TestAnalyzeDetectBars(t *testing.T) {
+	img := makeBarredFrame(320, 240, 40, 30)
+	content := zgeo.IRectForXYWH(40, 30, 240, 180)
+	for _, limit := range []zgeo.IRect{{}, zgeo.IRectForXYWH(0, 0, 160, 240)} {
+		detect := NewImageInfo()
+		detect.DetectBars = true
+		detect.MeasureBandingAndNoise = true
+		detect.LimitFrame = limit
+		detect.Analyze(img)
+		if detect.LimitFrame != limit || detect.ContentFrame != content {
+			t.Error("frames after Analyze:", detect.LimitFrame, detect.ContentFrame)
+		}
+		limited := NewImageInfo()
+		limited.MeasureBandingAndNoise = true
+		limited.LimitFrame = content
+		if limit.Size.W != 0 {
+			limited.LimitFrame = zgeo.IRectForXYWH(40, 30, 120, 180)
+		}
+		limited.Analyze(img)
+		da := detect.SimpleAnalytics(false)
+		da.ContentFrame = zgeo.IRect{}
+		if fmt.Sprintf("%+v", da) != fmt.Sprintf("%+v", limited.SimpleAnalytics(false)) {
+			t.Error("detected bars not analyzed as a LimitFrame inside them:", limit)
+		}
+	}
+}
+
+func TestAnalyzeDetectBarsLimitInBars(t *testing.T) {
+	img := makeBarredFrame(320, 240, 40, 30)
+	info := NewImageInfo()
+	info.DetectBars = true
+	info.MeasureBandingAndNoise = true
+	info.Analyze(img) // first the whole picture, so nothing is left over from it below
+	info.LimitFrame = zgeo.IRectForXYWH(0, 0, 30, 240)
+	info.Analyze(img)
+	want := SimpleAnalytics{Size: zgeo.ISize{W: 320, H: 240}, ContentFrame: zgeo.IRectForXYWH(40, 30, 240, 180)}
+	if got := info.SimpleAnalytics(false); fmt.Sprintf("%+v", got) != fmt.Sprintf("%+v", want) {
+		t.Errorf("limit in bars analyzed: %+v", got)
+	}
+	for _, c := range append(info.hCounts, info.vCounts...) {
+		if c.blur != 0 || c.flats != 0 || c.edgePoints != 0 {
+			t.Fatal("bars counted:", c)
+		}
+	}
+}
+
+func TestAnalyzeSquaresDetectBars(t *testing.T) {
+	img := makeBarredFrame(256, 256, 0, 64)
+	isDrawn := func(debug image.Image, x, y int) bool {
+		_, _, _, a := debug.At(x, y).RGBA()
+		return a != 0
+	}
+	info := NewImageInfo()
+	debug, _, _, _, _, _ := info.AnalyzeSquares(img, 64, 0.1, 0.1, 1.1)
+	if !isDrawn(debug, 128, 10) {
+		t.Error("squares not in bars without DetectBars")
+	}
+	info.DetectBars = true
+	debug, _, _, _, _, _ = info.AnalyzeSquares(img, 64, 0.1, 0.1, 1.1)
+	if isDrawn(debug, 128, 10) || isDrawn(debug, 128, 250) || !isDrawn(debug, 128, 128) {
+		t.Error("squares not only inside the bars")
+	}
+	if !info.DetectBars || info.LimitFrame != (zgeo.IRect{}) || info.ContentFrame != zgeo.IRectForXYWH(0, 64, 256, 128) {
+		t.Error("info changed by AnalyzeSquares:", info.DetectBars, info.LimitFrame, info.ContentFrame)
+	}
+}
+
+func benchmarkAnalyze(b *testing.B, w, h, workers int) {
+	img := makeTestFrame(w, h)
+	info := NewImageInfo()
+	info.Workers = workers
+	b.ResetTimer()
+	for range b.N {
+		info.Analyze(img)
+	}
+}
+
+func BenchmarkAnalyze1080pSerial(b *testing.B)   { benchmarkAnalyze(b, 1920, 1080, 1) }
+func BenchmarkAnalyze1080pWorkers4(b *testing.B) { benchmarkAnalyze(b, 1920, 1080, 4) }
+func BenchmarkAnalyze1080pWorkers8(b *testing.B) { benchmarkAnalyze(b, 1920, 1080, 8) }
+func BenchmarkAnalyze4KSerial(b *testing.B)      { benchmarkAnalyze(b, 3840, 2160, 1) }
+func BenchmarkAnalyze4KWorkers8(b *testing.B)    { benchmarkAnalyze(b, 3840, 2160, 8) }
@@ -0,0 +1,141 @@
+package zanalysis
+
+import (
+	"image"
+	"math"
+
+	"github.com/torlangballe/zutil/zgeo"
+)
+
+const (
+	barMaxBrightRatio = 0.01 // barMaxBrightRatio is how many pixels of a bar row/column can be brighter than BarMaxLuma, for noise and logos
+	barMinRatio       = 0.02 // barMinRatio is the least size of a bar relative to the frame, so dark edges of a picture aren't bars
+	bandingMinRun     = 4    // bandingMinRun is how many flat pixels must be on each side of a small step for it to be a band edge
+	bandingMaxStep    = 4.0  // bandingMaxStep is the biggest luma step that can be between two bands
+)
+
+// FindContentFrame returns the part of img inside black bars; letterbox bars at top and bottom and pillarbox bars at the sides.
+// A bar is rows or columns where nearly all pixels have luma at most BarMaxLuma, and at least 2% of the frame.
+// If img has no bars, or is all black, its whole frame is returned.
+func (info *ImageInfo) FindContentFrame(img image.Image) zgeo.IRect {
+	b := img.Bounds()
+	full := zgeo.IRectForXYWH(0, 0, b.Dx(), b.Dy())
+	p := newLumaPlane(img, b)
+	w, h := p.size.W, p.size.H
+	isBar := func(x0, y0, dx, dy, n int) bool {
+		var bright int
+		for i := 0; i < n; i++ {
+			if p.y[(y0+i*dy)*w+x0+i*dx] > info.BarMaxLuma {
+				bright++
+			}
+		}
+		return float64(bright) <= float64(n)*barMaxBrightRatio
+	}
+	top := 0
+	for top < h && isBar(0, top, 1, 0, w) {
+		top++
+	}
+	if top == h {
+		return full
+	}
+	bottom := h
+	for bottom > top && isBar(0, bottom-1, 1, 0, w) {
+		bottom--
+	}
+	left := 0
+	for left < w && isBar(left, top, 0, 1, bottom-top) {
+		left++
+	}
+	right := w
+	for right > left && isBar(right-1, top, 0, 1, bottom-top) {
+		right--
+	}
+	minH := int(math.Ceil(float64(h) * barMinRatio))
+	minW := int(math.Ceil(float64(w) * barMinRatio))
+	if top < minH {
+		top = 0
+	}
+	if h-bottom < minH {
+		bottom = h
+	}
+	if left < minW {
+		left = 0
+	}
+	if w-right < minW {
+		right = w
+	}
+	return zgeo.IRectForXYWH(left, top, right-left, bottom-top)
+}
+
+// NoiseLevel estimates the standard deviation of noise in img's luma (0-255) within frame,
+// with Immerkær's fast method, which filters out edges and gradients with a Laplacian difference kernel.
+func NoiseLevel(img image.Image, frame zgeo.IRect) float64 {
+	p := newLumaPlane(img, frameRectangle(img, frame))
+	w, h := p.size.W, p.size.H
+	if w < 3 || h < 3 {
+		return 0
+	}
+	var sum float64
+	for y := 1; y < h-1; y++ {
+		for x := 1; x < w-1; x++ {
+			i := y*w + x
+			v := p.y[i-w-1] - 2*p.y[i-w] + p.y[i-w+1] -
+				2*p.y[i-1] + 4*p.y[i] - 2*p.y[i+1] +
+				p.y[i+w-1] - 2*p.y[i+w] + p.y[i+w+1]
+			sum += math.Abs(v)
+		}
+	}
+	return sum * math.Sqrt(math.Pi/2) / (6 * float64(w-2) * float64(h-2))
+}
+
+// BandingAmount is how much of the smooth gradients in img within frame are banded, 0-1.
+// Of all small luma steps between neighboring pixels, it is the ratio that are between two flat runs,
+// which is how a gradient with too few levels looks; a smooth or dithered gradient has few flat runs.
+func BandingAmount(img image.Image, frame zgeo.IRect) float64 {
+	p := newLumaPlane(img, frameRectangle(img, frame))
+	w, h := p.size.W, p.size.H
+	var steps, bandEdges int
+	count := func(start, step, n int) {
+		flatBefore := 0
+		lastStep := -1 // lastStep is the index of the last small step, if it still has a flat run before it, or -1
+		for i := 1; i < n; i++ {
+			d := math.Abs(math.Round(p.y[start+i*step]) - math.Round(p.y[start+(i-1)*step]))
+			if d == 0 {
+				flatBefore++
+				if lastStep != -1 && i-lastStep >= bandingMinRun {
+					bandEdges++
+					lastStep = -1
+				}
+				continue
+			}
+			lastStep = -1
+			if d <= bandingMaxStep {
+				steps++
+				if flatBefore >= bandingMinRun {
+					lastStep = i
+				}
+			}
+			flatBefore = 0
+		}
+	}
+	for y := 0; y < h; y++ {
+		count(y*w, 1, w)
+	}
+	for x := 0; x < w; x++ {
+		count(x, w, h)
+	}
+	if steps == 0 {
+		return 0
+	}
+	return float64(bandEdges) / float64(steps)
+}
+
+// frameRectangle returns frame as a rectangle in img's bounds, or all of them if frame is empty.
+func frameRectangle(img image.Image, frame zgeo.IRect) image.Rectangle {
+	b := img.Bounds()
+	if frame.Size.W == 0 || frame.Size.H == 0 {
+		return b
+	}
+	r := image.Rect(frame.Pos.X, frame.Pos.Y, frame.Pos.X+frame.Size.W, frame.Pos.Y+frame.Size.H).Add(b.Min)
+	return r.Intersect(b)
+}
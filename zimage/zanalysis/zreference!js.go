@@ -0,0 +1,233 @@
+package zanalysis
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/torlangballe/zutil/zgeo"
+)
+
+// ReferenceAnalytics are full-reference metrics of a frame compared to its source, for instance a transcoded frame against the original.
+type ReferenceAnalytics struct {
+	Size       zgeo.ISize
+	MSE        float64 // MSE is the mean squared error of luma, 0-255
+	PSNR       float64 // PSNR is the peak signal-to-noise ratio in dB, IdenticalPSNR if the frames are the same
+	SSIM       float64 // SSIM is the mean structural similarity of luma over all windows, 1 is identical
+	MinSSIM    float64 // MinSSIM is the SSIM of the worst window, to catch local damage the mean hides
+	SSIMMap    *SSIMMap
+	DebugImage image.Image `json:"-"` // DebugImage is SSIMMap as an image, if CompareToReference was asked to make it
+}
+
+// SSIMMap is the SSIM of each window of a comparison, which overlap as Stride is less than Window.
+type SSIMMap struct {
+	Window int // Window is the width and height of each window, 8, or less if the image is smaller
+	Stride int
+	Cols   int
+	Rows   int
+	Values []float64 // Values are SSIM of each window, row by row
+}
+
+// IdenticalPSNR is the PSNR given for identical frames, where it would be infinite, which JSON can't encode.
+const IdenticalPSNR = 100.0
+
+const (
+	ssimWindow = 8
+	ssimStride = 4
+	ssimC1     = (0.01 * 255) * (0.01 * 255)
+	ssimC2     = (0.03 * 255) * (0.03 * 255)
+)
+
+var ErrSizeMismatch = errors.New("zanalysis: images differ in size")
+
+func (r *ReferenceAnalytics) PrintInfo() {
+	fmt.Print("zanalize: psnr:", r.PSNR)
+	fmt.Print(" mse:", r.MSE)
+	fmt.Println(" ssim:", r.SSIM, "minssim:", r.MinSSIM)
+}
+
+// lumaPlane is the BT.601 luma of an image, 0-255, row by row.
+type lumaPlane struct {
+	size zgeo.ISize
+	y    []float64
+}
+
+// newLumaPlane gets the luma of the part of img within b.
+func newLumaPlane(img image.Image, b image.Rectangle) lumaPlane {
+	p := lumaPlane{size: zgeo.ISize{W: b.Dx(), H: b.Dy()}}
+	p.y = make([]float64, p.size.W*p.size.H)
+	i := 0
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			p.y[i] = (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)) / 257
+			i++
+		}
+	}
+	return p
+}
+
+func lumaPlanes(ref, img image.Image) (lumaPlane, lumaPlane, error) {
+	if ref.Bounds().Size() != img.Bounds().Size() {
+		return lumaPlane{}, lumaPlane{}, ErrSizeMismatch
+	}
+	return newLumaPlane(ref, ref.Bounds()), newLumaPlane(img, img.Bounds()), nil
+}
+
+// PSNR returns the peak signal-to-noise ratio in dB and mean squared error of img's luma compared to ref's.
+func PSNR(ref, img image.Image) (psnr, mse float64, err error) {
+	rp, ip, err := lumaPlanes(ref, img)
+	if err != nil {
+		return 0, 0, err
+	}
+	psnr, mse = planePSNR(rp, ip)
+	return psnr, mse, nil
+}
+
+func planePSNR(rp, ip lumaPlane) (psnr, mse float64) {
+	if len(rp.y) == 0 {
+		return IdenticalPSNR, 0
+	}
+	var sum float64
+	for i, ry := range rp.y {
+		d := ry - ip.y[i]
+		sum += d * d
+	}
+	mse = sum / float64(len(rp.y))
+	if mse == 0 {
+		return IdenticalPSNR, 0
+	}
+	psnr = 10 * math.Log10(255*255/mse)
+	return min(psnr, IdenticalPSNR), mse
+}
+
+// SSIM returns the mean structural similarity of img's luma compared to ref's,
+// and a map of it for each 8x8 window, stepping 4 pixels.
+func SSIM(ref, img image.Image) (mean float64, m *SSIMMap, err error) {
+	rp, ip, err := lumaPlanes(ref, img)
+	if err != nil {
+		return 0, nil, err
+	}
+	m = planeSSIMMap(rp, ip)
+	return m.Mean(), m, nil
+}
+
+func planeSSIMMap(rp, ip lumaPlane) *SSIMMap {
+	m := &SSIMMap{Stride: ssimStride}
+	w, h := rp.size.W, rp.size.H
+	win := min(ssimWindow, w, h) // small images get one window of all of it
+	m.Window = win
+	if win == 0 {
+		return m
+	}
+	m.Cols = (w-win)/ssimStride + 1
+	m.Rows = (h-win)/ssimStride + 1
+	m.Values = make([]float64, 0, m.Cols*m.Rows)
+	n := float64(win * win)
+	for row := 0; row < m.Rows; row++ {
+		for col := 0; col < m.Cols; col++ {
+			var sr, si, srr, sii, sri float64
+			for y := row * ssimStride; y < row*ssimStride+win; y++ {
+				i := y*w + col*ssimStride
+				for x := 0; x < win; x++ {
+					r := rp.y[i+x]
+					v := ip.y[i+x]
+					sr += r
+					si += v
+					srr += r * r
+					sii += v * v
+					sri += r * v
+				}
+			}
+			mr := sr / n
+			mi := si / n
+			vr := srr/n - mr*mr
+			vi := sii/n - mi*mi
+			cov := sri/n - mr*mi
+			ssim := ((2*mr*mi + ssimC1) * (2*cov + ssimC2)) / ((mr*mr + mi*mi + ssimC1) * (vr + vi + ssimC2))
+			m.Values = append(m.Values, ssim)
+		}
+	}
+	return m
+}
+
+// Mean is the average SSIM of all windows, or 1 if there are none.
+func (m *SSIMMap) Mean() float64 {
+	if len(m.Values) == 0 {
+		return 1
+	}
+	var sum float64
+	for _, v := range m.Values {
+		sum += v
+	}
+	return sum / float64(len(m.Values))
+}
+
+// Min is the lowest SSIM of any window, or 1 if there are none.
+func (m *SSIMMap) Min() float64 {
+	low := 1.0
+	for _, v := range m.Values {
+		low = min(low, v)
+	}
+	return low
+}
+
+// At returns the SSIM of the window at col, row.
+func (m *SSIMMap) At(col, row int) float64 {
+	return m.Values[row*m.Cols+col]
+}
+
+// DebugImage makes an image of size with the stride-square at the top-left of each window colored
+// from green for SSIM 1, through yellow, to red for SSIM 0.5 or less.
+// The squares of the last column and row go to the edges, so the squares cover the whole image.
+func (m *SSIMMap) DebugImage(size zgeo.ISize) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size.W, size.H))
+	for row := 0; row < m.Rows; row++ {
+		for col := 0; col < m.Cols; col++ {
+			amount := max(0, min(1, (1-m.At(col, row))*2))
+			var c zgeo.Color
+			if amount < 0.5 {
+				c = zgeo.ColorGreen.Mixed(zgeo.ColorYellow, float32(amount*2))
+			} else {
+				c = zgeo.ColorYellow.Mixed(zgeo.ColorRed, float32((amount-0.5)*2))
+			}
+			gc := c.GoColor()
+			x0 := col * m.Stride
+			y0 := row * m.Stride
+			x1 := x0 + m.Stride
+			y1 := y0 + m.Stride
+			if col == m.Cols-1 {
+				x1 = size.W
+			}
+			if row == m.Rows-1 {
+				y1 = size.H
+			}
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					img.Set(x, y, gc)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// CompareToReference gets PSNR and SSIM of img compared to its source ref, which must be the same size.
+// If makeDebugImage is set, the SSIM map is drawn in DebugImage, see SSIMMap.DebugImage.
+func CompareToReference(ref, img image.Image, makeDebugImage bool) (ReferenceAnalytics, error) {
+	var r ReferenceAnalytics
+	rp, ip, err := lumaPlanes(ref, img)
+	if err != nil {
+		return r, err
+	}
+	r.Size = rp.size
+	r.PSNR, r.MSE = planePSNR(rp, ip)
+	r.SSIMMap = planeSSIMMap(rp, ip)
+	r.SSIM = r.SSIMMap.Mean()
+	r.MinSSIM = r.SSIMMap.Min()
+	if makeDebugImage {
+		r.DebugImage = r.SSIMMap.DebugImage(r.Size)
+	}
+	return r, nil
+}
@@ -0,0 +1,87 @@
+package zanalysis
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+
+	"github.com/torlangballe/zutil/zgeo"
+)
+
+func makeGrayFrame(w, h int, level uint8) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = level
+	}
+	return img
+}
+
+func TestPSNR(t *testing.T) {
+	ref := makeTestFrame(64, 48)
+	psnr, mse, err := PSNR(ref, ref)
+	if err != nil || psnr != IdenticalPSNR || mse != 0 {
+		t.Error("identical:", psnr, mse, err)
+	}
+	psnr, mse, err = PSNR(makeGrayFrame(64, 48, 100), makeGrayFrame(64, 48, 110))
+	if err != nil || math.Abs(mse-100) > 1e-6 || math.Abs(psnr-10*math.Log10(255*255/100.0)) > 1e-6 {
+		t.Error("offset by 10:", psnr, mse, err)
+	}
+	if _, _, err = PSNR(ref, makeTestFrame(64, 47)); err != ErrSizeMismatch {
+		t.Error("size mismatch:", err)
+	}
+}
+
+func TestSSIM(t *testing.T) {
+	ref := makeTestFrame(64, 48)
+	mean, m, err := SSIM(ref, ref)
+	if err != nil || math.Abs(mean-1) > 1e-9 || math.Abs(m.Min()-1) > 1e-9 {
+		t.Error("identical:", mean, err)
+	}
+	if m.Window != 8 || m.Stride != 4 || m.Cols != 15 || m.Rows != 11 || len(m.Values) != m.Cols*m.Rows {
+		t.Errorf("map: %d %d %dx%d %d", m.Window, m.Stride, m.Cols, m.Rows, len(m.Values))
+	}
+	damaged := image.NewRGBA(ref.Bounds())
+	copy(damaged.Pix, ref.Pix)
+	for y := 20; y < 28; y++ {
+		for x := 32; x < 40; x++ {
+			damaged.Set(x, y, color.RGBA{255, 255, 255, 255})
+		}
+	}
+	mean, m, _ = SSIM(ref, damaged)
+	if mean >= 1 || m.Min() >= mean {
+		t.Error("damage not found:", mean, m.Min())
+	}
+	for row := 0; row < m.Rows; row++ {
+		for col := 0; col < m.Cols; col++ {
+			x, y := col*m.Stride, row*m.Stride
+			overlaps := x < 40 && x+m.Window > 32 && y < 28 && y+m.Window > 20
+			if overlaps != (m.At(col, row) < 0.999) {
+				t.Error("window damaged:", col, row, overlaps, m.At(col, row))
+			}
+		}
+	}
+	_, m, _ = SSIM(makeTestFrame(5, 6), makeTestFrame(5, 6))
+	if m.Window != 5 || m.Cols != 1 || m.Rows != 1 {
+		t.Error("small image:", m.Window, m.Cols, m.Rows)
+	}
+}
+
+func TestSSIMMapDebugImage(t *testing.T) {
+	m := &SSIMMap{Window: 8, Stride: 4, Cols: 2, Rows: 2, Values: []float64{1, 0, 1, 1}}
+	img := m.DebugImage(zgeo.ISize{W: 11, H: 9})
+	isRed := func(x, y int) bool {
+		r, g, _, _ := img.At(x, y).RGBA()
+		return r > g
+	}
+	for _, p := range []image.Point{{0, 0}, {3, 3}, {0, 8}, {10, 8}} {
+		if isRed(p.X, p.Y) {
+			t.Error("should be green:", p)
+		}
+	}
+	for _, p := range []image.Point{{4, 0}, {10, 3}} {
+		if !isRed(p.X, p.Y) {
+			t.Error("should be red:", p)
+		}
+	}
+}
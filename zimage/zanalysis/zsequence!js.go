@@ -0,0 +1,199 @@
+package zanalysis
+
+import (
+	"image"
+	"math"
+	"time"
+
+	"github.com/torlangballe/zui/zimage"
+)
+
+type AnomalyKind string
+
+const (
+	AnomalyFreeze   AnomalyKind = "freeze"   // the picture hasn't changed for at least FreezeMinDuration
+	AnomalyBlack    AnomalyKind = "black"    // frames are black for at least SolidMinDuration
+	AnomalySolid    AnomalyKind = "solid"    // frames are a single colour other than black for at least SolidMinDuration
+	AnomalyFlash    AnomalyKind = "flash"    // mean luma jumped and came back within FlashMaxDuration
+	AnomalySceneCut AnomalyKind = "scenecut" // most of the picture changed from one frame to the next
+)
+
+// Anomaly is something wrong or notable found in a sequence of frames.
+// Freeze, black and solid span Start to End, where End is the time of the first frame without it.
+// A scene cut has End equal to Start.
+type Anomaly struct {
+	Kind  AnomalyKind
+	Start time.Time
+	End   time.Time
+	Value float64 // Value is the mean luma for black/solid, the luma jump for flash, and ratio of pixels changed for a scene cut
+}
+
+// FrameStats are the measurements of a frame used to find anomalies.
+type FrameStats struct {
+	Time      time.Time
+	MeanLuma  float64 // MeanLuma is the BT.601 luma, 0-255
+	Flats     float64 // Flats is ImageInfo's FlatAmount, 1 is a single colour
+	DiffRatio float64 // DiffRatio is the ratio of pixels differing from the previous frame, see zimage.GoImagesDiffAreaRatio
+}
+
+// SequenceAnalyzer is fed consecutive frames of a stream with AddFrame, and reports anomalies over time.
+// It uses an ImageInfo for the per-frame counts, so its thresholds can be set too.
+type SequenceAnalyzer struct {
+	Info                 *ImageInfo    // Info's thresholds, LimitFrame and DetectBars are used to analyze each frame, in a copy, so it isn't changed.
+	PixelDiffPercent     float64       // PixelDiffPercent is how much a pixel's color components must differ to count as changed.
+	FreezeMaxDiffRatio   float64       // FreezeMaxDiffRatio is the most ratio of changed pixels a frame can have and be frozen.
+	FreezeMinDuration    time.Duration // FreezeMinDuration is how long frames must be frozen to report it.
+	SolidMinFlats        float64       // SolidMinFlats is the least Flats a frame can have to be a single colour.
+	BlackMaxLuma         float64       // BlackMaxLuma is the highest MeanLuma a solid frame can have to be black.
+	SolidMinDuration     time.Duration // SolidMinDuration is how long frames must be black or solid to report it.
+	FlashMinLumaChange   float64       // FlashMinLumaChange is the least jump in MeanLuma from one frame to the next that can start a flash.
+	FlashMaxDuration     time.Duration // FlashMaxDuration is the longest MeanLuma can be away before returning for it to be a flash.
+	SceneCutMinDiffRatio float64       // SceneCutMinDiffRatio is the least ratio of changed pixels for a scene cut.
+	Last                 FrameStats    // Last is the stats of the last frame added.
+
+	previous   image.Image
+	freeze     interval
+	black      interval
+	solid      interval
+	flashStart time.Time
+	flashLuma  float64 // flashLuma is the mean luma before the flash started
+	flashJump  float64
+	flashDiff  float64 // flashDiff is the DiffRatio of the frame that started the flash, reported as a scene cut if it turns out not to be one
+}
+
+type interval struct {
+	active bool
+	start  time.Time
+	value  float64
+}
+
+func NewSequenceAnalyzer() *SequenceAnalyzer {
+	s := &SequenceAnalyzer{}
+	s.Info = NewImageInfo()
+	s.PixelDiffPercent = 2
+	s.FreezeMaxDiffRatio = 0.001
+	s.FreezeMinDuration = 2 * time.Second
+	s.SolidMinFlats = 0.98
+	s.BlackMaxLuma = 20
+	s.SolidMinDuration = time.Second
+	s.FlashMinLumaChange = 80
+	s.FlashMaxDuration = 250 * time.Millisecond
+	s.SceneCutMinDiffRatio = 0.6
+	return s
+}
+
+// AddFrame analyzes img, the frame shown at t, comparing it with the previous frame.
+// It returns anomalies that have ended or happened with this frame.
+// A cut to a much darker or brighter picture could be a flash, so is only returned when it lasts longer than FlashMaxDuration.
+func (s *SequenceAnalyzer) AddFrame(img image.Image, t time.Time) []Anomaly {
+	var anomalies []Anomaly
+	info := *s.Info
+	info.Analyze(img)
+	stats := FrameStats{Time: t, MeanLuma: meanLuma(img), Flats: info.FlatAmount().Average()}
+	hasPrevious := (s.previous != nil && s.previous.Bounds().Size() == img.Bounds().Size())
+	if hasPrevious {
+		stats.DiffRatio = zimage.GoImagesDiffAreaRatio(s.previous, img, s.PixelDiffPercent, false)
+	}
+	solid := (stats.Flats >= s.SolidMinFlats)
+	black := solid && stats.MeanLuma <= s.BlackMaxLuma
+	frozen := hasPrevious && stats.DiffRatio <= s.FreezeMaxDiffRatio
+	// Freezing is measured from the frame before the first unchanged one, as that is when the picture stopped.
+	anomalies = s.freeze.update(anomalies, AnomalyFreeze, frozen, s.Last.Time, t, s.FreezeMinDuration, stats.DiffRatio)
+	anomalies = s.black.update(anomalies, AnomalyBlack, black, t, t, s.SolidMinDuration, stats.MeanLuma)
+	anomalies = s.solid.update(anomalies, AnomalySolid, solid && !black, t, t, s.SolidMinDuration, stats.MeanLuma)
+	if hasPrevious {
+		var inFlash bool
+		anomalies, inFlash = s.checkFlash(anomalies, stats)
+		if stats.DiffRatio >= s.SceneCutMinDiffRatio && !inFlash {
+			anomalies = append(anomalies, Anomaly{Kind: AnomalySceneCut, Start: t, End: t, Value: stats.DiffRatio})
+		}
+	}
+	s.previous = img
+	s.Last = stats
+	return anomalies
+}
+
+// Flush ends ongoing freeze, black and solid intervals at t, returning those long enough to report,
+// and a scene cut for a possible flash that hadn't come back yet.
+// Call it when a stream ends.
+func (s *SequenceAnalyzer) Flush(t time.Time) []Anomaly {
+	var anomalies []Anomaly
+	anomalies = s.freeze.update(anomalies, AnomalyFreeze, false, t, t, s.FreezeMinDuration, 0)
+	anomalies = s.black.update(anomalies, AnomalyBlack, false, t, t, s.SolidMinDuration, 0)
+	anomalies = s.solid.update(anomalies, AnomalySolid, false, t, t, s.SolidMinDuration, 0)
+	anomalies = s.endFlashAsSceneCut(anomalies)
+	s.previous = nil
+	return anomalies
+}
+
+// update starts the interval at start if on and not active, or ends it at end if not on,
+// adding it to anomalies if it lasted minDuration.
+func (iv *interval) update(anomalies []Anomaly, kind AnomalyKind, on bool, start, end time.Time, minDuration time.Duration, value float64) []Anomaly {
+	if on {
+		if !iv.active {
+			iv.active = true
+			iv.start = start
+			iv.value = value
+		}
+		return anomalies
+	}
+	if iv.active {
+		iv.active = false
+		if end.Sub(iv.start) >= minDuration {
+			anomalies = append(anomalies, Anomaly{Kind: kind, Start: iv.start, End: end, Value: iv.value})
+		}
+	}
+	return anomalies
+}
+
+// checkFlash starts a flash if MeanLuma jumps, and reports it if it comes back within FlashMaxDuration.
+// inFlash is true if the frame started or ended a flash, or is in one, so isn't a scene cut of its own.
+func (s *SequenceAnalyzer) checkFlash(anomalies []Anomaly, stats FrameStats) (all []Anomaly, inFlash bool) {
+	if !s.flashStart.IsZero() {
+		if math.Abs(stats.MeanLuma-s.flashLuma) < s.FlashMinLumaChange/2 {
+			anomalies = append(anomalies, Anomaly{Kind: AnomalyFlash, Start: s.flashStart, End: stats.Time, Value: s.flashJump})
+			s.flashStart = time.Time{}
+			return anomalies, true
+		}
+		if stats.Time.Sub(s.flashStart) <= s.FlashMaxDuration {
+			return anomalies, true
+		}
+		return s.endFlashAsSceneCut(anomalies), false // it stayed, so was a change in level, not a flash
+	}
+	jump := stats.MeanLuma - s.Last.MeanLuma
+	if math.Abs(jump) >= s.FlashMinLumaChange {
+		s.flashStart = stats.Time
+		s.flashLuma = s.Last.MeanLuma
+		s.flashJump = jump
+		s.flashDiff = stats.DiffRatio
+		return anomalies, true
+	}
+	return anomalies, false
+}
+
+// endFlashAsSceneCut ends a possible flash that didn't come back, reporting its start as a scene cut if enough changed.
+func (s *SequenceAnalyzer) endFlashAsSceneCut(anomalies []Anomaly) []Anomaly {
+	if s.flashStart.IsZero() {
+		return anomalies
+	}
+	if s.flashDiff >= s.SceneCutMinDiffRatio {
+		anomalies = append(anomalies, Anomaly{Kind: AnomalySceneCut, Start: s.flashStart, End: s.flashStart, Value: s.flashDiff})
+	}
+	s.flashStart = time.Time{}
+	return anomalies
+}
+
+func meanLuma(img image.Image) float64 {
+	b := img.Bounds()
+	if b.Empty() {
+		return 0
+	}
+	var sum float64
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			r, g, bl, _ := img.At(x, y).RGBA()
+			sum += (0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)) / 257
+		}
+	}
+	return sum / float64(b.Dx()*b.Dy())
+}
@@ -0,0 +1,95 @@
+package zanalysis
+
+import (
+	"image"
+	"image/color"
+	"testing"
+	"time"
+
+	"github.com/torlangballe/zutil/zgeo"
+)
+
+// makeLevelFrame makes a textured gray frame with a mean luma of about level, so all pixels change if level does.
+func makeLevelFrame(w, h int, level uint8) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			v := level + uint8((x*7+y*13)%20)
+			img.Set(x, y, color.RGBA{v, v, v, 255})
+		}
+	}
+	return img
+}
+
+// addLevels adds a frame for each level at 25 fps, returning the flash and scene cut anomalies and when each frame was.
+func addLevels(s *SequenceAnalyzer, levels []uint8) (anomalies []Anomaly, times []time.Time) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	frames := map[uint8]*image.RGBA{}
+	for i, l := range levels {
+		if frames[l] == nil {
+			frames[l] = makeLevelFrame(64, 48, l)
+		}
+		t := start.Add(time.Duration(i) * 40 * time.Millisecond)
+		times = append(times, t)
+		for _, a := range s.AddFrame(frames[l], t) {
+			if a.Kind == AnomalyFlash || a.Kind == AnomalySceneCut {
+				anomalies = append(anomalies, a)
+			}
+		}
+	}
+	for _, a := range s.Flush(start.Add(time.Duration(len(levels)) * 40 * time.Millisecond)) {
+		if a.Kind == AnomalyFlash || a.Kind == AnomalySceneCut {
+			anomalies = append(anomalies, a)
+		}
+	}
+	return anomalies, times
+}
+
+func repeatLevel(level uint8, n int) []uint8 {
+	levels := make([]uint8, n)
+	for i := range levels {
+		levels[i] = level
+	}
+	return levels
+}
+
+func TestSequenceFlashAndSceneCut(t *testing.T) {
+	// A flash: bright for 2 frames, then back.
+	levels := append(append(repeatLevel(40, 5), repeatLevel(220, 2)...), repeatLevel(40, 5)...)
+	anomalies, times := addLevels(NewSequenceAnalyzer(), levels)
+	if len(anomalies) != 1 || anomalies[0].Kind != AnomalyFlash || !anomalies[0].Start.Equal(times[5]) || !anomalies[0].End.Equal(times[7]) {
+		t.Errorf("flash: %+v", anomalies)
+	}
+
+	// A cut from dark to bright that stays is reported as a scene cut once longer than a flash, from when it happened.
+	levels = append(repeatLevel(40, 5), repeatLevel(220, 15)...)
+	anomalies, times = addLevels(NewSequenceAnalyzer(), levels)
+	if len(anomalies) != 1 || anomalies[0].Kind != AnomalySceneCut || !anomalies[0].Start.Equal(times[5]) {
+		t.Errorf("dark to bright cut: %+v", anomalies)
+	}
+
+	// A cut just before the stream ends is reported when flushed.
+	levels = append(repeatLevel(220, 5), 40)
+	anomalies, times = addLevels(NewSequenceAnalyzer(), levels)
+	if len(anomalies) != 1 || anomalies[0].Kind != AnomalySceneCut || !anomalies[0].Start.Equal(times[5]) {
+		t.Errorf("cut at end: %+v", anomalies)
+	}
+
+	// A cut to a picture of about the same brightness is reported at once.
+	levels = append(repeatLevel(100, 5), repeatLevel(130, 5)...)
+	anomalies, times = addLevels(NewSequenceAnalyzer(), levels)
+	if len(anomalies) != 1 || anomalies[0].Kind != AnomalySceneCut || !anomalies[0].Start.Equal(times[5]) {
+		t.Errorf("same level cut: %+v", anomalies)
+	}
+}
+
+func TestSequenceKeepsInfo(t *testing.T) {
+	s := NewSequenceAnalyzer()
+	limit := zgeo.IRectForXYWH(4, 4, 32, 32)
+	s.Info.LimitFrame = limit
+	s.Info.DetectBars = true
+	addLevels(s, []uint8{40, 40})
+	if s.Info.LimitFrame != limit {
+		t.Error("AddFrame changed Info.LimitFrame:", s.Info.LimitFrame)
+	}
+}
@@ -116,6 +116,8 @@ var (
 	CopyKeyMod       = KMod('C', ModifierMenu)
 	PasteKeyMod      = KMod('V', ModifierMenu)
 	CutKeyMod        = KMod('X', ModifierMenu)
+	UndoKeyMod       = KMod('Z', ModifierMenu)
+	RedoKeyMod       = KMod('Z', ModifierMenu|ModifierShift)
 )
 
 func init() {
@@ -125,6 +127,8 @@ func init() {
 		CopyKeyMod = KMod('C', ModifierMenu) // need to do this again as ModiferMenu changed
 		PasteKeyMod = KMod('V', ModifierMenu)
 		CutKeyMod = KMod('X', ModifierMenu)
+		UndoKeyMod = KMod('Z', ModifierMenu)
+		RedoKeyMod = KMod('Z', ModifierMenu|ModifierShift)
 	} else if zdevice.OS() == zdevice.LinuxType {
 		CopyKeyMod = KMod('C', ModifierMenu|ModifierShift)
 		PasteKeyMod = KMod('V', ModifierMenu|ModifierShift)
@@ -0,0 +1,78 @@
+//go:build !js && zui
+
+package zlabel
+
+import (
+	"github.com/torlangballe/zui/zstyle"
+	"github.com/torlangballe/zui/ztextinfo"
+	"github.com/torlangballe/zui/zview"
+	"github.com/torlangballe/zutil/zgeo"
+)
+
+func (label *Label) InitAsLink(view zview.View, title, surl string, newWindow bool) {
+	label.MakeHeadless(view, "a")
+	label.init(title)
+	label.SetURL(surl, newWindow)
+}
+
+func (label *Label) Init(view zview.View, text string) {
+	label.MakeHeadless(view, "label")
+	label.init(text)
+}
+
+func (label *Label) init(text string) {
+	label.pressWithModifierToClipboard = -1
+	label.alignment = zgeo.Left
+	label.SetColor(zstyle.DefaultFGColor())
+	label.wrap = ztextinfo.WrapNone
+	label.SetObjectName(text)
+	label.SetMaxLines(1)
+	label.SetText(text)
+	f := zgeo.FontNice(zgeo.FontDefaultSize, zgeo.FontStyleNormal)
+	label.SetFont(f)
+}
+
+func (label *Label) SetURL(surl string, newWindow bool) {
+	label.JSSet("href", surl)
+	label.SetUsable(surl != "")
+}
+
+func (v *Label) SetText(text string) {
+	v.text = text
+	v.runs = nil
+	v.NativeView.SetInnerText(text)
+}
+
+// SetRuns sets the label's text to attributed runs, which are drawn by ztextinfo when rendering headless labels.
+func (v *Label) SetRuns(runs []ztextinfo.Run) {
+	var ti ztextinfo.Info
+	ti.SetRuns(runs)
+	v.text = ti.Text
+	v.runs = runs
+	v.NativeView.SetInnerText(ti.Text)
+}
+
+func (v *Label) SetWrap(wrap ztextinfo.WrapType) {
+	v.wrap = wrap
+}
+
+func (v *Label) SetMaxLines(max int) {
+	v.maxLines = max
+}
+
+func (v *Label) SetTextAlignment(a zgeo.Alignment) {
+	v.alignment = a
+}
+
+func (v *Label) SetMargin(m zgeo.Rect) {
+	v.margin = m
+}
+
+// Margin returns what was set with SetMargin. It is used when rendering headless labels.
+func (v *Label) Margin() zgeo.Rect {
+	return v.margin
+}
+
+func (v *Label) OutsideDropStroke(delta float64, col zgeo.Color) {
+	v.SetDropShadow(zgeo.MakeDropShadow(0, 0, delta, col))
+}
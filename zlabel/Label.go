@@ -30,6 +30,7 @@ type Label struct {
 	alignment         zgeo.Alignment
 	text              string // we need to store the text as NativeView's Text() doesn't work right away
 	wrap              ztextinfo.WrapType
+	runs              []ztextinfo.Run // runs are set with SetRuns, for attributed text
 	pressed           func()
 	longPressed       func()
 
@@ -67,18 +68,44 @@ func (v *Label) MakeZAppLink(path string) {
 	v.SetCursor(zcursor.Pointer)
 	ztextinfo.SetTextDecoration(&v.NativeView, ztextinfo.DecorationUnderlined)
 	v.SetPressedHandler("zapp-link", 0, func() {
-		var parts []zdocs.PathPart
-		for _, stub := range strings.Split(path, "/") {
-			pp := zdocs.PathPart{PathStub: stub, Type: zdocs.PressField}
-			parts = append(parts, pp)
-		}
-		if zdocs.PartOpener != nil {
-			zdocs.PartOpener.OpenGUIFromPathParts(parts)
-		}
+		openZAppPath(path)
 	})
 
 }
 
+// openZAppPath opens the gui at path, from a zapp:// link without the prefix.
+func openZAppPath(path string) {
+	var parts []zdocs.PathPart
+	for _, stub := range strings.Split(path, "/") {
+		pp := zdocs.PathPart{PathStub: stub, Type: zdocs.PressField}
+		parts = append(parts, pp)
+	}
+	if zdocs.PartOpener != nil {
+		zdocs.PartOpener.OpenGUIFromPathParts(parts)
+	}
+}
+
+// Runs returns the attributed text set with SetRuns, or nil.
+func (v *Label) Runs() []ztextinfo.Run {
+	return v.runs
+}
+
+// RunsForMatch returns runs of m's text, with the matched part highlighted with a highlight background.
+// Use it with SetRuns to show search matches.
+func RunsForMatch(m zdocs.MatchedText, highlight zgeo.Color) []ztextinfo.Run {
+	var runs []ztextinfo.Run
+	if m.Pre != "" {
+		runs = append(runs, ztextinfo.Run{Text: m.Pre})
+	}
+	if m.Match != "" {
+		runs = append(runs, ztextinfo.Run{Text: m.Match, Background: highlight})
+	}
+	if m.Post != "" {
+		runs = append(runs, ztextinfo.Run{Text: m.Post})
+	}
+	return runs
+}
+
 func (v *Label) GetToolTipAddition() string {
 	var str string
 	if !v.KeyboardShortcut.IsNull() {
@@ -131,6 +158,9 @@ func (v *Label) GetTextInfo() ztextinfo.Info {
 	t.Wrap = v.wrap
 	if v.Columns == 0 {
 		t.Text = v.Text()
+		if len(v.runs) != 0 {
+			t.SetRuns(v.runs)
+		}
 	}
 	if v.maxWidth != 0 {
 		t.SetWidthFreeHight(v.maxWidth)
@@ -2,7 +2,10 @@ package zlabel
 
 import (
 	"fmt"
+	"html"
+	"sort"
 	"strings"
+	"syscall/js"
 
 	"github.com/torlangballe/zui/zdom"
 	"github.com/torlangballe/zui/zkeyboard"
@@ -12,6 +15,7 @@ import (
 	"github.com/torlangballe/zutil/zdevice"
 	"github.com/torlangballe/zutil/zgeo"
 	"github.com/torlangballe/zutil/zlog"
+	"github.com/torlangballe/zutil/zstr"
 )
 
 func (label *Label) InitAsLink(view zview.View, title, surl string, newWindow bool) {
@@ -65,14 +69,105 @@ func (label *Label) SetURL(surl string, newWindow bool) {
 }
 
 func (v *Label) SetText(text string) {
-	if v.Text() == text {
+	if v.Text() == text && len(v.runs) == 0 {
 		return
 	}
+	if len(v.runs) != 0 {
+		v.SetListenerJSFunc(zappLinksClickName, nil)
+	}
 	v.text = text
+	v.runs = nil
 	v.NativeView.SetInnerText(text)
 	setPadding(v)
 }
 
+// zappLinksClickName is the name of the click listener for zapp:// links in runs.
+// Being a named listener, it is released when set again or the label is removed, and doesn't replace other click handlers.
+const zappLinksClickName = "click:zapplinks"
+
+// SetRuns sets the label's text to attributed runs, each a span styled with its font, color, background and decoration.
+// Runs with a Link are links; zapp:// links open the gui path like MakeZAppLink.
+func (v *Label) SetRuns(runs []ztextinfo.Run) {
+	var text, inner string
+	var hasZAppLink bool
+	for _, r := range runs {
+		text += r.Text
+		inner += runHTML(r)
+		if strings.HasPrefix(r.Link, "zapp://") {
+			hasZAppLink = true
+		}
+	}
+	v.text = text
+	v.runs = runs
+	v.JSSet("innerHTML", inner)
+	setPadding(v)
+	if !hasZAppLink {
+		v.SetListenerJSFunc(zappLinksClickName, nil)
+		return
+	}
+	v.SetListenerJSFunc(zappLinksClickName, func(this js.Value, args []js.Value) any {
+		path := args[0].Get("target").Get("dataset").Get("zapp")
+		if path.Truthy() {
+			openZAppPath(path.String())
+		}
+		return nil
+	})
+}
+
+func runHTML(r ztextinfo.Run) string {
+	var styles []string
+	if r.Font != nil {
+		for k, val := range zdom.GetFontCSSKeyValues(r.Font) {
+			styles = append(styles, k+":"+val)
+		}
+	}
+	if r.Color.Valid {
+		styles = append(styles, "color:"+zdom.MakeRGBAString(r.Color))
+	}
+	if r.Background.Valid {
+		styles = append(styles, "background-color:"+zdom.MakeRGBAString(r.Background), "border-radius:2px")
+	}
+	d := r.Decoration
+	var lines []string
+	if d.LinePos&ztextinfo.DecorationUnder != 0 {
+		lines = append(lines, "underline")
+	}
+	if d.LinePos&ztextinfo.DecorationOver != 0 {
+		lines = append(lines, "overline")
+	}
+	if d.LinePos&ztextinfo.DecorationMiddle != 0 {
+		lines = append(lines, "line-through")
+	}
+	if len(lines) != 0 {
+		styles = append(styles, "text-decoration-line:"+strings.Join(lines, " "))
+		switch d.Style {
+		case ztextinfo.DecorationDashed:
+			styles = append(styles, "text-decoration-style:dashed")
+		case ztextinfo.DecorationWavy:
+			styles = append(styles, "text-decoration-style:wavy")
+		}
+		if d.Width > 0 {
+			styles = append(styles, fmt.Sprintf("text-decoration-thickness:%gpx", d.Width))
+		}
+		if d.Color.Valid {
+			styles = append(styles, "text-decoration-color:"+zdom.MakeRGBAString(d.Color))
+		}
+	}
+	sort.Strings(styles)
+	style := html.EscapeString(strings.Join(styles, ";"))
+	text := html.EscapeString(r.Text)
+	var zpath string
+	if zstr.HasPrefix(r.Link, "zapp://", &zpath) {
+		return fmt.Sprintf(`<span style="cursor:pointer;%s" data-zapp="%s">%s</span>`, style, html.EscapeString(zpath), text)
+	}
+	if r.Link != "" && !ztextinfo.IsSafeLink(r.Link) {
+		zlog.Error("unsafe link not made:", r.Link)
+	} else if r.Link != "" {
+		return fmt.Sprintf(`<a href="%s" target="_blank" rel="noopener noreferrer" style="%s">%s</a>`, html.EscapeString(r.Link), style, text)
+	}
+	return fmt.Sprintf(`<span style="%s">%s</span>`, style, text)
+}
+
 func setPadding(v *Label) {
 	pad := v.margin
 
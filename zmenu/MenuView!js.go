@@ -3,24 +3,120 @@
 package zmenu
 
 import (
+	"fmt"
+
 	"github.com/torlangballe/zutil/zdict"
 	"github.com/torlangballe/zutil/zgeo"
+	"github.com/torlangballe/zutil/zkeyvalue"
+	"github.com/torlangballe/zutil/zlog"
+	"github.com/torlangballe/zutil/zslices"
 )
 
+// NewView creates a headless menu, keeping its items and current value in memory like the js select does.
 func NewView(name string, items zdict.Items, value any) *MenuView {
-	return &MenuView{}
+	v := &MenuView{}
+	v.MakeHeadless(v, "select")
+	v.SetObjectName(name)
+	if len(items) > 0 {
+		v.UpdateItems(items, value, false)
+	}
+	return v
+}
+
+func (v *MenuView) ReadyToShow(beforeWindow bool) {
+	if beforeWindow && v.storeKey != "" {
+		val, got := zkeyvalue.DefaultStore.GetItemAsAny(v.storeKey)
+		if got {
+			v.SelectWithValue(val)
+		}
+	}
 }
 
-func (v *MenuView) SetSelectedHandler(handler func()) {}
-func (v *MenuView) Empty()                            {}
-func (v *MenuView) AddSeparator()                     {}
+func (v *MenuView) SetSelectedHandler(handler func(edited bool)) {
+	v.selectedHandler = handler
+}
+
+func (v *MenuView) Empty() {
+	v.items = v.items[:0]
+	v.currentValue = nil
+}
 
-func (v *MenuView) SelectWithValue(value any) bool                          { return true }
-func (v *MenuView) SetFont(font *zgeo.Font)                                 {}
-func (v *MenuView) AddItem(name string, value any)                          {}
-func (v *MenuView) RemoveItemByValue(value any)                             {}
-func (v *MenuView) ChangeNameForValue(name string, value any)               {}
-func (v *MenuView) UpdateItems(items zdict.Items, value any, isAction bool) {}
+func (v *MenuView) AddSeparator() {
+	var item zdict.Item
+	item.Name = MenuSeparatorID
+	v.items = append(v.items, item)
+}
+
+func (v *MenuView) AddItem(name string, value any) {
+	var item zdict.Item
+	item.Name = name
+	item.Value = value
+	v.items = append(v.items, item)
+}
+
+func (v *MenuView) RemoveItemByValue(value any) {
+	sval := fmt.Sprint(value)
+	for i, item := range v.items {
+		if fmt.Sprint(item.Value) == sval {
+			zslices.RemoveAt(&v.items, i)
+			break
+		}
+	}
+	if fmt.Sprint(v.currentValue) == sval {
+		v.currentValue = nil
+	}
+}
+
+func (v *MenuView) ChangeNameForValue(name string, value any) {
+	if zlog.ErrorIf(value == nil, v.ObjectName()) {
+		return
+	}
+	for i, item := range v.items {
+		if fmt.Sprint(item.Value) == fmt.Sprint(value) {
+			v.items[i].Name = name
+			break
+		}
+	}
+}
+
+func (v *MenuView) UpdateItems(items zdict.Items, value any, isAction bool) {
+	v.items = items
+	v.SelectWithValue(value)
+}
+
+func (v *MenuView) SelectWithValue(value any) bool {
+	if value == nil {
+		return false
+	}
+	for _, item := range v.items {
+		if fmt.Sprint(item.Value) == fmt.Sprint(value) {
+			v.currentValue = item.Value
+			if v.selectedHandler != nil {
+				v.CurrentSelectIsProgramatic = true
+				v.selectedHandler(false)
+				v.CurrentSelectIsProgramatic = false
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// SelectIndex selects the item at index as if the user did it, calling the selected handler with edited true.
+func (v *MenuView) SelectIndex(index int) {
+	zlog.Assert(index < len(v.items), "index too big", index, len(v.items))
+	v.currentValue = v.items[index].Value
+	if v.storeKey != "" {
+		zkeyvalue.DefaultStore.SetItem(v.storeKey, v.currentValue, true)
+	}
+	if v.selectedHandler != nil {
+		v.selectedHandler(!v.CurrentSelectIsProgramatic)
+	}
+}
+
+func (v *MenuView) SetFont(font *zgeo.Font) {
+	v.NativeView.SetFont(font)
+}
 
 func menuViewGetHackedFontForSize(font *zgeo.Font) *zgeo.Font {
 	return font
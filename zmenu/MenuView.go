@@ -51,6 +51,10 @@ func (v *MenuView) CurrentItem() *zdict.Item {
 	return nil
 }
 
+func (v *MenuView) Items() zdict.Items {
+	return v.items
+}
+
 func (v *MenuView) GetDump() string {
 	return fmt.Sprintf("%+v", v.items)
 }
@@ -25,6 +25,7 @@ import (
 	"github.com/torlangballe/zui/zpresent"
 	"github.com/torlangballe/zui/zshape"
 	"github.com/torlangballe/zui/zstyle"
+	"github.com/torlangballe/zui/ztext"
 	"github.com/torlangballe/zui/ztextinfo"
 	"github.com/torlangballe/zui/zview"
 	"github.com/torlangballe/zui/zwindow"
@@ -33,6 +34,7 @@ import (
 	"github.com/torlangballe/zutil/zgeo"
 	"github.com/torlangballe/zutil/zkeyvalue"
 	"github.com/torlangballe/zutil/zlog"
+	"github.com/torlangballe/zutil/zmath"
 	"github.com/torlangballe/zutil/zslices"
 	"github.com/torlangballe/zutil/ztimer"
 	"github.com/torlangballe/zutil/zwords"
@@ -75,7 +77,12 @@ type MenuedOwner struct {
 	HoverColor                zgeo.Color
 	MinWidth                  float64
 	DefaultSelected           any
+	SearchMinItems            int // SearchMinItems is how many items a popup needs to get a type-ahead search field. 0 uses DefaultSearchMinItems, -1 never
 	items                     []MenuedOItem
+	filterMatches             map[int][]zmath.Range[int] // filterMatches are the runes matched in each item's name by the popup's search filter
+	parent                    *MenuedOwner               // parent is the owner whose popup opened this one as a submenu
+	openSub                   *MenuedOwner               // openSub is the submenu open from the current popup
+	openSubID                 string
 	hasShortcut               bool
 	currentPopupStack         *zcontainer.StackView
 	needsSave                 bool
@@ -95,6 +102,7 @@ type MenuedOItem struct {
 	IsDebug           bool
 	Function          func()
 	SearchableSubView zdocs.SearchableItemsGetter
+	Children          []MenuedOItem // Children are actions shown in a submenu opened from this item, see MenuedSubMenu
 }
 
 var (
@@ -104,6 +112,7 @@ var (
 	//	MenuedOwnerDefaultHightlightColor = zstyleColF(zgeo.ColorNewGray(0, 0.7), zgeo.ColorNewGray(1, 0.7))
 	MenuedOwnerDefaultHightlightColor = zstyle.ColCur(zgeo.ColorNew(0.035, 0.29, 0.85, 1), zgeo.ColorNew(0.8, 0.8, 1, 1))
 	menuOwnersMap                     = map[zview.View]*MenuedOwner{}
+	DefaultSearchMinItems             = 15
 )
 
 func NewMenuedOwner() *MenuedOwner {
@@ -127,6 +136,16 @@ func MenuedAction(name string, val any) MenuedOItem {
 	return item
 }
 
+// MenuedSubMenu creates an item that opens a submenu of children actions, on hover, right-arrow or press.
+func MenuedSubMenu(name string, children ...MenuedOItem) MenuedOItem {
+	var item MenuedOItem
+	item.Name = name
+	item.Value = rand.Int31()
+	item.IsAction = true
+	item.Children = children
+	return item
+}
+
 func MenuedFuncAction(name string, f func()) MenuedOItem {
 	return MenuedSCFuncAction(name, zkeyboard.KeyNone, zkeyboard.ModifierNone, f)
 }
@@ -426,6 +445,11 @@ func (o *MenuedOwner) SetSelectedValue(val any) {
 	o.SetSelectedValues([]any{val})
 }
 
+// Items returns the items as last set or generated, without calling CreateItemsFunc or adding edit actions.
+func (o *MenuedOwner) Items() []MenuedOItem {
+	return o.items
+}
+
 func (o *MenuedOwner) RegenerateItems() {
 	o.getItems()
 }
@@ -530,18 +554,20 @@ func (o *MenuedOwner) ChangeNameForValue(name string, value any) {
 }
 
 const (
-	gap           = 4
-	colorWidth    = 20
-	imageWidth    = 40
-	checkWidth    = 14
-	shortcutWidth = 28
-	topMarg       = 6
-	bottomMarg    = 2
-	rightMarg     = 4
+	gap               = 4
+	colorWidth        = 20
+	imageWidth        = 40
+	checkWidth        = 14
+	shortcutWidth     = 28
+	submenuArrowWidth = 12
+	topMarg           = 6
+	bottomMarg        = 2
+	rightMarg         = 4
 )
 
 func (o *MenuedOwner) popup() {
 	allAction := true
+	hasChildren := false
 	o.hasShortcut = false
 	items := o.getItems()
 	for _, item := range items {
@@ -551,6 +577,9 @@ func (o *MenuedOwner) popup() {
 		if item.Shortcut.Key != zkeyboard.KeyNone {
 			o.hasShortcut = true
 		}
+		if len(item.Children) != 0 {
+			hasChildren = true
+		}
 	}
 	stack := zcontainer.StackViewVert("menued-pop-stack")
 	o.currentPopupStack = stack
@@ -574,12 +603,53 @@ func (o *MenuedOwner) popup() {
 	list.BorderColor = zgeo.Color{}
 	list.DeselectOnEscape = false
 	list.CellColorFunc = func(id string) zgeo.Color {
-		i := list.IndexOfID(id)
+		i, _ := strconv.Atoi(id)
 		if items[i].IsDebug {
 			return zstyle.DebugBackgroundColor
 		}
 		return list.CellColor
 	}
+	visible := o.filterItems(items, "") // visible are the indexes in items shown, which are also their cell ids
+	var search *ztext.SearchField
+	searchMin := o.SearchMinItems
+	if searchMin == 0 {
+		searchMin = DefaultSearchMinItems
+	}
+	if searchMin > 0 && len(items) >= searchMin {
+		search = ztext.SearchFieldNew(ztext.Style{}, 12)
+		search.TextView.UpdateSecs = 0.1
+		filter := func() {
+			visible = o.filterItems(items, strings.ToLower(search.Text()))
+			list.CurrentHoverID = ""
+			list.RecreateCells = true // rows are made with their name highlighted by the filter
+			list.LayoutCells(true)
+			moveFilteredHover(list, items, visible, true)
+		}
+		search.SetValueHandler("zmenu.Filter", func(edited bool) {
+			filter()
+		})
+		search.TextView.SetKeyHandler(func(km zkeyboard.KeyMod, down bool) bool {
+			if !down || km.Modifier != zkeyboard.ModifierNone {
+				return false
+			}
+			switch {
+			case km.Key == zkeyboard.KeyUpArrow || km.Key == zkeyboard.KeyDownArrow:
+				moveFilteredHover(list, items, visible, km.Key == zkeyboard.KeyDownArrow)
+				return true
+			case km.Key.IsReturnish():
+				if list.CurrentHoverID != "" {
+					list.SelectCell(list.CurrentHoverID, true, false)
+				}
+				return true
+			case km.Key == zkeyboard.KeyEscape && search.Text() != "":
+				search.SetText("")
+				filter()
+				return true
+			}
+			return false
+		})
+		stack.Add(search, zgeo.TopLeft|zgeo.HorExpand, zgeo.SizeD(6, 2))
+	}
 	stack.Add(list, zgeo.TopLeft|zgeo.Expand)
 	lineHeight := o.Font.LineHeight() + 6
 	list.CellHeightFunc = func(id string) float64 {
@@ -590,10 +660,10 @@ func (o *MenuedOwner) popup() {
 		return lineHeight
 	}
 	list.CellCountFunc = func() int {
-		return len(items)
+		return len(visible)
 	}
 	list.IDAtIndexFunc = func(i int) string {
-		return strconv.Itoa(i)
+		return strconv.Itoa(visible[i])
 	}
 	list.UpdateCellSelectionFunc = o.updateCellSelection
 
@@ -623,6 +693,9 @@ func (o *MenuedOwner) popup() {
 	if o.hasShortcut {
 		w += shortcutWidth + gap
 	}
+	if hasChildren {
+		w += submenuArrowWidth + gap
+	}
 	w += 40 // test
 	zfloat.Maximize(&w, o.MinWidth)
 	list.SetMinSize(zgeo.SizeD(w, 0))
@@ -630,7 +703,40 @@ func (o *MenuedOwner) popup() {
 		list.ShowBar = false
 	}
 
+	list.HandleHoverOverFunc = func(id string) {
+		if id == "" {
+			return
+		}
+		i, _ := strconv.Atoi(id)
+		if len(items[i].Children) == 0 {
+			// A sibling hovered as long as it takes to open a submenu closes the open one; just passing over it on the way to the submenu doesn't.
+			if o.openSub != nil {
+				ztimer.StartIn(0.3, func() {
+					if list.CurrentHoverID == id && o.openSub != nil {
+						o.openSub.ClosePopup()
+					}
+				})
+			}
+			return
+		}
+		ztimer.StartIn(0.3, func() {
+			if list.CurrentHoverID == id {
+				o.openSubmenu(list, id, items[i])
+			}
+		})
+	}
 	list.HandleKeyFunc = func(km zkeyboard.KeyMod, down bool) bool {
+		if km.Key == zkeyboard.KeyRightArrow && list.CurrentHoverID != "" {
+			i, _ := strconv.Atoi(list.CurrentHoverID)
+			if len(items[i].Children) != 0 {
+				o.openSubmenu(list, list.CurrentHoverID, items[i])
+				return true
+			}
+		}
+		if km.Key == zkeyboard.KeyLeftArrow && o.parent != nil {
+			o.ClosePopup()
+			return true
+		}
 		if list.CurrentHoverID != "" && km.Key.IsReturnish() {
 			list.SelectCell(list.CurrentHoverID, true, false)
 			return true
@@ -661,7 +767,13 @@ func (o *MenuedOwner) popup() {
 				zpresent.Close(stack, false, nil)
 				return
 			}
+			if len(item.Children) != 0 {
+				list.UnselectAll(false)
+				o.openSubmenu(list, ids[0], item)
+				return
+			}
 			if item.IsAction {
+				o.closeParentPopups()
 				// items[i].Selected = false
 				if item.Function != nil {
 					zpresent.Close(stack, false, nil)
@@ -734,10 +846,18 @@ func (o *MenuedOwner) popup() {
 			return
 		}
 		ztimer.StartIn(0.1, func() {
+			if search != nil {
+				search.TextView.Focus(true)
+				return
+			}
 			list.Focus(true)
 		})
 	}
 	att.ClosedFunc = func(dismissed bool) {
+		o.currentPopupStack = nil
+		if o.parent != nil {
+			o.parent.openSub = nil
+		}
 		if !dismissed || o.IsMultiple { // if multiple, we handle any select/deselect done
 			o.UpdateTitleAndImage()
 			if o.ClosedFunc != nil {
@@ -754,6 +874,120 @@ func (o *MenuedOwner) popup() {
 	zpresent.PresentView(stack, att)
 }
 
+// filterItems returns the indexes of items whose names fuzzy-match filter, best first, or all if filter is empty.
+// It sets o.filterMatches to the runes matched in each name. Separators are left out while filtering.
+func (o *MenuedOwner) filterItems(items []MenuedOItem, filter string) []int {
+	var indexes []int
+	o.filterMatches = map[int][]zmath.Range[int]{}
+	scores := map[int]float64{}
+	for i, item := range items {
+		if filter == "" {
+			indexes = append(indexes, i)
+			continue
+		}
+		if item.IsSeparator {
+			continue
+		}
+		score, matched := zdocs.MatchFuzzy(filter, item.Name)
+		if score == 0 {
+			continue
+		}
+		scores[i] = score
+		o.filterMatches[i] = matched
+		indexes = append(indexes, i)
+	}
+	sort.SliceStable(indexes, func(a, b int) bool {
+		return scores[indexes[a]] > scores[indexes[b]]
+	})
+	return indexes
+}
+
+// moveFilteredHover moves the list's hover to the next or previous visible item that can be selected.
+func moveFilteredHover(list *zgridlist.GridListView, items []MenuedOItem, visible []int, down bool) {
+	pos := -1
+	if !down {
+		pos = len(visible)
+	}
+	for i, index := range visible {
+		if strconv.Itoa(index) == list.CurrentHoverID {
+			pos = i
+			break
+		}
+	}
+	for {
+		if down {
+			pos++
+		} else {
+			pos--
+		}
+		if pos < 0 || pos >= len(visible) {
+			return
+		}
+		item := items[visible[pos]]
+		if item.IsSeparator || item.IsDisabled {
+			continue
+		}
+		id := strconv.Itoa(visible[pos])
+		list.SetHoverID(id)
+		list.ScrollToCell(id, false)
+		return
+	}
+}
+
+// matchedRuns returns text as runs, with the runes in matched bold and underlined.
+func matchedRuns(text string, matched []zmath.Range[int], font *zgeo.Font) []ztextinfo.Run {
+	var runs []ztextinfo.Run
+	bold := *font
+	bold.Style = zgeo.FontStyleBold
+	runes := []rune(text)
+	last := 0
+	for _, m := range matched {
+		if m.Min > last {
+			runs = append(runs, ztextinfo.Run{Text: string(runes[last:m.Min])})
+		}
+		runs = append(runs, ztextinfo.Run{Text: string(runes[m.Min:m.Max]), Font: &bold, Decoration: ztextinfo.DecorationUnderlined})
+		last = m.Max
+	}
+	if last < len(runes) {
+		runs = append(runs, ztextinfo.Run{Text: string(runes[last:])})
+	}
+	return runs
+}
+
+// openSubmenu pops up item's children to the right of its row in list, closing any other submenu open.
+// Actions chosen in the submenu close it and its parents, and go to o.ActionHandlerFunc if they have no Function.
+func (o *MenuedOwner) openSubmenu(list *zgridlist.GridListView, id string, item MenuedOItem) {
+	if o.openSub != nil {
+		if o.openSubID == id {
+			return
+		}
+		o.openSub.ClosePopup()
+	}
+	cell := list.CellView(id)
+	if cell == nil {
+		return
+	}
+	r := cell.Native().AbsoluteRect()
+	sub := NewMenuedOwner()
+	sub.Name = o.Name + "." + item.Name
+	sub.Font = o.Font
+	sub.BGColor = o.BGColor
+	sub.TextColor = o.TextColor
+	sub.HoverColor = o.HoverColor
+	sub.ActionHandlerFunc = o.ActionHandlerFunc
+	sub.parent = o
+	o.openSub = sub
+	o.openSubID = id
+	sub.PopInPos(zgeo.PosD(r.Max().X, r.Pos.Y-topMarg), item.Children)
+}
+
+// closeParentPopups closes the popups of the menus this submenu was opened from.
+func (o *MenuedOwner) closeParentPopups() {
+	for p := o.parent; p != nil; p = p.parent {
+		p.ClosePopup()
+	}
+}
+
 func (o *MenuedOwner) ClosePopup() {
 	if o.currentPopupStack != nil {
 		zpresent.Close(o.currentPopupStack, false, nil)
@@ -765,6 +999,12 @@ func (o *MenuedOwner) HandleShortcut(sc zkeyboard.KeyMod, inFocus bool) bool {
 		if item.IsDisabled {
 			continue
 		}
+		if len(item.Children) != 0 {
+			if o.handleChildShortcut(item.Children, sc) {
+				return true
+			}
+			continue
+		}
 		if item.Shortcut.Matches(sc) {
 			if o.CreateItemsFunc != nil {
 				// o.items = o.CreateItemsFunc() // we need to re-generate menu items -- done in getItems above
@@ -783,6 +1023,33 @@ func (o *MenuedOwner) HandleShortcut(sc zkeyboard.KeyMod, inFocus bool) bool {
 	return false
 }
 
+// handleChildShortcut performs the action in a submenu's items, or their submenus, with shortcut sc.
+func (o *MenuedOwner) handleChildShortcut(items []MenuedOItem, sc zkeyboard.KeyMod) bool {
+	for _, item := range items {
+		if item.IsDisabled {
+			continue
+		}
+		if len(item.Children) != 0 {
+			if o.handleChildShortcut(item.Children, sc) {
+				return true
+			}
+			continue
+		}
+		if !item.Shortcut.Matches(sc) {
+			continue
+		}
+		if item.Function != nil {
+			go item.Function()
+			return true
+		}
+		zlog.Assert(o.ActionHandlerFunc != nil)
+		id := item.Value.(string)
+		o.ActionHandlerFunc(id)
+		return true
+	}
+	return false
+}
+
 func (o *MenuedOwner) updateCellSelection(grid *zgridlist.GridListView, id string) {
 	// zlog.Info("updateCellSelection:", id)
 	i, _ := strconv.Atoi(id)
@@ -846,6 +1113,9 @@ func (o *MenuedOwner) createRow(grid *zgridlist.GridListView, id string) zview.V
 		font.Style = zgeo.FontStyleItalic
 	}
 	title.SetFont(&font)
+	if matched := o.filterMatches[i]; len(matched) != 0 {
+		title.SetRuns(matchedRuns(item.Name, matched, &font))
+	}
 	v.Add(title, zgeo.CenterLeft, marg)
 
 	marg.W = 8
@@ -874,11 +1144,18 @@ func (o *MenuedOwner) createRow(grid *zgridlist.GridListView, id string) zview.V
 		}
 		marg.W += gap + colorWidth
 	}
+	if len(item.Children) != 0 {
+		arrow := zlabel.New("▸")
+		arrow.SetObjectName("submenu-arrow")
+		arrow.SetFont(o.Font)
+		v.Add(arrow, zgeo.CenterRight, marg)
+		marg.W += gap + submenuArrowWidth
+	}
 	if o.hasShortcut {
 		singleLetterKey := false
 		str := item.Shortcut.AsString(singleLetterKey)
 		keyLabel := zlabel.New(str)
-		title.SetObjectName("shortcut")
+		keyLabel.SetObjectName("shortcut")
 		font := o.Font
 		font.Style = zgeo.FontStyleBold
 		keyLabel.SetFont(font)
@@ -934,14 +1211,21 @@ func (o *MenuedOwner) Dump() {
 }
 
 func (o *MenuedOwner) GetSearchableItems(currentPath []zdocs.PathPart) []zdocs.SearchableItem {
+	return searchableItems(currentPath, o.getItems())
+}
+
+func searchableItems(currentPath []zdocs.PathPart, items []MenuedOItem) []zdocs.SearchableItem {
 	var parts []zdocs.SearchableItem
-	items := o.getItems()
 	for _, ditem := range items {
 		if ditem.IsDebug && !zui.DebugOwnerMode || ditem.IsSeparator {
 			continue
 		}
 		key := zdocs.MakeSearchableItem(currentPath, zdocs.StaticField, "", "", ditem.Name)
 		parts = append(parts, key)
+		if len(ditem.Children) != 0 {
+			subPath := zdocs.AddedPath(currentPath, zdocs.StaticField, ditem.Name, ditem.Name)
+			parts = append(parts, searchableItems(subPath, ditem.Children)...)
+		}
 		if ditem.SearchableSubView != nil {
 			view := ditem.SearchableSubView.(zview.View)
 			r, _ := view.(zview.ReadyToShowType)
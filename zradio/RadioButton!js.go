@@ -0,0 +1,74 @@
+//go:build !js && zui
+
+package zradio
+
+import (
+	"github.com/torlangballe/zui/zview"
+	"github.com/torlangballe/zutil/zkeyvalue"
+)
+
+func NewWithStore(defaultVal bool, id, group, storeKey string) *RadioButton {
+	val := defaultVal
+	if storeKey != "" && zkeyvalue.DefaultStore != nil {
+		v, got := zkeyvalue.DefaultStore.GetBool(storeKey, defaultVal)
+		if got {
+			val = v
+		}
+	}
+	v := NewButton(val, id, group)
+	v.storeKey = storeKey
+	return v
+}
+
+// NewButton makes a headless radio button. Pressing it turns it on and the others in group off, as in a browser.
+func NewButton(on bool, id, group string) *RadioButton {
+	v := &RadioButton{}
+	v.MakeHeadless(v, "radio")
+	v.SetObjectName(id)
+	v.JSSet("value", id)
+	v.JSSet("name", group)
+	v.SetCanTabFocus(false)
+	v.SetValue(on)
+	v.NativeView.SetPressedHandler("$select", 0, func() {
+		v.selectInGroup()
+		if v.storeKey != "" && zkeyvalue.DefaultStore != nil {
+			zkeyvalue.DefaultStore.SetBool(v.Value(), v.storeKey, true)
+		}
+		v.changed.CallAll(true)
+	})
+	return v
+}
+
+// selectInGroup turns v on, and other radio buttons with the same group under the same root off.
+func (v *RadioButton) selectInGroup() {
+	root := &v.NativeView
+	for root.Parent() != nil {
+		root = root.Parent()
+	}
+	group := v.JSGet("name")
+	var turnOff func(n *zview.NativeView)
+	turnOff = func(n *zview.NativeView) {
+		for _, c := range n.NativeChildren() {
+			r, _ := c.View.(*RadioButton)
+			if r != nil && r != v && r.JSGet("name") == group {
+				r.SetValue(false)
+			}
+			turnOff(c)
+		}
+	}
+	turnOff(root)
+	v.SetValue(true)
+}
+
+func (v *RadioButton) SetValueHandler(id string, handler func(edited bool)) {
+	v.changed.Add(id, handler)
+}
+
+func (v *RadioButton) Value() bool {
+	b, _ := v.JSGet("checked").(bool)
+	return b
+}
+
+func (v *RadioButton) SetValue(b bool) {
+	v.JSSet("checked", b)
+}
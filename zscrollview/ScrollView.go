@@ -158,6 +158,10 @@ func (v *ScrollView) ScrollPage(up bool, animate bool) {
 	v.SetContentOffset(y, animate)
 }
 
+func (v *ScrollView) OffsetAtBottom() float64 {
+	return v.child.Rect().Size.H - v.Rect().Size.H
+}
+
 func (v *ScrollView) SetScrollHandler(handler func(pos zgeo.Pos, infiniteDir int, delta float64)) {
 	v.ScrollHandler = handler
 }
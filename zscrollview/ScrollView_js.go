@@ -38,10 +38,6 @@ func (v *ScrollView) Init(view zview.View, name string) {
 	})
 }
 
-func (v *ScrollView) OffsetAtBottom() float64 {
-	return v.child.Rect().Size.H - v.Rect().Size.H
-}
-
 func (v *ScrollView) ReadyToShow(beforeWindow bool) {
 	v.CustomView.ReadyToShow(beforeWindow)
 	rs, has := v.child.(zview.ReadyToShowType)
@@ -5,14 +5,20 @@ package zslicegrid
 import (
 	"fmt"
 	"reflect"
-	"strings"
+	"strconv"
+	"time"
 
 	"github.com/torlangballe/zui/zalert"
+	"github.com/torlangballe/zui/zcontainer"
 	"github.com/torlangballe/zui/zfields"
 	"github.com/torlangballe/zui/zkeyboard"
+	"github.com/torlangballe/zui/zlabel"
 	"github.com/torlangballe/zui/zmenu"
 	"github.com/torlangballe/zui/zpresent"
+	"github.com/torlangballe/zui/zsqltable"
 	"github.com/torlangballe/zui/zview"
+	"github.com/torlangballe/zutil/zdict"
+	"github.com/torlangballe/zutil/zgeo"
 	"github.com/torlangballe/zutil/zlog"
 	"github.com/torlangballe/zutil/zrpc"
 	"github.com/torlangballe/zutil/zsql"
@@ -23,17 +29,23 @@ import (
 type SQLOwner[S zstr.StrIDer] struct {
 	Grid *SQLTableView[S]
 	// slice         *[]S // we need to store slice when grid is nil
-	TableName     string
-	rpcCallerName string
-	DeleteQuery   string
-	IsSqlite      bool
-	IsQuoteIDs    bool
-	Constraints   string
-	limit         int
-	offset        int
-	slicePage     *[]S
-	searchFields  []string
-	HandleGot     func()
+	TableName      string
+	rpcCallerName  string
+	DeleteQuery    string
+	IsSqlite       bool
+	IsQuoteIDs     bool           // Deprecated: ids are sent to zsqltable.Service.DeleteRows as SQL arguments, so they are never quoted.
+	Constraints    string         // Deprecated: raw SQL isn't sent to the server anymore. Nothing is got if it is set, use Equals and SetSearchFields.
+	Equals         map[string]any // Equals are field names and values rows must have, sent in the zsqltable.Query to Select.
+	InfiniteScroll bool           // InfiniteScroll fetches the next page and appends it when the grid is scrolled to near its bottom, instead of showing one page at a time.
+	limit          int
+	offset         int
+	total          int64 // total is the number of rows matching the query on the server, ignoring paging
+	fetching       bool
+	liveSerial     int64 // liveSerial is the serial of the last change got with live updates, or -1 if they are stopped
+	liveGeneration int   // liveGeneration is increased on each start/stop of live updates, so an old poller knows to quit
+	slicePage      *[]S
+	searchFields   []string
+	HandleGot      func()
 }
 
 type SQLTableView[S zstr.StrIDer] struct {
@@ -42,10 +54,17 @@ type SQLTableView[S zstr.StrIDer] struct {
 	searchString string
 	// selectMethod  string
 	// skipFields   []string
-	showID int64
+	showID    int64
+	pager     *zcontainer.StackView
+	rowsLabel *zlabel.Label
+	pageMenu  *zmenu.MenuView
 }
 
+// How near the bottom of the grid, in pixels, scrolling must get to fetch the next page with InfiniteScroll.
+const infiniteScrollMargin = 200
+
 func (o *SQLOwner[S]) Init(slice *[]S, tableName, rpcCallerName string, limit int) {
+	o.liveSerial = -1
 	o.slicePage = slice
 	o.TableName = tableName
 	o.rpcCallerName = rpcCallerName
@@ -74,9 +93,82 @@ func (v *SQLTableView[S]) Init(view zview.View, owner *SQLOwner[S], options Opti
 	v.TableView.Init(v, v.Owner.slicePage, "ztable."+v.Owner.TableName, options)
 	v.StoreChangedItemsFunc = v.Owner.PushRowsToServer
 	v.DeleteItemsFunc = v.deleteItems
+	v.RestoreItemsFunc = func(items []S) {
+		v.Owner.InsertRows(items)
+		v.Owner.GetAndUpdate()
+	}
 	if v.Options&AddHeader != 0 {
 		v.addActionButton()
 	}
+	if v.Bar != nil {
+		v.addPager()
+	}
+	scrolled := v.Grid.ScrollHandler
+	v.Grid.SetScrollHandler(func(pos zgeo.Pos, infinityDir int, delta float64) {
+		if scrolled != nil {
+			scrolled(pos, infinityDir, delta)
+		}
+		if v.Owner.InfiniteScroll && delta > 0 && (infinityDir == 1 || pos.Y > v.Grid.OffsetAtBottom()-infiniteScrollMargin) {
+			go v.Owner.AppendNextPage()
+		}
+	})
+}
+
+// addPager adds "rows X–Y of N", previous/next buttons and a menu to jump to a page to the bar.
+// The buttons and menu are collapsed if there is only one page, or with InfiniteScroll.
+func (v *SQLTableView[S]) addPager() {
+	v.pager = zcontainer.StackViewHor("pager")
+	v.pager.SetSpacing(4)
+	v.Bar.Add(v.pager, zgeo.CenterLeft)
+
+	prev := zlabel.New("◀")
+	prev.SetObjectName("previous-page")
+	prev.SetToolTip("Go to previous page")
+	prev.KeyboardShortcut.KeyMod = zkeyboard.KMod(zkeyboard.KeyLeftArrow, zkeyboard.ModifierShift)
+	prev.SetPressedHandler("", zkeyboard.ModifierNone, func() {
+		go v.Owner.PrevPage()
+	})
+	v.pager.Add(prev, zgeo.CenterLeft)
+
+	v.pageMenu = zmenu.NewView("page", nil, 0)
+	v.pageMenu.SetToolTip("Jump to page")
+	v.pageMenu.SetSelectedHandler(func(edited bool) {
+		if edited {
+			page, _ := v.pageMenu.CurrentValue().(int)
+			go v.Owner.GoToPage(page)
+		}
+	})
+	v.pager.Add(v.pageMenu, zgeo.CenterLeft)
+
+	next := zlabel.New("▶")
+	next.SetObjectName("next-page")
+	next.SetToolTip("Go to next page")
+	next.KeyboardShortcut.KeyMod = zkeyboard.KMod(zkeyboard.KeyRightArrow, zkeyboard.ModifierShift)
+	next.SetPressedHandler("", zkeyboard.ModifierNone, func() {
+		go v.Owner.NextPage()
+	})
+	v.pager.Add(next, zgeo.CenterLeft)
+
+	v.rowsLabel = zlabel.New("")
+	v.rowsLabel.SetObjectName("rows")
+	v.rowsLabel.SetMinWidth(120)
+	v.Bar.Add(v.rowsLabel, zgeo.CenterLeft)
+}
+
+// updatePager shows the current rows and page in the bar's pager.
+func (v *SQLTableView[S]) updatePager() {
+	if v.pager == nil {
+		return
+	}
+	o := v.Owner
+	v.rowsLabel.SetText(o.RowsText())
+	count := o.PageCount()
+	var items zdict.Items
+	for i := 0; i < count; i++ {
+		items = append(items, zdict.Item{Name: "Page " + strconv.Itoa(i+1), Value: i})
+	}
+	v.pageMenu.UpdateItems(items, o.CurrentPage(), false)
+	v.Bar.CollapseChild(v.pager, o.InfiniteScroll || count <= 1, true)
 }
 
 func (v *SQLTableView[S]) addActionButton() {
@@ -158,15 +250,8 @@ func (o *SQLOwner[S]) InsertRows(slice any) {
 
 func (v *SQLTableView[S]) deleteItems(ids []string) {
 	var affected int64
-	if v.Owner.IsQuoteIDs {
-		for i := range ids {
-			ids[i] = zsql.QuoteString(ids[i])
-		}
-	}
 	zrpc.MainClient.Call(v.Owner.rpcCallerName+".PreDeleteRows", ids, nil)
-
-	query := "DELETE FROM " + v.Owner.TableName + " WHERE id IN (" + strings.Join(ids, ",") + ")"
-	err := zrpc.MainClient.Call("SQLCalls.ExecuteQuery", query, &affected)
+	err := zrpc.MainClient.Call(v.Owner.rpcCallerName+".DeleteRows", ids, &affected)
 	if err != nil {
 		zalert.ShowError(err, "updating")
 	}
@@ -174,43 +259,44 @@ func (v *SQLTableView[S]) deleteItems(ids []string) {
 	v.UpdateViewFunc(true, false)
 }
 
-func (o *SQLOwner[S]) createConstraints() string {
-	var order string
-	// zlog.Info("createConstraints", v.Header != nil, v.Header.SortOrder)
+// SetSearchFields sets the names of the fields the table's search text is searched for in, on the server.
+func (o *SQLOwner[S]) SetSearchFields(fieldNames ...string) {
+	o.searchFields = fieldNames
+}
+
+// createQuery makes a query for the current search text, sorting and page.
+// The server turns it into parameterized SQL with zsqltable.Builder, so it works with both Postgres and SQLite.
+// It returns an error if the deprecated Constraints is set, as getting rows without its filter could show rows it was meant to hide.
+func (o *SQLOwner[S]) createQuery() (zsqltable.Query, error) {
+	if o.Constraints != "" {
+		return zsqltable.Query{}, zlog.Error("SQLOwner.Constraints isn't supported anymore, use Equals and SetSearchFields:", o.TableName, o.Constraints)
+	}
+	q := zsqltable.Query{
+		Table:  o.TableName,
+		Equals: o.Equals,
+		Limit:  o.limit,
+		Offset: o.offset,
+	}
+	if o.InfiniteScroll && o.limit > 0 {
+		// We get all pages appended so far again, so a refresh doesn't drop rows scrolled to.
+		q.Offset = 0
+		q.Limit = max(o.limit, len(*o.slicePage))
+	}
 	if o.Grid != nil && o.Grid.Header != nil {
 		var s S
 		fieldColMap, primary := zsql.FieldNamesToColumnFromStruct(s, nil, "")
-		var orders []string
 		for _, s := range o.Grid.Header.SortOrder {
-			column := fieldColMap[s.FieldName] + " "
-			if column == primary {
+			if fieldColMap[s.FieldName] == primary {
 				continue
 			}
-			o := column + " "
-			if s.SmallFirst {
-				o += "ASC"
-			} else {
-				o += "DESC"
-			}
-			orders = append(orders, o)
-		}
-		order = strings.Join(orders, ",")
-	}
-	cons := o.Constraints
-	if o.Grid != nil && cons == "" && o.Grid.searchString != "" && len(o.searchFields) > 0 {
-		var wheres []string
-		for _, s := range o.searchFields {
-			w := s + `ILIKE '%` + zsql.SanitizeString(o.Grid.searchString) + `%'`
-			wheres = append(wheres, w)
+			q.Sort = append(q.Sort, zsqltable.SortColumn{FieldName: s.FieldName, SmallFirst: s.SmallFirst})
 		}
-		cons = "WHERE (" + strings.Join(wheres, " OR ") + ")"
 	}
-	if order != "" {
-		cons += " ORDER BY " + order
+	if o.Grid != nil && o.Grid.searchString != "" {
+		q.SearchText = o.Grid.searchString
+		q.SearchFields = o.searchFields
 	}
-	cons += fmt.Sprintf(" LIMIT %d OFFSET %d", o.limit, o.offset)
-	// zlog.Info("createConstraints:", cons)
-	return cons
+	return q, nil
 }
 
 // func (v *SQLTableView[S]) SetConstraints(constraints string) {
@@ -225,18 +311,25 @@ func (o *SQLOwner[S]) UpdateRows(rows []S) {
 }
 
 func (o *SQLOwner[S]) GetAndUpdate() {
-	var slice []S
-	var q zsql.QueryBase
+	var page zsqltable.Page[S]
 
-	q.Table = o.TableName
-	q.Constraints = o.createConstraints()
-	err := zrpc.MainClient.Call(o.rpcCallerName+".Select", q, &slice)
+	q, err := o.createQuery()
 	if err != nil {
-		zlog.Error("select", q.Constraints, o.limit, o.offset, err)
 		return
 	}
+	err = zrpc.MainClient.Call(o.rpcCallerName+".SelectPage", q, &page)
+	if err != nil {
+		zlog.Error("select", q.Table, q.SearchText, o.limit, o.offset, err)
+		return
+	}
+	o.total = page.Total
+	o.setSlice(page.Rows)
+}
+
+func (o *SQLOwner[S]) setSlice(slice []S) {
 	if o.Grid != nil {
 		o.Grid.UpdateSlice(slice, true)
+		o.Grid.updatePager()
 	} else {
 		*o.slicePage = slice
 	}
@@ -245,6 +338,168 @@ func (o *SQLOwner[S]) GetAndUpdate() {
 	}
 }
 
+// Total is the number of rows matching the table's search and equals on the server, as of the last get.
+func (o *SQLOwner[S]) Total() int64 {
+	return o.total
+}
+
+// PageCount is how many pages of limit rows the total rows are, at least 1.
+func (o *SQLOwner[S]) PageCount() int {
+	if o.limit <= 0 || o.total == 0 {
+		return 1
+	}
+	return int((o.total + int64(o.limit) - 1) / int64(o.limit))
+}
+
+// CurrentPage is the index of the page shown, starting at 0.
+func (o *SQLOwner[S]) CurrentPage() int {
+	if o.limit <= 0 {
+		return 0
+	}
+	return o.offset / o.limit
+}
+
+// RowsText returns "rows X–Y of N" for the rows shown, or "no rows".
+func (o *SQLOwner[S]) RowsText() string {
+	n := len(*o.slicePage)
+	if n == 0 {
+		return "no rows"
+	}
+	first := o.offset + 1
+	if o.InfiniteScroll {
+		first = 1
+	}
+	return fmt.Sprintf("rows %d–%d of %d", first, first+n-1, o.total)
+}
+
+// GoToPage gets the rows of page, which is clamped to the pages there are, and shows them.
+func (o *SQLOwner[S]) GoToPage(page int) {
+	if o.limit <= 0 {
+		return
+	}
+	page = max(0, min(page, o.PageCount()-1))
+	o.offset = page * o.limit
+	o.GetAndUpdate()
+	if o.Grid != nil {
+		o.Grid.Grid.SetContentOffset(0, false)
+	}
+}
+
+func (o *SQLOwner[S]) NextPage() {
+	o.GoToPage(o.CurrentPage() + 1)
+}
+
+func (o *SQLOwner[S]) PrevPage() {
+	o.GoToPage(o.CurrentPage() - 1)
+}
+
+// AppendNextPage gets the page after the rows already in the slice, and appends it.
+// It is called when scrolling near the bottom with InfiniteScroll, and does nothing if all rows are got or it is already fetching.
+func (o *SQLOwner[S]) AppendNextPage() {
+	got := len(*o.slicePage)
+	if o.fetching || o.limit <= 0 || int64(got) >= o.total {
+		return
+	}
+	o.fetching = true
+	defer func() {
+		o.fetching = false
+	}()
+	var page zsqltable.Page[S]
+	q, err := o.createQuery()
+	if err != nil {
+		return
+	}
+	q.Offset = got
+	q.Limit = o.limit
+	err = zrpc.MainClient.Call(o.rpcCallerName+".SelectPage", q, &page)
+	if err != nil {
+		zlog.Error("select next page", q.Table, q.Offset, err)
+		return
+	}
+	o.total = page.Total
+	slice := append(append([]S{}, (*o.slicePage)...), page.Rows...)
+	o.setSlice(slice)
+}
+
+// StartLiveUpdates long-polls the server's WaitForChanges (see zsqltable.Service) in a goroutine,
+// merging rows updated or deleted by others into the grid, so all operators see the same table.
+// If the server no longer has the changes since the last poll, or rows not in the grid were changed,
+// the rows are got again with the grid's query, so only rows it is filtered and paged to are shown.
+func (o *SQLOwner[S]) StartLiveUpdates() {
+	if o.liveSerial != -1 {
+		return
+	}
+	o.liveSerial = 0
+	o.liveGeneration++
+	generation := o.liveGeneration
+	go func() {
+		for generation == o.liveGeneration {
+			var changes zsqltable.RowChanges[S]
+			err := zrpc.MainClient.Call(o.rpcCallerName+".WaitForChanges", o.liveSerial, &changes)
+			if generation != o.liveGeneration {
+				break
+			}
+			if err != nil {
+				zlog.Error("wait for changes", o.TableName, err)
+				time.Sleep(5 * time.Second)
+				continue
+			}
+			first := (o.liveSerial == 0)
+			o.liveSerial = changes.Serial
+			if changes.Reset {
+				o.GetAndUpdate()
+				continue
+			}
+			if first || len(changes.Rows)+len(changes.DeletedIDs) == 0 {
+				continue
+			}
+			o.mergeChanges(changes)
+		}
+	}()
+}
+
+// StopLiveUpdates stops live updates. The poller quits when its current long-poll returns,
+// without merging what it got, even if StartLiveUpdates is called again before that.
+func (o *SQLOwner[S]) StopLiveUpdates() {
+	o.liveSerial = -1
+	o.liveGeneration++
+}
+
+func (o *SQLOwner[S]) mergeChanges(changes zsqltable.RowChanges[S]) {
+	if o.Grid == nil {
+		known, unknown := rowsInSlice(changes.Rows, *o.slicePage)
+		if unknown != 0 {
+			o.GetAndUpdate()
+			return
+		}
+		UpdateRows(known, nil, o.slicePage)
+		var kept []S
+		for _, s := range *o.slicePage {
+			if !zstr.StringsContain(changes.DeletedIDs, s.GetStrID()) {
+				kept = append(kept, s)
+			}
+		}
+		*o.slicePage = kept
+		return
+	}
+	if o.Grid.MergeChangedRows(changes.Rows, changes.DeletedIDs) != 0 {
+		o.GetAndUpdate()
+		return
+	}
+	q, err := o.createQuery()
+	if err != nil {
+		return
+	}
+	err = zrpc.MainClient.Call(o.rpcCallerName+".Count", q, &o.total) // deleted rows change the total, and only it is needed
+	if err != nil {
+		zlog.Error("count", q.Table, q.SearchText, err)
+	}
+	o.Grid.updatePager()
+	if o.HandleGot != nil {
+		o.HandleGot()
+	}
+}
+
 func (o *SQLOwner[S]) PushRowsToServer(items []S) {
 	// zlog.Info("UpdateItems:", o.TableName, zlog.Full(items))
 	// v.SetItemsInSlice(items)
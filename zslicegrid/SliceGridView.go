@@ -27,6 +27,7 @@ import (
 	"github.com/torlangballe/zui/zshortcuts"
 	"github.com/torlangballe/zui/zstyle"
 	"github.com/torlangballe/zui/ztext"
+	"github.com/torlangballe/zui/zundo"
 	"github.com/torlangballe/zui/zview"
 	"github.com/torlangballe/zui/zwidgets"
 	"github.com/torlangballe/zui/zwindow"
@@ -70,6 +71,7 @@ type SliceGridView[S any] struct {
 	StoreChangedItemsFunc           func(items []S)                                        // StoreChangedItemsFunc is called with ids of all cells that have been edited. It must set the items in slicePtr, can use SetItemsInSlice. It ends by calling UpdateViewFunc(). Might call go-routine to push to backend.
 	StoreChangedItemFunc            func(item S, last bool) error                          // StoreChangedItemFunc is called by the default StoreChangedItemsFunc with index of item in slicePtr, each in a goroutine which can clear showError to not show more than one error. The items are set in the slicePtr afterwards. last is true if it's the last one in items.
 	DeleteItemsFunc                 func(ids []string)                                     // DeleteItemsFunc is called with ids of all selected cells to be deleted. It must remove them from slicePtr.
+	RestoreItemsFunc                func(items []S)                                        // RestoreItemsFunc puts back deleted items when their delete is undone. If nil, they are stored with StoreChangedItemsFunc and put back in the slice.
 	ValidateClipboardPasteItemsFunc func(items *[]S, proceed func())                       // Called on incoming items paste items to zero ID's or something, and validate. Call proceed if any left or user accepts or something
 	HandleShortCutInRowFunc         func(rowID string, sc zkeyboard.KeyMod) bool           // Called if key pressed when row selected, and row-cell  or action menu doesn't handle it
 	CallDeleteItemFunc              func(id string, showErr *bool, last bool) error        // CallDeleteItemFunc is called from default DeleteItemsFunc, with id of each item. They are not removed from slice.
@@ -80,6 +82,7 @@ type SliceGridView[S any] struct {
 	EditDialogDocumentationPath     string
 	FilterSkipCache                 map[string]bool
 	Options                         OptionType
+	UndoManager                     *zundo.Manager // UndoManager records edits, deletes and pastes, so they can be undone with zkeyboard.UndoKeyMod and redone. It is made with the AllowUndo option, or can be set to share one.
 
 	slicePtr      *[]S
 	filteredSlice []S
@@ -119,6 +122,7 @@ const (
 	RowsGUISearchable                           // Allows rows to be part of gui search
 	AddNameAsSearchItem                         // If true, this table adds ObjectName() to currentPath for searching
 	AddDetachedBar                              // Adds a detached bar to be placed elsewhere. Sets AddBar.
+	AllowUndo                                   // Records edits, deletes and pastes in UndoManager, so they can be undone and redone with menu or keyboard.
 	LastBaseOption
 	AllowAllEditing = AllowEdit | AllowNew | AllowDelete | AllowDuplicate
 )
@@ -266,6 +270,9 @@ func (v *SliceGridView[S]) Init(view zview.View, slice *[]S, storeName string, o
 		// 		return true
 		// 	}
 		// }
+		if v.handleUndoShortcut(km) {
+			return true
+		}
 		if v.ActionMenu != nil {
 			// focused := isInFocus
 			// if !focused {
@@ -385,6 +392,9 @@ func (v *SliceGridView[S]) Init(view zview.View, slice *[]S, storeName string, o
 		// zlog.Info("StoreChangedItemsFunc done")
 	}
 
+	if options&AllowUndo != 0 {
+		v.UndoManager = zundo.NewManager()
+	}
 	v.DeleteItemsFunc = func(ids []string) {
 		var deleteIDs []string
 		if v.CallDeleteItemFunc == nil {
@@ -479,11 +489,11 @@ func (v *SliceGridView[S]) ClearFilterSkipCache() {
 
 func (v *SliceGridView[S]) insertItemsIntoASlice(items []S, slicePtr *[]S) int {
 	var added int
-	found := false
 	if v != nil {
 		v.Grid.DirtyIDs = map[string]bool{}
 	}
 	for _, item := range items {
+		found := false
 		isid := GetIDForItem(&item)
 		if v != nil {
 			delete(v.FilterSkipCache, isid)
@@ -527,6 +537,52 @@ func (v *SliceGridView[S]) RemoveItemsFromSlice(ids []string) {
 	v.doFilterAndSort(*v.slicePtr)
 }
 
+// MergeChangedRows sets changed rows that are already in the slice by their StrID, and removes rows with deletedIDs.
+// It is for rows changed elsewhere, like pushed from a server, so the selection and scroll position are kept;
+// only deleted rows are deselected.
+// Changed rows not in the slice are not added, as they might be outside what the slice is filtered/paged to;
+// unknown is how many there were, so the caller can get the slice again if needed.
+func (v *SliceGridView[S]) MergeChangedRows(changed []S, deletedIDs []string) (unknown int) {
+	if len(changed) != 0 {
+		var known []S
+		known, unknown = rowsInSlice(changed, *v.slicePtr)
+		if len(known) != 0 {
+			v.insertItemsIntoASlice(known, v.slicePtr)
+		}
+	}
+	if len(deletedIDs) != 0 {
+		selected := v.Grid.SelectedIDs()
+		var kept []string
+		for _, sid := range selected {
+			if !zstr.StringsContain(deletedIDs, sid) {
+				kept = append(kept, sid)
+			}
+		}
+		if len(kept) != len(selected) {
+			v.Grid.SelectCells(kept, false, false)
+		}
+		v.RemoveItemsFromSlice(deletedIDs)
+	}
+	v.UpdateViewFunc(true, false)
+	return unknown
+}
+
+// rowsInSlice returns the rows that have the StrID of a row in slice, and how many didn't.
+func rowsInSlice[S any](rows, slice []S) (known []S, unknown int) {
+	ids := map[string]bool{}
+	for i := range slice {
+		ids[GetIDForItem(&slice[i])] = true
+	}
+	for _, r := range rows {
+		if ids[GetIDForItem(&r)] {
+			known = append(known, r)
+		} else {
+			unknown++
+		}
+	}
+	return known, unknown
+}
+
 func (v *SliceGridView[S]) getIDForIndex(slice *[]S, index int, count *int) string {
 	for i, s := range *slice {
 		// if index != -1 {
@@ -773,6 +829,9 @@ func (v *SliceGridView[S]) editOrViewItems(ns []S, isReadOnly bool, title string
 			return true
 		}
 		if v.StoreChangedItemsFunc != nil { // if we do this before setting the slice below, StoreChangedItemsFunc func can compare with original items
+			if !isReadOnly {
+				v.recordUndo(strings.TrimRight(title, ":…"), ns)
+			}
 			v.StoreChangedItemsFunc(ns)
 		}
 		if selectAfterEditing {
@@ -820,7 +879,7 @@ func (v *SliceGridView[S]) HandleDeleteKey(ask bool, overrideIDs []string) {
 	if ask {
 		v.DeleteItemsAsk(ids)
 	} else {
-		go v.DeleteItemsFunc(ids)
+		go v.deleteItemsWithUndo(ids)
 	}
 }
 
@@ -833,10 +892,96 @@ func (v *SliceGridView[S]) DeleteItemsAsk(ids []string) {
 		alert.SubText = sub
 	}
 	alert.ShowOK(func() {
-		go v.DeleteItemsFunc(ids)
+		go v.deleteItemsWithUndo(ids)
 	})
 }
 
+// deleteItemsWithUndo records the items with ids for undo before deleting them with DeleteItemsFunc.
+func (v *SliceGridView[S]) deleteItemsWithUndo(ids []string) {
+	if v.UndoManager != nil {
+		items := v.getItemsFromIDs(ids)
+		v.UndoManager.Add(zundo.ItemsAction("Delete "+v.NameOfXItemsFunc(ids, true), zundo.ItemChanges(items, nil, GetIDForItem[S]), v.StoreChangedItemsFunc, v.restoreItems, v.DeleteItemsFunc))
+	}
+	v.DeleteItemsFunc(ids)
+}
+
+// recordUndo records items changing from what they are in the slice to changed, for UndoManager.
+// Items not in the slice are new, so undoing deletes them.
+func (v *SliceGridView[S]) recordUndo(name string, changed []S) {
+	if v.UndoManager == nil {
+		return
+	}
+	before := v.getItemsFromIDs(itemIDs(changed))
+	v.addUndo(name, zundo.ItemChanges(before, changed, GetIDForItem[S]))
+}
+
+func (v *SliceGridView[S]) addUndo(name string, changes []zundo.ItemChange[S]) {
+	v.UndoManager.Add(zundo.ItemsAction(name, changes, v.StoreChangedItemsFunc, v.restoreItems, v.DeleteItemsFunc))
+}
+
+// restoreItems puts back items whose delete is undone, with RestoreItemsFunc if set.
+// Otherwise they are stored with StoreChangedItemsFunc, and put in the slice, as the default
+// StoreChangedItemsFunc does nothing if there is no StoreChangedItemFunc.
+func (v *SliceGridView[S]) restoreItems(items []S) {
+	if v.RestoreItemsFunc != nil {
+		v.RestoreItemsFunc(items)
+		return
+	}
+	v.StoreChangedItemsFunc(items)
+	v.SetItemsInSlice(items)
+	v.UpdateViewFunc(true, false)
+}
+
+func itemIDs[S any](items []S) []string {
+	var ids []string
+	for i := range items {
+		ids = append(ids, GetIDForItem(&items[i]))
+	}
+	return ids
+}
+
+func (v *SliceGridView[S]) handleUndoShortcut(km zkeyboard.KeyMod) bool {
+	if v.UndoManager == nil {
+		return false
+	}
+	if km.Matches(zkeyboard.UndoKeyMod) {
+		if _, can := v.UndoManager.UndoName(); can {
+			go v.UndoManager.Undo()
+			return true
+		}
+	}
+	if km.Matches(zkeyboard.RedoKeyMod) {
+		if _, can := v.UndoManager.RedoName(); can {
+			go v.UndoManager.Redo()
+			return true
+		}
+	}
+	return false
+}
+
+// undoMenuItems returns actions to undo and redo with UndoManager, if there is anything to undo or redo.
+func (v *SliceGridView[S]) undoMenuItems() []zmenu.MenuedOItem {
+	var items []zmenu.MenuedOItem
+	if v.UndoManager == nil {
+		return nil
+	}
+	if name, can := v.UndoManager.UndoName(); can || zdocs.IsGettingSearchItems {
+		undo := zmenu.MenuedFuncAction(zstr.Concat(" ", "Undo", name), func() {
+			go v.UndoManager.Undo()
+		})
+		undo.Shortcut = zkeyboard.UndoKeyMod
+		items = append(items, undo)
+	}
+	if name, can := v.UndoManager.RedoName(); can || zdocs.IsGettingSearchItems {
+		redo := zmenu.MenuedFuncAction(zstr.Concat(" ", "Redo", name), func() {
+			go v.UndoManager.Redo()
+		})
+		redo.Shortcut = zkeyboard.RedoKeyMod
+		items = append(items, redo)
+	}
+	return items
+}
+
 func (v *SliceGridView[S]) getHierarchy(slice *[]S, level int, id string) (hlevel int, leaf, got bool) {
 	for i, s := range *slice {
 		children := v.getChildren(&v.filteredSlice, i)
@@ -970,6 +1115,12 @@ func (v *SliceGridView[S]) CreateDefaultMenuItems(ids []string, forSingleCell bo
 			}
 		}
 	}
+	if undoItems := v.undoMenuItems(); len(undoItems) != 0 {
+		if len(items) != 0 {
+			items = append(items, zmenu.MenuedOItemSeparator)
+		}
+		items = append(items, undoItems...)
+	}
 	return items
 }
 
@@ -981,11 +1132,15 @@ func (v *SliceGridView[S]) pasteItemsFromClipboard() {
 			return
 		}
 		v.ValidateClipboardPasteItemsFunc(&slice, func() {
-			go v.StoreChangedItemsFunc(slice)
-			var ids []string
-			for _, s := range slice {
-				ids = append(ids, GetIDForItem(&s))
-			}
+			name := "Paste " + zwords.PluralWordWithCount(v.StructName, float64(len(slice)), "", "", 0)
+			ids := itemIDs(slice)
+			before := v.getItemsFromIDs(ids)
+			go func() {
+				v.StoreChangedItemsFunc(slice)
+				if v.UndoManager != nil { // we record undo after storing, so undoing can't happen before the items are there to delete
+					v.addUndo(name, zundo.ItemChanges(before, slice, GetIDForItem[S]))
+				}
+			}()
 			v.Grid.SelectCells(ids, true, false)
 		})
 	})
@@ -0,0 +1,142 @@
+package zsqltable
+
+import (
+	"sync"
+	"time"
+)
+
+// RowChanges are the rows changed since a serial, as returned by Service.WaitForChanges.
+type RowChanges[S any] struct {
+	Serial     int64    // Serial is of the last change included, pass it to the next WaitForChanges.
+	Rows       []S      // Rows are inserted or updated rows, as they are now.
+	DeletedIDs []string // DeletedIDs are the primary keys of deleted rows.
+	Reset      bool     // Reset is set if changes since the serial asked for aren't kept anymore, so all rows must be got again.
+}
+
+// ChangeFeed keeps the latest row changes, so clients can long-poll for them with Wait.
+// Service publishes its own changes to it; call Changed and Deleted for rows changed elsewhere.
+type ChangeFeed[S any] struct {
+	MaxKept int                 // MaxKept is how many changes are kept. A client further behind than that gets a Reset.
+	IDFunc  func(row *S) string // IDFunc returns row's primary key as a string, as in DeletedIDs.
+	lock    sync.Mutex
+	serial  int64
+	changes []rowChange[S]
+	wake    chan struct{} // wake is closed and replaced on each change, to wake all waiting
+}
+
+type rowChange[S any] struct {
+	serial int64
+	id     string
+	row    *S // row is nil if deleted
+}
+
+// LongPollWait is how long Service.WaitForChanges waits for a change before returning none.
+// It must be less than the rpc client's timeout.
+var LongPollWait = 20 * time.Second
+
+func NewChangeFeed[S any](idFunc func(row *S) string) *ChangeFeed[S] {
+	return &ChangeFeed[S]{
+		MaxKept: 1000,
+		IDFunc:  idFunc,
+		// serial starts at when the feed is made, not 0, which asks Wait for the serial to start from.
+		// A client of a restarted server then has an older serial than any kept, and gets a Reset.
+		serial: time.Now().UnixMicro(),
+		wake:   make(chan struct{}),
+	}
+}
+
+// Changed publishes rows as inserted or updated.
+func (f *ChangeFeed[S]) Changed(rows ...S) {
+	if len(rows) == 0 {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for i := range rows {
+		row := rows[i]
+		f.add(f.IDFunc(&row), &row)
+	}
+	f.notify()
+}
+
+// Deleted publishes the rows with primary keys ids as deleted.
+func (f *ChangeFeed[S]) Deleted(ids ...string) {
+	if len(ids) == 0 {
+		return
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, id := range ids {
+		f.add(id, nil)
+	}
+	f.notify()
+}
+
+func (f *ChangeFeed[S]) add(id string, row *S) {
+	f.serial++
+	f.changes = append(f.changes, rowChange[S]{serial: f.serial, id: id, row: row})
+	if len(f.changes) > f.MaxKept {
+		f.changes = f.changes[len(f.changes)-f.MaxKept:]
+	}
+}
+
+func (f *ChangeFeed[S]) notify() {
+	close(f.wake)
+	f.wake = make(chan struct{})
+}
+
+// Serial is the serial of the latest change.
+func (f *ChangeFeed[S]) Serial() int64 {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return f.serial
+}
+
+// Wait returns the changes after since, waiting up to timeout for one if there are none yet.
+// If since is <= 0, it returns the current serial at once, to start from.
+func (f *ChangeFeed[S]) Wait(since int64, timeout time.Duration) RowChanges[S] {
+	f.lock.Lock()
+	if since > 0 && since == f.serial {
+		wake := f.wake
+		f.lock.Unlock()
+		select {
+		case <-wake:
+		case <-time.After(timeout):
+		}
+		f.lock.Lock()
+	}
+	defer f.lock.Unlock()
+	return f.since(since)
+}
+
+// since returns the changes after serial, with only the latest change for each row.
+func (f *ChangeFeed[S]) since(serial int64) RowChanges[S] {
+	rc := RowChanges[S]{Serial: f.serial}
+	if serial <= 0 || serial == f.serial {
+		return rc
+	}
+	// If serial is newer than ours, the server has restarted.
+	if serial > f.serial || len(f.changes) == 0 || f.changes[0].serial > serial+1 {
+		rc.Reset = true
+		return rc
+	}
+	latest := map[string]bool{}
+	var reversed []rowChange[S]
+	for i := len(f.changes) - 1; i >= 0 && f.changes[i].serial > serial; i-- {
+		c := f.changes[i]
+		if latest[c.id] {
+			continue
+		}
+		latest[c.id] = true
+		reversed = append(reversed, c)
+	}
+	for i := len(reversed) - 1; i >= 0; i-- {
+		c := reversed[i]
+		if c.row == nil {
+			rc.DeletedIDs = append(rc.DeletedIDs, c.id)
+		} else {
+			rc.Rows = append(rc.Rows, *c.row)
+		}
+	}
+	return rc
+}
@@ -0,0 +1,185 @@
+// Package zsqltable is the server side of zslicegrid.SQLTableView.
+// Query is what the table sends over zrpc to select rows; Builder turns it into parameterized SQL
+// for Postgres or SQLite, so search text and values are never part of the SQL string.
+//
+// Migrating a server from the raw SQL calls SQLTableView used to make:
+//   - Select with a zsql.QueryBase of SQL constraints is replaced by SelectPage and Count with a Query.
+//   - SQLCalls.ExecuteQuery with a DELETE statement is replaced by DeleteRows with the ids on the table's own caller.
+//   - InsertRows, UpdateRows and PreDeleteRows are called as before, and WaitForChanges is new, for live updates.
+//
+// Service implements all of these; embedding it under the old caller name is usually all a server needs.
+// SQLOwner.Constraints is no longer sent, and the table gets no rows if it is set; use SQLOwner.Equals and SetSearchFields.
+package zsqltable
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/torlangballe/zutil/zlog"
+	"github.com/torlangballe/zutil/zsql"
+)
+
+type Dialect string
+
+const (
+	Postgres Dialect = "postgres"
+	SQLite   Dialect = "sqlite"
+)
+
+// SortColumn is a field to sort by, like zfields.SortInfo.
+type SortColumn struct {
+	FieldName  string
+	SmallFirst bool
+}
+
+// Query is a structured select, sent from client to server instead of raw SQL constraints.
+// All names are struct field names, which Builder maps to columns, so unknown names are errors, not SQL.
+type Query struct {
+	Table        string
+	SearchText   string         // SearchText is searched for case-insensitively as a substring in any of SearchFields. With SQLite only ASCII letters are case-insensitive.
+	SearchFields []string       // SearchFields are the fields SearchText is searched for in.
+	Equals       map[string]any // Equals are field names and values the rows must have.
+	Sort         []SortColumn
+	Limit        int // Limit is the maximum rows returned, 0 is all.
+	Offset       int
+}
+
+// Builder builds SQL for a struct type's columns in a dialect.
+// It collects arguments while building a statement, so copy it for concurrent use.
+type Builder struct {
+	Dialect       Dialect
+	FieldToColumn map[string]string
+	Primary       string // Primary is the primary key column
+	args          []any
+}
+
+// NewBuilder makes a builder for the columns of S as mapped by zsql.
+func NewBuilder[S any](dialect Dialect) *Builder {
+	var s S
+	b := &Builder{Dialect: dialect}
+	b.FieldToColumn, b.Primary = zsql.FieldNamesToColumnFromStruct(s, nil, "")
+	return b
+}
+
+// Placeholder adds arg to the builder's arguments, and returns the placeholder for it.
+func (b *Builder) Placeholder(arg any) string {
+	b.args = append(b.args, arg)
+	if b.Dialect == SQLite {
+		return "?"
+	}
+	return fmt.Sprint("$", len(b.args))
+}
+
+// Args returns the arguments added with Placeholder so far, and resets them for a new statement.
+func (b *Builder) Args() []any {
+	args := b.args
+	b.args = nil
+	return args
+}
+
+// Column returns the column for fieldName, or an error if it isn't a field of the builder's struct.
+func (b *Builder) Column(fieldName string) (string, error) {
+	col, got := b.FieldToColumn[fieldName]
+	if !got {
+		return "", zlog.NewError("zsqltable: no column for field:", fieldName)
+	}
+	return col, nil
+}
+
+// Where returns a WHERE clause for q's search and equals, or "" if neither is set.
+// Its arguments are added to the builder, see Args.
+func (b *Builder) Where(q Query) (string, error) {
+	var ands []string
+	if q.SearchText != "" && len(q.SearchFields) > 0 {
+		like := "%" + escapeLike(q.SearchText) + "%"
+		var ors []string
+		for _, fn := range q.SearchFields {
+			col, err := b.Column(fn)
+			if err != nil {
+				return "", err
+			}
+			ors = append(ors, b.caseInsensitiveLike(col, b.Placeholder(like)))
+		}
+		ands = append(ands, "("+strings.Join(ors, " OR ")+")")
+	}
+	var fields []string
+	for fn := range q.Equals {
+		fields = append(fields, fn)
+	}
+	sort.Strings(fields) // so the SQL is the same each time, for statement caches and tests
+	for _, fn := range fields {
+		col, err := b.Column(fn)
+		if err != nil {
+			return "", err
+		}
+		ands = append(ands, col+"="+b.Placeholder(q.Equals[fn]))
+	}
+	if len(ands) == 0 {
+		return "", nil
+	}
+	return "WHERE " + strings.Join(ands, " AND "), nil
+}
+
+func (b *Builder) caseInsensitiveLike(col, placeholder string) string {
+	if b.Dialect == SQLite {
+		// SQLite's LIKE is case-insensitive, but only for ASCII letters unless built with ICU, as its LOWER is.
+		return "CAST(" + col + " AS TEXT) LIKE " + placeholder + ` ESCAPE '\'`
+	}
+	return "CAST(" + col + " AS TEXT) ILIKE " + placeholder + ` ESCAPE '\'`
+}
+
+// escapeLike escapes LIKE's wildcards in str, so it is matched as-is.
+func escapeLike(str string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(str)
+}
+
+// OrderBy returns an ORDER BY clause for q.Sort, or "".
+func (b *Builder) OrderBy(q Query) (string, error) {
+	var orders []string
+	for _, s := range q.Sort {
+		col, err := b.Column(s.FieldName)
+		if err != nil {
+			return "", err
+		}
+		dir := " DESC"
+		if s.SmallFirst {
+			dir = " ASC"
+		}
+		orders = append(orders, col+dir)
+	}
+	if len(orders) == 0 {
+		return "", nil
+	}
+	return "ORDER BY " + strings.Join(orders, ","), nil
+}
+
+// Constraints returns WHERE, ORDER BY, LIMIT and OFFSET for q, to append after SELECT ... FROM table.
+// Its arguments are added to the builder, see Args.
+func (b *Builder) Constraints(q Query) (string, error) {
+	var parts []string
+	where, err := b.Where(q)
+	if err != nil {
+		return "", err
+	}
+	if where != "" {
+		parts = append(parts, where)
+	}
+	order, err := b.OrderBy(q)
+	if err != nil {
+		return "", err
+	}
+	if order != "" {
+		parts = append(parts, order)
+	}
+	if q.Limit > 0 {
+		parts = append(parts, "LIMIT "+b.Placeholder(q.Limit))
+	}
+	if q.Offset > 0 {
+		if q.Limit == 0 && b.Dialect == SQLite {
+			parts = append(parts, "LIMIT -1") // SQLite needs a LIMIT for OFFSET
+		}
+		parts = append(parts, "OFFSET "+b.Placeholder(q.Offset))
+	}
+	return strings.Join(parts, " "), nil
+}
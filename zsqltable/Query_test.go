@@ -0,0 +1,113 @@
+package zsqltable
+
+import (
+	"reflect"
+	"testing"
+)
+
+func newTestBuilder(dialect Dialect) *Builder {
+	return &Builder{
+		Dialect:       dialect,
+		FieldToColumn: map[string]string{"ID": "id", "Name": "name", "Team": "team", "Age": "age"},
+		Primary:       "id",
+	}
+}
+
+func TestBuilderConstraints(t *testing.T) {
+	byNameAge := []SortColumn{{FieldName: "Name", SmallFirst: true}, {FieldName: "Age"}}
+	tests := []struct {
+		name     string
+		dialect  Dialect
+		q        Query
+		wantSQL  string
+		wantArgs []any
+	}{
+		{"empty postgres", Postgres, Query{}, "", nil},
+		{"empty sqlite", SQLite, Query{}, "", nil},
+		{
+			"search postgres", Postgres,
+			Query{SearchText: "a_b%", SearchFields: []string{"Name", "Team"}},
+			`WHERE (CAST(name AS TEXT) ILIKE $1 ESCAPE '\' OR CAST(team AS TEXT) ILIKE $2 ESCAPE '\')`,
+			[]any{`%a\_b\%%`, `%a\_b\%%`},
+		},
+		{
+			"search sqlite", SQLite,
+			Query{SearchText: `c:\x`, SearchFields: []string{"Name"}},
+			`WHERE (CAST(name AS TEXT) LIKE ? ESCAPE '\')`,
+			[]any{`%c:\\x%`},
+		},
+		{"search without fields", Postgres, Query{SearchText: "a"}, "", nil},
+		{
+			"equals sorted by field", Postgres,
+			Query{Equals: map[string]any{"Team": "red", "Age": 3}},
+			"WHERE age=$1 AND team=$2",
+			[]any{3, "red"},
+		},
+		{
+			"search and equals", SQLite,
+			Query{SearchText: "x", SearchFields: []string{"Name"}, Equals: map[string]any{"Team": "red"}},
+			`WHERE (CAST(name AS TEXT) LIKE ? ESCAPE '\') AND team=?`,
+			[]any{"%x%", "red"},
+		},
+		{"order", Postgres, Query{Sort: byNameAge}, "ORDER BY name ASC,age DESC", nil},
+		{"limit and offset postgres", Postgres, Query{Limit: 10, Offset: 20}, "LIMIT $1 OFFSET $2", []any{10, 20}},
+		{"limit and offset sqlite", SQLite, Query{Limit: 10, Offset: 20}, "LIMIT ? OFFSET ?", []any{10, 20}},
+		{"offset only postgres", Postgres, Query{Offset: 5}, "OFFSET $1", []any{5}},
+		{"offset only sqlite", SQLite, Query{Offset: 5}, "LIMIT -1 OFFSET ?", []any{5}},
+		{
+			"all postgres", Postgres,
+			Query{SearchText: "b", SearchFields: []string{"Name"}, Equals: map[string]any{"Team": "blue"}, Sort: byNameAge, Limit: 2, Offset: 4},
+			`WHERE (CAST(name AS TEXT) ILIKE $1 ESCAPE '\') AND team=$2 ORDER BY name ASC,age DESC LIMIT $3 OFFSET $4`,
+			[]any{"%b%", "blue", 2, 4},
+		},
+		{
+			"all sqlite", SQLite,
+			Query{SearchText: "b", SearchFields: []string{"Name"}, Equals: map[string]any{"Team": "blue"}, Sort: byNameAge, Limit: 2, Offset: 4},
+			`WHERE (CAST(name AS TEXT) LIKE ? ESCAPE '\') AND team=? ORDER BY name ASC,age DESC LIMIT ? OFFSET ?`,
+			[]any{"%b%", "blue", 2, 4},
+		},
+	}
+	for _, test := range tests {
+		b := newTestBuilder(test.dialect)
+		sql, err := b.Constraints(test.q)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if sql != test.wantSQL {
+			t.Errorf("%s: sql\n%s\nwant\n%s", test.name, sql, test.wantSQL)
+		}
+		args := b.Args()
+		if !reflect.DeepEqual(args, test.wantArgs) {
+			t.Errorf("%s: args %#v, want %#v", test.name, args, test.wantArgs)
+		}
+		if b.Args() != nil {
+			t.Errorf("%s: args not reset", test.name)
+		}
+	}
+}
+
+func TestBuilderUnknownFields(t *testing.T) {
+	for _, q := range []Query{
+		{SearchText: "a", SearchFields: []string{"Nope"}},
+		{Equals: map[string]any{"name; DROP TABLE people": 1}},
+		{Sort: []SortColumn{{FieldName: "name"}}},
+	} {
+		b := newTestBuilder(Postgres)
+		if _, err := b.Constraints(q); err == nil {
+			t.Errorf("no error for %+v", q)
+		}
+	}
+}
+
+func TestBuilderPlaceholdersContinue(t *testing.T) {
+	b := newTestBuilder(Postgres)
+	set := "name=" + b.Placeholder("Ada")
+	where, err := b.Where(Query{Equals: map[string]any{"ID": 1}})
+	if err != nil || set != "name=$1" || where != "WHERE id=$2" {
+		t.Error("placeholders:", set, where, err)
+	}
+	if args := b.Args(); !reflect.DeepEqual(args, []any{"Ada", 1}) {
+		t.Error("args:", args)
+	}
+}
@@ -0,0 +1,262 @@
+package zsqltable
+
+import (
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/torlangballe/zutil/zlog"
+	"github.com/torlangballe/zutil/zrpc"
+)
+
+// Service is a zrpc-callable SQL table for struct type S, with the calls zslicegrid.SQLOwner makes.
+// Columns are mapped from S's fields with zsql, and all SQL is parameterized with Builder.
+// To serve it under the rpcCallerName given to SQLOwner.Init, embed it in a type with that name, and register that:
+//
+//	type UserCalls struct{ *zsqltable.Service[User] }
+//	executor.Register(UserCalls{zsqltable.NewService[User](db, "users", zsqltable.Postgres)})
+type Service[S any] struct {
+	DB            *sql.DB
+	Table         string
+	PreDeleteFunc func(ids []string) error // PreDeleteFunc is called with ids from PreDeleteRows, before rows are deleted.
+	Changes       *ChangeFeed[S]           // Changes gets rows inserted, updated and deleted through the service, for WaitForChanges.
+	builder       Builder
+	fields        []string // fields are the names of S's fields that have columns, in struct order
+	primaryField  string
+}
+
+// Page is the rows of a query's page, and how many rows match it in all, as got with Service.SelectPage.
+type Page[S any] struct {
+	Rows  []S
+	Total int64 // Total is the number of rows matching the query's search and equals, ignoring its sorting and paging.
+}
+
+// NewService makes a service for table in db, which is in dialect.
+func NewService[S any](db *sql.DB, table string, dialect Dialect) *Service[S] {
+	s := &Service[S]{DB: db, Table: table}
+	s.builder = *NewBuilder[S](dialect)
+	for _, sf := range reflect.VisibleFields(reflect.TypeOf(*new(S))) {
+		col, got := s.builder.FieldToColumn[sf.Name]
+		if !got || sf.Anonymous {
+			continue
+		}
+		s.fields = append(s.fields, sf.Name)
+		if col == s.builder.Primary {
+			s.primaryField = sf.Name
+		}
+	}
+	zlog.Assert(s.primaryField != "", "no primary key field for", table)
+	s.Changes = NewChangeFeed(func(row *S) string {
+		return fmt.Sprint(reflect.ValueOf(row).Elem().FieldByName(s.primaryField).Interface())
+	})
+	return s
+}
+
+func (s *Service[S]) columns(fields []string) []string {
+	cols := make([]string, len(fields))
+	for i, f := range fields {
+		cols[i] = s.builder.FieldToColumn[f]
+	}
+	return cols
+}
+
+func fieldPointers(row reflect.Value, fields []string) []any {
+	ptrs := make([]any, len(fields))
+	for i, f := range fields {
+		ptrs[i] = row.FieldByName(f).Addr().Interface()
+	}
+	return ptrs
+}
+
+// Select gets rows matching q's search and equals, sorted and paged as set in q.
+func (s *Service[S]) Select(q Query, rows *[]S) error {
+	b := s.builder
+	cons, err := b.Constraints(q)
+	if err != nil {
+		return err
+	}
+	query := "SELECT " + strings.Join(s.columns(s.fields), ",") + " FROM " + s.Table + " " + cons
+	sqlRows, err := s.DB.Query(query, b.Args()...)
+	if err != nil {
+		return zlog.Error("select", query, err)
+	}
+	defer sqlRows.Close()
+	*rows = []S{}
+	for sqlRows.Next() {
+		var row S
+		err = sqlRows.Scan(fieldPointers(reflect.ValueOf(&row).Elem(), s.fields)...)
+		if err != nil {
+			return zlog.Error("scan", s.Table, err)
+		}
+		*rows = append(*rows, row)
+	}
+	return sqlRows.Err()
+}
+
+// Count gets the total number of rows matching q's search and equals, ignoring its sorting and paging.
+func (s *Service[S]) Count(q Query, count *int64) error {
+	b := s.builder
+	where, err := b.Where(q)
+	if err != nil {
+		return err
+	}
+	query := "SELECT COUNT(*) FROM " + s.Table + " " + where
+	err = s.DB.QueryRow(query, b.Args()...).Scan(count)
+	if err != nil {
+		return zlog.Error("count", query, err)
+	}
+	return nil
+}
+
+// SelectPage gets the rows of q's page and the total rows matching q, so a paged table needs one call, see Select and Count.
+func (s *Service[S]) SelectPage(q Query, page *Page[S]) error {
+	err := s.Select(q, &page.Rows)
+	if err != nil {
+		return err
+	}
+	return s.Count(q, &page.Total)
+}
+
+// UpdateRows sets all columns of rows, finding them by primary key.
+// Only rows that exist are published to Changes.
+func (s *Service[S]) UpdateRows(rows []S, reply *zrpc.Unused) error {
+	var fields []string
+	for _, f := range s.fields {
+		if f != s.primaryField {
+			fields = append(fields, f)
+		}
+	}
+	var updated []S
+	err := s.inTransaction(func(tx *sql.Tx) error {
+		for _, row := range rows {
+			b := s.builder
+			rval := reflect.ValueOf(&row).Elem()
+			var sets []string
+			for i, col := range s.columns(fields) {
+				sets = append(sets, col+"="+b.Placeholder(rval.FieldByName(fields[i]).Interface()))
+			}
+			query := "UPDATE " + s.Table + " SET " + strings.Join(sets, ",") + " WHERE " + s.builder.Primary + "=" + b.Placeholder(rval.FieldByName(s.primaryField).Interface())
+			result, err := tx.Exec(query, b.Args()...)
+			if err != nil {
+				return zlog.Error("update", query, err)
+			}
+			n, err := result.RowsAffected()
+			if err != nil {
+				return zlog.Error("update affected", query, err)
+			}
+			if n != 0 {
+				updated = append(updated, row)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.Changes.Changed(updated...)
+	return nil
+}
+
+// InsertRows inserts rows. A row's primary key column is left out if zero, so the database sets it,
+// and it is got back into the row with RETURNING, which Postgres and SQLite 3.35+ support.
+func (s *Service[S]) InsertRows(rows []S, reply *zrpc.Unused) error {
+	err := s.inTransaction(func(tx *sql.Tx) error {
+		for i := range rows {
+			b := s.builder
+			rval := reflect.ValueOf(&rows[i]).Elem()
+			pval := rval.FieldByName(s.primaryField)
+			var fields, places []string
+			for _, f := range s.fields {
+				fval := rval.FieldByName(f)
+				if f == s.primaryField && fval.IsZero() {
+					continue
+				}
+				fields = append(fields, f)
+				places = append(places, b.Placeholder(fval.Interface()))
+			}
+			query := "INSERT INTO " + s.Table + " (" + strings.Join(s.columns(fields), ",") + ") VALUES (" + strings.Join(places, ",") + ")"
+			var err error
+			if pval.IsZero() {
+				query += " RETURNING " + s.builder.Primary
+				err = tx.QueryRow(query, b.Args()...).Scan(pval.Addr().Interface())
+			} else {
+				_, err = tx.Exec(query, b.Args()...)
+			}
+			if err != nil {
+				return zlog.Error("insert", query, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.Changes.Changed(rows...)
+	return nil
+}
+
+// PreDeleteRows is called by the client before DeleteRows, and calls PreDeleteFunc if set.
+func (s *Service[S]) PreDeleteRows(ids []string, reply *zrpc.Unused) error {
+	if s.PreDeleteFunc != nil {
+		return s.PreDeleteFunc(ids)
+	}
+	return nil
+}
+
+// DeleteRows deletes the rows with primary keys in ids, returning how many were deleted in affected.
+// The ids of the rows deleted are got back with RETURNING, and only those are published to Changes.
+func (s *Service[S]) DeleteRows(ids []string, affected *int64) error {
+	*affected = 0
+	if len(ids) == 0 {
+		return nil
+	}
+	b := s.builder
+	var places []string
+	for _, id := range ids {
+		places = append(places, b.Placeholder(id))
+	}
+	query := "DELETE FROM " + s.Table + " WHERE " + s.builder.Primary + " IN (" + strings.Join(places, ",") + ") RETURNING " + s.builder.Primary
+	sqlRows, err := s.DB.Query(query, b.Args()...)
+	if err != nil {
+		return zlog.Error("delete", query, err)
+	}
+	defer sqlRows.Close()
+	var deleted []string
+	for sqlRows.Next() {
+		var id string
+		err = sqlRows.Scan(&id)
+		if err != nil {
+			return zlog.Error("delete scan", query, err)
+		}
+		deleted = append(deleted, id)
+	}
+	err = sqlRows.Err()
+	if err != nil {
+		return zlog.Error("delete rows", query, err)
+	}
+	*affected = int64(len(deleted))
+	s.Changes.Deleted(deleted...)
+	return nil
+}
+
+// WaitForChanges long-polls for rows inserted, updated or deleted after the serial since, waiting up to LongPollWait.
+// Call it with 0 to get the serial to start from, see ChangeFeed.Wait.
+// The changes are for the whole table, not filtered by any query; clients only merge rows they already show.
+func (s *Service[S]) WaitForChanges(since int64, changes *RowChanges[S]) error {
+	*changes = s.Changes.Wait(since, LongPollWait)
+	return nil
+}
+
+func (s *Service[S]) inTransaction(do func(tx *sql.Tx) error) error {
+	tx, err := s.DB.Begin()
+	if err != nil {
+		return err
+	}
+	err = do(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,152 @@
+package zsqltable
+
+import (
+	"database/sql"
+	"testing"
+
+	"github.com/torlangballe/zutil/zrpc"
+	_ "modernc.org/sqlite"
+)
+
+type serviceTestRow struct {
+	ID   int64  `db:"id,primary"`
+	Name string `db:"name"`
+	Team string `db:"team"`
+}
+
+func newTestService(t *testing.T) *Service[serviceTestRow] {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1) // each connection to :memory: is its own database
+	t.Cleanup(func() { db.Close() })
+	_, err = db.Exec("CREATE TABLE people (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT NOT NULL, team TEXT NOT NULL)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewService[serviceTestRow](db, "people", SQLite)
+}
+
+func selectNames(t *testing.T, s *Service[serviceTestRow], q Query) []string {
+	t.Helper()
+	var rows []serviceTestRow
+	err := s.Select(q, &rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, r := range rows {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+func sameStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestServiceInsertSelectCount(t *testing.T) {
+	s := newTestService(t)
+	rows := []serviceTestRow{
+		{Name: "Ada", Team: "red"},
+		{Name: "bob", Team: "blue"},
+		{Name: "Carl_1", Team: "red"},
+		{ID: 10, Name: "Dina 100%", Team: "red"},
+	}
+	err := s.InsertRows(rows, &zrpc.Unused{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0].ID != 1 || rows[1].ID != 2 || rows[2].ID != 3 || rows[3].ID != 10 {
+		t.Error("primary keys not got back:", rows)
+	}
+	byName := []SortColumn{{FieldName: "Name", SmallFirst: true}}
+	tests := []struct {
+		name  string
+		q     Query
+		want  []string
+		count int64
+	}{
+		{"all", Query{Sort: byName}, []string{"Ada", "Carl_1", "Dina 100%", "bob"}, 4},
+		{"descending", Query{Sort: []SortColumn{{FieldName: "ID"}}}, []string{"Dina 100%", "Carl_1", "bob", "Ada"}, 4},
+		{"search", Query{SearchText: "B", SearchFields: []string{"Name", "Team"}, Sort: byName}, []string{"bob"}, 1},
+		{"search case", Query{SearchText: "aDA", SearchFields: []string{"Name"}}, []string{"Ada"}, 1},
+		{"search wildcards", Query{SearchText: "_", SearchFields: []string{"Name"}}, []string{"Carl_1"}, 1},
+		{"search percent", Query{SearchText: "0%", SearchFields: []string{"Name"}}, []string{"Dina 100%"}, 1},
+		{"equals", Query{Equals: map[string]any{"Team": "red"}, Sort: byName}, []string{"Ada", "Carl_1", "Dina 100%"}, 3},
+		{"search and equals", Query{Equals: map[string]any{"Team": "red"}, SearchText: "a", SearchFields: []string{"Name"}, Sort: byName}, []string{"Ada", "Carl_1", "Dina 100%"}, 3},
+		{"page", Query{Sort: byName, Limit: 2, Offset: 1}, []string{"Carl_1", "Dina 100%"}, 4},
+		{"offset only", Query{Sort: byName, Offset: 3}, []string{"bob"}, 4},
+	}
+	for _, test := range tests {
+		got := selectNames(t, s, test.q)
+		if !sameStrings(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+		var count int64
+		err = s.Count(test.q, &count)
+		if err != nil || count != test.count {
+			t.Errorf("%s: count %d, want %d: %v", test.name, count, test.count, err)
+		}
+	}
+	var page Page[serviceTestRow]
+	err = s.SelectPage(Query{Equals: map[string]any{"Team": "red"}, Sort: byName, Limit: 2}, &page)
+	if err != nil || page.Total != 3 || len(page.Rows) != 2 || page.Rows[0].Name != "Ada" {
+		t.Error("select page:", page, err)
+	}
+	var none []serviceTestRow
+	if err := s.Select(Query{Sort: []SortColumn{{FieldName: "Nope"}}}, &none); err == nil {
+		t.Error("unknown sort field accepted")
+	}
+	if err := s.Select(Query{Equals: map[string]any{"name; DROP TABLE people": 1}}, &none); err == nil {
+		t.Error("unknown equals field accepted")
+	}
+}
+
+func TestServiceUpdateDeleteChanges(t *testing.T) {
+	s := newTestService(t)
+	start := s.Changes.Wait(0, 0).Serial
+	rows := []serviceTestRow{{Name: "Ada", Team: "red"}, {Name: "Bob", Team: "blue"}}
+	err := s.InsertRows(rows, &zrpc.Unused{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows[1].Team = "red"
+	err = s.UpdateRows([]serviceTestRow{rows[1], {ID: 99, Name: "Nobody", Team: "red"}}, &zrpc.Unused{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := selectNames(t, s, Query{Equals: map[string]any{"Team": "red"}, Sort: []SortColumn{{FieldName: "Name", SmallFirst: true}}})
+	if !sameStrings(got, []string{"Ada", "Bob"}) {
+		t.Error("after update:", got)
+	}
+	var affected int64
+	err = s.DeleteRows([]string{"1", "99"}, &affected)
+	if err != nil || affected != 1 {
+		t.Error("delete:", affected, err)
+	}
+	got = selectNames(t, s, Query{})
+	if !sameStrings(got, []string{"Bob"}) {
+		t.Error("after delete:", got)
+	}
+	var changes RowChanges[serviceTestRow]
+	err = s.WaitForChanges(start, &changes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(changes.Rows) != 1 || changes.Rows[0].Name != "Bob" || changes.Rows[0].Team != "red" {
+		t.Error("changed rows:", changes.Rows)
+	}
+	if len(changes.DeletedIDs) != 1 || changes.DeletedIDs[0] != "1" {
+		t.Error("deleted ids:", changes.DeletedIDs)
+	}
+}
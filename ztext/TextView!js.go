@@ -0,0 +1,108 @@
+//go:build !js && zui
+
+package ztext
+
+import (
+	"github.com/torlangballe/zui/zkeyboard"
+	"github.com/torlangballe/zui/zview"
+	"github.com/torlangballe/zutil/zfloat"
+	"github.com/torlangballe/zutil/zgeo"
+	"github.com/torlangballe/zutil/zkeyvalue"
+)
+
+func (v *TextView) Init(view zview.View, text string, textStyle Style, cols, rows int) {
+	kind := "input"
+	if rows > 1 {
+		kind = "textarea"
+	}
+	v.MakeHeadless(view, kind)
+	v.textStyle = textStyle
+	v.SetMaxLines(rows)
+	v.minValue = zfloat.Undefined
+	v.maxValue = zfloat.Undefined
+	v.SetObjectName("textview")
+	v.Columns = cols
+	v.SetStroke(1, zgeo.ColorGray, true)
+	v.JSSet("value", text)
+	v.UpdateSecs = 1
+	f := zgeo.FontNice(zgeo.FontDefaultSize, zgeo.FontStyleNormal)
+	v.SetFont(f)
+	if DefaultBGColor().Valid {
+		v.SetBGColor(DefaultBGColor())
+	}
+}
+
+func (v *TextView) SetIdent(m zgeo.Rect)    {}
+func (v *TextView) Select(from, to int)     {}
+func (v *TextView) SetIsStatic(s bool)      { v.JSSet("readOnly", s) }
+func (v *TextView) SetReadOnly(is bool)     { v.JSSet("readOnly", is) }
+func (v *TextView) SetMin(min float64)      { v.JSSet("min", min) }
+func (v *TextView) SetMax(max float64)      { v.JSSet("max", max) }
+func (v *TextView) SetStep(step float64)    { v.JSSet("step", step) }
+func (v *TextView) SetPlaceholder(s string) { v.JSSet("placeholder", s) }
+func (v *TextView) ScrollToBottom()         {}
+
+func (v *TextView) SetTextAlignment(a zgeo.Alignment) {
+	v.alignment = a
+}
+
+func (v *TextView) SetRect(rect zgeo.Rect) {
+	rect.Add(v.margin)
+	v.NativeView.SetRect(rect)
+}
+
+func (v *TextView) SetMargin(m zgeo.Rect) {
+	v.margin = m
+}
+
+func (v *TextView) SetText(text string) {
+	if v.FilterFunc != nil {
+		text = v.FilterFunc(text)
+	}
+	if v.Text() != text {
+		v.JSSet("value", text)
+		v.changed.CallAll(false)
+	}
+	if zkeyvalue.DefaultStore != nil && v.storeKey != "" {
+		zkeyvalue.DefaultStore.SetString(v.Text(), v.storeKey, true)
+	}
+}
+
+// TypeText sets text as if the user edited it, calling value handlers with edited true.
+func (v *TextView) TypeText(text string) {
+	if v.FilterFunc != nil {
+		text = v.FilterFunc(text)
+	}
+	v.JSSet("value", text)
+	v.changed.CallAll(true)
+}
+
+func (v *TextView) Text() string {
+	str, _ := v.JSGet("value").(string)
+	return str
+}
+
+func (v *TextView) SetValueHandler(id string, handler func(edited bool)) {
+	v.changed.Add(id, handler)
+}
+
+func (v *TextView) SetEditDoneHandler(handler func(canceled bool)) {
+	v.editDone = handler
+}
+
+func (v *TextView) SetKeyHandler(handler func(km zkeyboard.KeyMod, down bool) bool) {
+	v.NativeView.SetKeyHandler(handler)
+}
+
+func (v *TextView) ConsumesKey(sc zkeyboard.KeyMod) bool {
+	if sc.Modifier != zkeyboard.ModifierNone {
+		return false
+	}
+	switch sc.Key {
+	case zkeyboard.KeyEscape:
+		return false
+	case zkeyboard.KeyReturn, zkeyboard.KeyEnter, zkeyboard.KeyUpArrow, zkeyboard.KeyDownArrow:
+		return v.Kind() == "textarea"
+	}
+	return true
+}
@@ -118,7 +118,7 @@ func (v *TextView) SetTextAlignment(a zgeo.Alignment) {
 }
 
 func (v *TextView) SetReadOnly(is bool) {
-	v.JSSet("readonly", is)
+	v.JSSet("readOnly", is)
 }
 
 func (v *TextView) SetMin(min float64) {
@@ -0,0 +1,349 @@
+package ztextinfo
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Direction is the base direction of a paragraph of text.
+type Direction int
+
+const (
+	DirectionAuto Direction = iota // DirectionAuto gets the direction from the first strong letter, see BaseDirection
+	DirectionLTR
+	DirectionRTL
+)
+
+// bidiClass is a simplified Unicode bidirectional character type.
+type bidiClass int
+
+const (
+	bidiON  bidiClass = iota // other neutral
+	bidiL                    // left-to-right letter
+	bidiR                    // right-to-left letter, like Hebrew
+	bidiAL                   // arabic letter
+	bidiEN                   // european number
+	bidiAN                   // arabic number
+	bidiES                   // number separator, + and -
+	bidiET                   // number terminator, like % and currency
+	bidiCS                   // common number separator, like , . : /
+	bidiNSM                  // non-spacing mark
+	bidiWS                   // whitespace
+)
+
+const zeroWidthJoiner = 0x200D
+
+var bidiMirrors = map[rune]rune{
+	'(': ')', ')': '(', '[': ']', ']': '[', '{': '}', '}': '{', '<': '>', '>': '<',
+	'«': '»', '»': '«', '‹': '›', '›': '‹', '≤': '≥', '≥': '≤',
+}
+
+// Graphemes splits str into grapheme clusters; user-perceived characters like a letter with its combining marks,
+// an emoji with modifiers or in a ZWJ sequence, or a flag of two regional indicators.
+// It is a simplified UAX #29, enough that truncating, wrapping and reordering never separate a mark from its letter.
+func Graphemes(str string) []string {
+	var clusters []string
+	runes := []rune(str)
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		if i < len(runes) && joinsGrapheme(runes, start, i) {
+			continue
+		}
+		clusters = append(clusters, string(runes[start:i]))
+		start = i
+	}
+	return clusters
+}
+
+// joinsGrapheme returns true if runes[i] is part of the cluster starting at start.
+func joinsGrapheme(runes []rune, start, i int) bool {
+	r := runes[i]
+	prev := runes[i-1]
+	switch {
+	case prev == '\r' && r == '\n':
+		return true
+	case prev == '\n' || prev == '\r':
+		return false
+	case prev == zeroWidthJoiner, r == zeroWidthJoiner:
+		return true
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F, r >= 0xE0100 && r <= 0xE01EF: // variation selectors
+		return true
+	case r >= 0x1F3FB && r <= 0x1F3FF: // emoji skin tone modifiers
+		return true
+	case r >= 0xE0020 && r <= 0xE007F: // tags of subdivision flags
+		return true
+	case r >= 0x1160 && r <= 0x11FF: // hangul vowel and trailing jamo
+		return (prev >= 0x1100 && prev <= 0x11FF) || (prev >= 0xAC00 && prev <= 0xD7A3)
+	case isRegionalIndicator(r):
+		count := 0
+		for j := start; j < i && isRegionalIndicator(runes[j]); j++ {
+			count++
+		}
+		return count%2 == 1
+	}
+	return false
+}
+
+func isRegionalIndicator(r rune) bool {
+	return r >= 0x1F1E6 && r <= 0x1F1FF
+}
+
+func classOf(r rune) bidiClass {
+	switch {
+	case r == 0x200E:
+		return bidiL
+	case r == 0x200F:
+		return bidiR
+	case r >= 0x0660 && r <= 0x0669, r == 0x066B, r == 0x066C:
+		return bidiAN
+	case r >= '0' && r <= '9', r >= 0x06F0 && r <= 0x06F9, r == 0xB2, r == 0xB3, r == 0xB9:
+		return bidiEN
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return bidiNSM
+	case r >= 0x0590 && r <= 0x05FF, r >= 0x07C0 && r <= 0x085F, r >= 0xFB1D && r <= 0xFB4F, r >= 0x10800 && r <= 0x10FFF, r >= 0x1E800 && r <= 0x1EFFF:
+		return bidiR
+	case r == 0x060C:
+		return bidiCS
+	case r >= 0x0600 && r <= 0x07BF, r >= 0x0860 && r <= 0x08FF, r >= 0xFB50 && r <= 0xFDFF, r >= 0xFE70 && r <= 0xFEFF:
+		return bidiAL
+	case r == '+', r == '-':
+		return bidiES
+	case r == '#', r == '%', r == 0xB0, unicode.Is(unicode.Sc, r):
+		return bidiET
+	case r == ',', r == '.', r == ':', r == '/', r == 0xA0:
+		return bidiCS
+	case unicode.IsSpace(r):
+		return bidiWS
+	case unicode.IsLetter(r), unicode.Is(unicode.Mc, r), unicode.IsDigit(r):
+		return bidiL
+	}
+	return bidiON
+}
+
+// BaseDirection returns the direction of the first strong letter in str, or DirectionLTR if there is none.
+func BaseDirection(str string) Direction {
+	for _, r := range str {
+		switch classOf(r) {
+		case bidiL:
+			return DirectionLTR
+		case bidiR, bidiAL:
+			return DirectionRTL
+		}
+	}
+	return DirectionLTR
+}
+
+// IsRTL returns true if ti's text is a right-to-left paragraph, from ti.Direction or its first strong letter.
+func (ti *Info) IsRTL() bool {
+	return isRTL(ti.Text, ti.Direction)
+}
+
+func isRTL(str string, dir Direction) bool {
+	if dir == DirectionAuto {
+		dir = BaseDirection(str)
+	}
+	return dir == DirectionRTL
+}
+
+// hasRTL returns true if str has any right-to-left letters or arabic numbers.
+func hasRTL(str string) bool {
+	for _, r := range str {
+		switch classOf(r) {
+		case bidiR, bidiAL, bidiAN:
+			return true
+		}
+	}
+	return false
+}
+
+// bidiLevels returns the embedding level of each cluster of a line; even levels are left-to-right, odd right-to-left.
+// It follows the weak, neutral and implicit rules of the Unicode Bidirectional Algorithm (UAX #9),
+// but not explicit embeddings and isolates, whose control characters are treated as neutral.
+func bidiLevels(clusters []string, rtl bool) []int {
+	n := len(clusters)
+	base := 0
+	sor := bidiL
+	if rtl {
+		base = 1
+		sor = bidiR
+	}
+	types := make([]bidiClass, n)
+	for i, c := range clusters {
+		r, _ := utf8.DecodeRuneInString(c)
+		types[i] = classOf(r)
+	}
+	// W1: a mark without a letter takes the type before it
+	for i, t := range types {
+		if t == bidiNSM {
+			types[i] = sor
+			if i > 0 {
+				types[i] = types[i-1]
+			}
+		}
+	}
+	// W2 and W3: numbers after arabic letters are arabic, and arabic letters are right-to-left
+	strong := sor
+	for i, t := range types {
+		switch t {
+		case bidiL, bidiR, bidiAL:
+			strong = t
+		case bidiEN:
+			if strong == bidiAL {
+				types[i] = bidiAN
+			}
+		}
+	}
+	for i, t := range types {
+		if t == bidiAL {
+			types[i] = bidiR
+		}
+	}
+	// W4: a single separator between two numbers of the same type joins them
+	for i := 1; i < n-1; i++ {
+		prev, next := types[i-1], types[i+1]
+		switch {
+		case types[i] == bidiES && prev == bidiEN && next == bidiEN:
+			types[i] = bidiEN
+		case types[i] == bidiCS && prev == next && (prev == bidiEN || prev == bidiAN):
+			types[i] = prev
+		}
+	}
+	// W5: terminators next to european numbers are part of them
+	for i := 0; i < n; i++ {
+		if types[i] != bidiET {
+			continue
+		}
+		j := i
+		for j < n && types[j] == bidiET {
+			j++
+		}
+		if (i > 0 && types[i-1] == bidiEN) || (j < n && types[j] == bidiEN) {
+			for k := i; k < j; k++ {
+				types[k] = bidiEN
+			}
+		}
+		i = j - 1
+	}
+	// W6 and W7: other separators are neutral, and european numbers after left-to-right letters are left-to-right
+	strong = sor
+	for i, t := range types {
+		switch t {
+		case bidiES, bidiET, bidiCS:
+			types[i] = bidiON
+		case bidiL, bidiR:
+			strong = t
+		case bidiEN:
+			if strong == bidiL {
+				types[i] = bidiL
+			}
+		}
+	}
+	// N1 and N2: neutrals between the same direction take it, others the paragraph's
+	direction := func(t bidiClass) bidiClass {
+		if t == bidiEN || t == bidiAN {
+			return bidiR
+		}
+		return t
+	}
+	for i := 0; i < n; i++ {
+		if types[i] != bidiON && types[i] != bidiWS {
+			continue
+		}
+		j := i
+		for j < n && (types[j] == bidiON || types[j] == bidiWS) {
+			j++
+		}
+		before, after := sor, sor
+		if i > 0 {
+			before = direction(types[i-1])
+		}
+		if j < n {
+			after = direction(types[j])
+		}
+		set := sor
+		if before == after {
+			set = before
+		}
+		for k := i; k < j; k++ {
+			types[k] = set
+		}
+		i = j - 1
+	}
+	// I1 and I2: levels from the resolved types
+	levels := make([]int, n)
+	for i, t := range types {
+		levels[i] = base
+		switch {
+		case base == 0 && t == bidiR:
+			levels[i] = 1
+		case base == 0 && (t == bidiEN || t == bidiAN):
+			levels[i] = 2
+		case base == 1 && t != bidiR:
+			levels[i] = 2
+		}
+	}
+	// L1: whitespace at the end of the line is at the paragraph's level
+	for i := n - 1; i >= 0 && strings.TrimSpace(clusters[i]) == ""; i-- {
+		levels[i] = base
+	}
+	return levels
+}
+
+// VisualOrder returns line with its grapheme clusters in the order they are drawn from left to right,
+// for canvases that don't reorder bidirectional text themselves. Right-to-left runs are reversed, with brackets mirrored.
+// dir is the paragraph's base direction.
+func VisualOrder(line string, dir Direction) string {
+	rtl := isRTL(line, dir)
+	if !rtl && !hasRTL(line) {
+		return line
+	}
+	clusters := Graphemes(line)
+	order, levels := visualOrder(clusters, rtl)
+	visual := make([]string, len(clusters))
+	for i, ci := range order {
+		c := clusters[ci]
+		// L4: brackets in right-to-left runs are drawn mirrored
+		if levels[ci]%2 == 1 {
+			r, size := utf8.DecodeRuneInString(c)
+			if m, got := bidiMirrors[r]; got {
+				c = string(m) + c[size:]
+			}
+		}
+		visual[i] = c
+	}
+	return strings.Join(visual, "")
+}
+
+// visualOrder returns the indexes of clusters in the order they are drawn from left to right, and each cluster's level.
+func visualOrder(clusters []string, rtl bool) (order, levels []int) {
+	levels = bidiLevels(clusters, rtl)
+	order = make([]int, len(clusters))
+	visualLevels := make([]int, len(clusters))
+	maxLevel := 0
+	for i, l := range levels {
+		order[i] = i
+		visualLevels[i] = l
+		maxLevel = max(maxLevel, l)
+	}
+	// L2: from the highest level down to the lowest odd one, reverse each run at that level or higher
+	for level := maxLevel; level >= 1; level-- {
+		for i := 0; i < len(order); i++ {
+			if visualLevels[i] < level {
+				continue
+			}
+			j := i
+			for j < len(order) && visualLevels[j] >= level {
+				j++
+			}
+			for a, b := i, j-1; a < b; a, b = a+1, b-1 {
+				order[a], order[b] = order[b], order[a]
+				visualLevels[a], visualLevels[b] = visualLevels[b], visualLevels[a]
+			}
+			i = j - 1
+		}
+	}
+	return order, levels
+}
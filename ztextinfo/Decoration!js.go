@@ -0,0 +1,42 @@
+//go:build !js && zui
+
+package ztextinfo
+
+import (
+	"fmt"
+
+	"github.com/torlangballe/zui/zview"
+)
+
+// SetTextDecoration stores the decoration as the css styles the js version sets, so it can be inspected headless.
+// An underline also sets the view's underline, which zrender draws.
+func SetTextDecoration(v zview.View, d Decoration) {
+	nv := v.Native()
+	nv.SetTextUnderline(d.LinePos == DecorationUnder)
+	if d.LinePos == DecorationPosNone && d.Style == DecorationStyleNone && d.Width == 0 && !d.Color.Valid {
+		nv.SetJSStyle("text-decoration", "inherit")
+		return
+	}
+	switch d.LinePos {
+	case DecorationUnder:
+		nv.SetJSStyle("text-decoration-line", "underline")
+	case DecorationOver:
+		nv.SetJSStyle("text-decoration-line", "overline")
+	case DecorationMiddle:
+		nv.SetJSStyle("text-decoration-line", "line-through")
+	}
+	switch d.Style {
+	case DecorationDashed:
+		nv.SetJSStyle("text-decoration-style", "dashed")
+	case DecorationWavy:
+		nv.SetJSStyle("text-decoration-style", "wavy")
+	case DecorationSolid:
+		nv.SetJSStyle("text-decoration-style", "solid")
+	}
+	if d.Width > 0 {
+		nv.SetJSStyle("text-decoration-thickness", fmt.Sprintf("%gpx", d.Width))
+	}
+	if d.Color.Valid {
+		nv.SetJSStyle("text-decoration-color", d.Color.Hex())
+	}
+}
@@ -0,0 +1,339 @@
+package ztextinfo
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/torlangballe/zui/zcanvas"
+	"github.com/torlangballe/zutil/zfloat"
+	"github.com/torlangballe/zutil/zgeo"
+)
+
+// Run is a part of an attributed text, with its own style. Font and Color that are nil/invalid use the Info's.
+// Set an Info's runs with SetRuns; GetBounds and Draw then lay out and draw them, wrapping across runs.
+// MaxLines and truncating with Wrap apply as for plain text, but runs are always truncated at their tail.
+type Run struct {
+	Text       string
+	Font       *zgeo.Font
+	Color      zgeo.Color
+	Background zgeo.Color // Background is a highlight drawn behind the run's text if valid
+	Decoration Decoration
+	Link       string // Link is a URL or zapp:// path the run opens when pressed, see LinkAtPos and IsSafeLink
+}
+
+// IsSafeLink returns true if link is an http, https or mailto URL, or relative, so it can be opened without running script.
+// Links with other schemes, like javascript:, or that don't parse, are not safe.
+func IsSafeLink(link string) bool {
+	u, err := url.Parse(link) // control characters, that browsers remove from a scheme like java\tscript:, are errors
+	if err != nil {
+		return false
+	}
+	switch u.Scheme {
+	case "", "http", "https", "mailto":
+		return true
+	}
+	return false
+}
+
+// runPiece is a part of a run placed on a line.
+type runPiece struct {
+	run   *Run
+	text  string
+	x     float64
+	width float64
+	rtl   bool // rtl is set for a piece of a visual line that is drawn right-to-left, see visualRunLine
+}
+
+type runLine struct {
+	pieces []runPiece
+	width  float64
+	height float64
+}
+
+// SetRuns sets ti's text to runs; ti.Text is set to all their text.
+func (ti *Info) SetRuns(runs []Run) {
+	ti.Runs = runs
+	var str string
+	for _, r := range runs {
+		str += r.Text
+	}
+	ti.Text = str
+}
+
+func (ti *Info) runFont(r *Run) *zgeo.Font {
+	if r.Font != nil {
+		return r.Font
+	}
+	return ti.Font
+}
+
+// layoutRuns places ti.Runs on lines, breaking at newlines and wrapping to width as WrapLine does, if width isn't 0.
+func (ti *Info) layoutRuns(width float64) []runLine {
+	var lines []runLine
+	var line runLine
+	endLine := func() {
+		// Whitespace at a break is left out, as with WrapLine
+		if n := len(line.pieces); n != 0 {
+			p := &line.pieces[n-1]
+			p.text = strings.TrimRight(p.text, " \t")
+			p.width = measureWidth(p.text, ti.runFont(p.run))
+			line.width = p.x + p.width
+		}
+		if line.height == 0 {
+			line.height = ti.Font.LineHeight()
+		}
+		lines = append(lines, line)
+		line = runLine{}
+	}
+	add := func(r *Run, text string) {
+		font := ti.runFont(r)
+		zfloat.Maximize(&line.height, font.LineHeight())
+		n := len(line.pieces)
+		if n != 0 && line.pieces[n-1].run == r {
+			p := &line.pieces[n-1]
+			p.text += text
+			p.width = measureWidth(p.text, font)
+		} else {
+			line.pieces = append(line.pieces, runPiece{run: r, text: text, x: line.width, width: measureWidth(text, font)})
+		}
+		p := line.pieces[len(line.pieces)-1]
+		line.width = p.x + p.width
+	}
+	fits := func(r *Run, text string) bool {
+		if width == 0 || ti.MaxLines == 1 {
+			return true
+		}
+		n := len(line.pieces)
+		trimmed := strings.TrimRight(text, " \t")
+		if n != 0 && line.pieces[n-1].run == r {
+			p := line.pieces[n-1]
+			return p.x+measureWidth(p.text+trimmed, ti.runFont(r)) <= width
+		}
+		return line.width+measureWidth(trimmed, ti.runFont(r)) <= width
+	}
+	for i := range ti.Runs {
+		r := &ti.Runs[i]
+		wt := *ti
+		wt.Font = ti.runFont(r)
+		for j, para := range strings.Split(r.Text, "\n") {
+			if j > 0 {
+				endLine()
+			}
+			for _, seg := range wt.breakSegments(para) {
+				if len(line.pieces) == 0 {
+					seg = strings.TrimLeft(seg, " \t")
+				}
+				if fits(r, seg) {
+					add(r, seg)
+					continue
+				}
+				if len(line.pieces) != 0 {
+					endLine()
+					seg = strings.TrimLeft(seg, " \t")
+				}
+				for seg != "" && !fits(r, seg) {
+					head, rest := wt.breakWord(seg, width)
+					add(r, head)
+					endLine()
+					seg = rest
+				}
+				if seg != "" {
+					add(r, seg)
+				}
+			}
+		}
+	}
+	if len(line.pieces) != 0 || len(lines) == 0 {
+		endLine()
+	}
+	return ti.limitRunLines(lines, width)
+}
+
+// limitRunLines keeps at most ti.MaxLines of lines. Unless Wrap is WrapNone or WrapClip, the last line kept
+// is truncated with an ellipsis if lines were left out or it is wider than width, as reduceTextToFit does for Text.
+func (ti *Info) limitRunLines(lines []runLine, width float64) []runLine {
+	cut := ti.MaxLines > 0 && len(lines) > ti.MaxLines
+	if cut {
+		lines = lines[:ti.MaxLines]
+	}
+	if ti.Wrap == WrapNone || ti.Wrap == WrapClip || width == 0 {
+		return lines
+	}
+	last := &lines[len(lines)-1]
+	if cut || last.width > width {
+		ti.truncateRunLine(last, width)
+	}
+	return lines
+}
+
+// truncateRunLine removes grapheme clusters from the end of l, until it fits in width with an ellipsis after them.
+func (ti *Info) truncateRunLine(l *runLine, width float64) {
+	for len(l.pieces) != 0 {
+		p := &l.pieces[len(l.pieces)-1]
+		font := ti.runFont(p.run)
+		clusters := Graphemes(p.text)
+		truncated := func(keep int) string {
+			return strings.TrimRight(strings.Join(clusters[:keep], ""), " ") + "…"
+		}
+		// binary search for the most clusters kept that fit, as truncateText does
+		low, high := 0, len(clusters)
+		for low < high {
+			mid := (low + high + 1) / 2
+			if p.x+measureWidth(truncated(mid), font) <= width {
+				low = mid
+			} else {
+				high = mid - 1
+			}
+		}
+		if low != 0 || len(l.pieces) == 1 {
+			p.text = truncated(low)
+			p.width = measureWidth(p.text, font)
+			l.width = p.x + p.width
+			return
+		}
+		l.pieces = l.pieces[:len(l.pieces)-1] // nothing of it fits, so the ellipsis is put after the piece before
+	}
+}
+
+// visualRunLine returns l with its pieces split where their bidi level changes, in the order they are drawn from left to right,
+// as VisualOrder orders Text's lines. Each piece's text is kept in logical order, with rtl set if it is drawn right-to-left.
+func (ti *Info) visualRunLine(l runLine, rtl bool) runLine {
+	var clusters []string
+	var owners []int
+	for i, p := range l.pieces {
+		for _, c := range Graphemes(p.text) {
+			clusters = append(clusters, c)
+			owners = append(owners, i)
+		}
+	}
+	if !rtl && !hasRTL(strings.Join(clusters, "")) {
+		return l
+	}
+	order, levels := visualOrder(clusters, rtl)
+	vl := runLine{height: l.height}
+	for i := 0; i < len(order); {
+		ci := order[i]
+		first, last := ci, ci
+		j := i + 1
+		for j < len(order) && owners[order[j]] == owners[ci] && levels[order[j]] == levels[ci] && (order[j] == first-1 || order[j] == last+1) {
+			first = min(first, order[j])
+			last = max(last, order[j])
+			j++
+		}
+		p := l.pieces[owners[ci]]
+		text := strings.Join(clusters[first:last+1], "")
+		w := measureWidth(text, ti.runFont(p.run))
+		vl.pieces = append(vl.pieces, runPiece{run: p.run, text: text, x: vl.width, width: w, rtl: levels[ci]%2 == 1})
+		vl.width += w
+		i = j
+	}
+	return vl
+}
+
+// runsBounds is GetBounds for ti.Runs.
+func (ti *Info) runsBounds() (size zgeo.Size, allLines []string, widths []float64) {
+	lines := ti.layoutRuns(ti.Rect.Size.W)
+	for _, l := range lines {
+		var str string
+		for _, p := range l.pieces {
+			str += p.text
+		}
+		allLines = append(allLines, str)
+		widths = append(widths, l.width)
+		zfloat.Maximize(&size.W, l.width)
+		size.H += l.height * 1.3
+	}
+	for i := len(lines); i < ti.MinLines; i++ {
+		size.H += ti.Font.LineHeight() * 1.3
+	}
+	return size, allLines, widths
+}
+
+// drawRuns draws ti.Runs within ra, the rect the text's bounds are aligned to in ti.Rect.
+// Each line's pieces are drawn in visual order, see visualRunLine.
+func (ti *Info) drawRuns(canvas *zcanvas.Canvas, ra zgeo.Rect, strokeWidth float64) {
+	y := ra.Pos.Y
+	rtl := ti.IsRTL()
+	for _, l := range ti.layoutRuns(ti.Rect.Size.W) {
+		l = ti.visualRunLine(l, rtl)
+		x := ti.runLineX(ra, l)
+		for _, p := range l.pieces {
+			font := ti.runFont(p.run)
+			r := zgeo.RectFromXYWH(x+p.x, y, p.width, l.height)
+			if p.run.Background.Valid && ti.Type == Fill {
+				canvas.SetColor(p.run.Background)
+				canvas.FillRect(r.ExpandedD(1), 2)
+			}
+			col := p.run.Color
+			if !col.Valid || ti.Type == Stroke {
+				col = ti.Color
+			}
+			canvas.SetColor(col)
+			canvas.SetFont(font, nil)
+			baseline := y + l.height*0.73
+			dir := DirectionLTR
+			if p.rtl {
+				dir = DirectionRTL
+			}
+			drawLine(canvas, zgeo.PosD(r.Pos.X, baseline), p.text, strokeWidth, dir)
+			drawDecoration(canvas, p.run.Decoration, col, r.Pos.X, r.Max().X, baseline, font)
+		}
+		y += l.height
+	}
+}
+
+func (ti *Info) runLineX(ra zgeo.Rect, l runLine) float64 {
+	if ti.Alignment&zgeo.HorCenter != 0 {
+		return ra.Pos.X + (ra.Size.W-l.width)/2
+	}
+	if ti.Alignment&zgeo.Right != 0 {
+		return ra.Pos.X + ra.Size.W - l.width
+	}
+	return ra.Pos.X
+}
+
+// drawDecoration draws d's lines from x1 to x2, relative to the text's baseline.
+// Wavy and dashed lines are drawn solid.
+func drawDecoration(canvas *zcanvas.Canvas, d Decoration, col zgeo.Color, x1, x2, baseline float64, font *zgeo.Font) {
+	if d.LinePos == DecorationPosNone {
+		return
+	}
+	w := d.Width
+	if w == 0 {
+		w = 1
+	}
+	if d.Color.Valid {
+		col = d.Color
+	}
+	canvas.SetColor(col)
+	if d.LinePos&DecorationUnder != 0 {
+		canvas.StrokeHorizontal(x1, x2, baseline+font.Size*0.15, w, zgeo.PathLineSquare)
+	}
+	if d.LinePos&DecorationMiddle != 0 {
+		canvas.StrokeHorizontal(x1, x2, baseline-font.Size*0.3, w, zgeo.PathLineSquare)
+	}
+	if d.LinePos&DecorationOver != 0 {
+		canvas.StrokeHorizontal(x1, x2, baseline-font.Size*0.85, w, zgeo.PathLineSquare)
+	}
+}
+
+// LinkAtPos returns the Link of the run drawn at pos, if ti is drawn in ti.Rect, or "".
+func (tin *Info) LinkAtPos(pos zgeo.Pos) string {
+	ti := *tin
+	ti.Alignment = tin.alignment() // as Draw does
+	size, _, _ := ti.runsBounds()
+	ra := ti.Rect.Align(size, ti.Alignment, ti.Margin)
+	y := ra.Pos.Y
+	rtl := ti.IsRTL()
+	for _, l := range ti.layoutRuns(ti.Rect.Size.W) {
+		l = ti.visualRunLine(l, rtl)
+		x := ti.runLineX(ra, l)
+		for _, p := range l.pieces {
+			if zgeo.RectFromXYWH(x+p.x, y, p.width, l.height).Contains(pos) {
+				return p.run.Link
+			}
+		}
+		y += l.height
+	}
+	return ""
+}
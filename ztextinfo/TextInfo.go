@@ -3,6 +3,7 @@ package ztextinfo
 import (
 	"math"
 	"strings"
+	"unicode"
 	"unicode/utf8"
 
 	"github.com/torlangballe/zui/zcanvas"
@@ -52,6 +53,9 @@ type Info struct {
 	IsMinimumOneLineHight bool
 	SplitItems            []string
 	Margin                zgeo.Size
+	Hyphenate             bool      // Hyphenate adds a hyphen where a word too long for a line is broken
+	Runs                  []Run     // Runs are the text as parts with their own styles if set, see SetRuns
+	Direction             Direction // Direction is the base direction of the text. In right-to-left text Left and Right alignment are mirrored
 }
 
 type DecorationPos int
@@ -120,8 +124,9 @@ func New() *Info {
 }
 
 var count int
-var breakSet = []rune(` \t,.-_!@#$%^&**()=+<>?|:;\/`)
+var breakSet = []rune(" \t,.-_!@#$%^&**()=+<>?|:;\\/")
 
+// Trim1FromRunes removes a grapheme cluster from runes where wrap truncates it, so a letter is never split from its marks.
 func Trim1FromRunes(runes []rune, wrap WrapType) []rune {
 	// zlog.Info("reduceStringByOneToWrap")
 	length := len(runes)
@@ -152,14 +157,17 @@ func Trim1FromRunes(runes []rune, wrap WrapType) []rune {
 
 	case WrapChar:
 	case WrapTailTruncate:
-		return runes[:length-1]
+		clusters := Graphemes(string(runes))
+		return []rune(strings.Join(clusters[:len(clusters)-1], ""))
 
 	case WrapHeadTruncate:
-		return runes[1:]
+		clusters := Graphemes(string(runes))
+		return []rune(strings.Join(clusters[1:], ""))
 
 	case WrapMiddleTruncate:
-		m := length / 2
-		return append(runes[:m], runes[m+1:]...)
+		clusters := Graphemes(string(runes))
+		m := len(clusters) / 2
+		return []rune(strings.Join(clusters[:m], "") + strings.Join(clusters[m+1:], ""))
 	}
 	return nil
 }
@@ -169,24 +177,25 @@ func (ti *Info) SetWidthFreeHight(w float64) {
 }
 
 // GetBounds returns rect of size of text.
-// It is placed within ti.Rect using alignment
-// TODO: Make it handle multi-line with some home-made wrapping stuff.
+// It is placed within ti.Rect using alignment.
+// Unless MaxLines is 1, lines wider than ti.Rect are wrapped, see WrapLine, and widths are each line's measured width.
 func (ti *Info) GetBounds() (size zgeo.Size, allLines []string, widths []float64) {
+	if len(ti.Runs) != 0 {
+		return ti.runsBounds()
+	}
 	maxLines := ti.MaxLines
 	lines := zstr.SplitByAnyOf(ti.Text, ti.SplitItems, false)
 	for _, str := range lines {
 		s := zcanvas.GetTextSize(str, ti.Font)
 		if maxLines != 1 && ti.Rect.Size.W != 0 {
-			split := s.W / ti.Rect.Size.W
-			if split > 1 {
-				rlen := utf8.RuneCountInString(str)
-				each := int(math.Ceil(float64(rlen) / split))
-				rlines := zstr.BreakRunesIntoLines([]rune(str), "", each)
-				for _, rline := range rlines {
-					allLines = append(allLines, string(rline))
-					widths = append(widths, float64(len(rline))/float64(rlen)*s.W)
+			if s.W > ti.Rect.Size.W {
+				wlines, wwidths := ti.WrapLine(str, ti.Rect.Size.W)
+				allLines = append(allLines, wlines...)
+				widths = append(widths, wwidths...)
+				s.W = 0
+				for _, w := range wwidths {
+					zfloat.Maximize(&s.W, w)
 				}
-				s.W = ti.Rect.Size.W
 			} else {
 				allLines = append(allLines, str)
 				widths = append(widths, s.W)
@@ -219,6 +228,104 @@ func (ti *Info) GetBounds() (size zgeo.Size, allLines []string, widths []float64
 	return size, allLines, widths
 }
 
+// measureWidth is a variable so tests can measure without a canvas.
+var measureWidth = func(str string, font *zgeo.Font) float64 {
+	return zcanvas.GetTextSize(str, font).W
+}
+
+// WrapLine breaks str, which has no newlines, into lines no wider than width in ti.Font, returning them and their widths.
+// With WrapChar it breaks anywhere, otherwise after a rune in breakSet, like space, - or /, so words are kept whole.
+// Words wider than a line are broken where they must, with a hyphen if ti.Hyphenate.
+// Whitespace at a break is left out of both lines.
+func (ti *Info) WrapLine(str string, width float64) (lines []string, widths []float64) {
+	var line string
+	add := func(l string) {
+		l = strings.TrimRight(l, " \t")
+		lines = append(lines, l)
+		widths = append(widths, measureWidth(l, ti.Font))
+	}
+	for _, seg := range ti.breakSegments(str) {
+		if line == "" {
+			seg = strings.TrimLeft(seg, " \t")
+		}
+		candidate := line + seg
+		if measureWidth(strings.TrimRight(candidate, " \t"), ti.Font) <= width {
+			line = candidate
+			continue
+		}
+		if line != "" {
+			add(line)
+			line = ""
+			seg = strings.TrimLeft(seg, " \t")
+		}
+		for seg != "" && measureWidth(strings.TrimRight(seg, " \t"), ti.Font) > width {
+			head, rest := ti.breakWord(seg, width)
+			add(head)
+			seg = rest
+		}
+		line = seg
+	}
+	if line != "" || len(lines) == 0 {
+		add(line)
+	}
+	return lines, widths
+}
+
+// breakSegments splits str into the pieces that can start a new line.
+func (ti *Info) breakSegments(str string) []string {
+	var segs []string
+	clusters := Graphemes(str)
+	if ti.Wrap == WrapChar {
+		return clusters
+	}
+	isBreak := func(c string) bool {
+		r, _ := utf8.DecodeRuneInString(c)
+		return zstr.IndexOfRuneInSet(r, breakSet) != -1
+	}
+	start := 0
+	for i, c := range clusters {
+		nextIsBreak := i+1 < len(clusters) && isBreak(clusters[i+1])
+		if isBreak(c) && !nextIsBreak {
+			segs = append(segs, strings.Join(clusters[start:i+1], ""))
+			start = i + 1
+		}
+	}
+	if start < len(clusters) {
+		segs = append(segs, strings.Join(clusters[start:], ""))
+	}
+	return segs
+}
+
+// breakWord returns the longest start of word that fits in width, with a hyphen if ti.Hyphenate, and the rest.
+// At least one grapheme cluster is returned in head, so wrapping always progresses.
+func (ti *Info) breakWord(word string, width float64) (head, rest string) {
+	clusters := Graphemes(word)
+	hyphen := ""
+	if ti.Hyphenate {
+		hyphen = "-"
+	}
+	// binary search for the most clusters that fit
+	low, high := 1, len(clusters)
+	for low < high {
+		mid := (low + high + 1) / 2
+		if measureWidth(strings.Join(clusters[:mid], "")+hyphen, ti.Font) <= width {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	head = strings.Join(clusters[:low], "")
+	if ti.Hyphenate && low < len(clusters) && isLetterCluster(clusters[low-1]) && isLetterCluster(clusters[low]) {
+		head += hyphen
+	}
+	return head, strings.Join(clusters[low:], "")
+}
+
+func isLetterCluster(c string) bool {
+	r, _ := utf8.DecodeRuneInString(c)
+	return unicode.IsLetter(r)
+}
+
 func (ti *Info) MakeAttributes() zdict.Dict {
 	return zdict.Dict{}
 }
@@ -227,6 +334,13 @@ func reduceTextToFit(ti *Info) {
 	if ti.Wrap == WrapClip || ti.Wrap == WrapNone {
 		return
 	}
+	switch ti.Wrap {
+	case WrapHeadTruncate, WrapTailTruncate, WrapMiddleTruncate:
+		ti.Text = truncateText(ti.Text, ti.Wrap, func(str string) bool {
+			return measureWidth(str, ti.Font) <= ti.Rect.Size.W
+		})
+		return
+	}
 	runes := []rune(ti.Text)
 	for {
 		s := zcanvas.GetTextSize(ti.Text, ti.Font)
@@ -246,6 +360,45 @@ func reduceTextToFit(ti *Info) {
 	}
 }
 
+// truncateText returns text with as many grapheme clusters as fits, and an ellipsis where wrap truncates it.
+// Head and tail are logical, so the tail of right-to-left text is at its left.
+func truncateText(text string, wrap WrapType, fits func(str string) bool) string {
+	if fits(text) {
+		return text
+	}
+	clusters := Graphemes(text)
+	n := len(clusters)
+	truncated := func(keep int) string {
+		switch wrap {
+		case WrapHeadTruncate:
+			return "…" + strings.TrimLeft(strings.Join(clusters[n-keep:], ""), " ")
+		case WrapMiddleTruncate:
+			head := (keep + 1) / 2
+			return strings.TrimRight(strings.Join(clusters[:head], ""), " ") + "…" + strings.TrimLeft(strings.Join(clusters[n-(keep-head):], ""), " ")
+		}
+		return strings.TrimRight(strings.Join(clusters[:keep], ""), " ") + "…"
+	}
+	// binary search for the most clusters kept that fit
+	low, high := 0, n-1
+	for low < high {
+		mid := (low + high + 1) / 2
+		if fits(truncated(mid)) {
+			low = mid
+		} else {
+			high = mid - 1
+		}
+	}
+	return truncated(low)
+}
+
+// alignment is ti.Alignment, mirrored horizontally for right-to-left text.
+func (ti *Info) alignment() zgeo.Alignment {
+	if ti.IsRTL() {
+		return ti.Alignment.FlippedHorizontal()
+	}
+	return ti.Alignment
+}
+
 // StrokeAndFill strokes the text with *strokeColor* and width, then fills with ti.Color
 // canvas' width, color and stroke style are changed
 func (ti *Info) StrokeAndFill(canvas *zcanvas.Canvas, strokeColor zgeo.Color, width float64) zgeo.Rect {
@@ -262,6 +415,10 @@ func (ti *Info) StrokeAndFill(canvas *zcanvas.Canvas, strokeColor zgeo.Color, wi
 
 func (tin *Info) Draw(canvas *zcanvas.Canvas) zgeo.Rect {
 	ti := *tin
+	ti.Alignment = tin.alignment()
+	if ti.Direction == DirectionAuto {
+		ti.Direction = BaseDirection(ti.Text) // wrapped and truncated lines keep the direction of the whole text
+	}
 	w := 0.0
 	if ti.Text == "" {
 		return zgeo.Rect{Pos: ti.Rect.Pos, Size: zgeo.SizeNull}
@@ -284,13 +441,22 @@ func (tin *Info) Draw(canvas *zcanvas.Canvas) zgeo.Rect {
 	// fmt.Println("CANVAS TI SetFont:", font)
 	canvas.SetFont(ti.Font, nil)
 	if ti.Rect.Size.IsNull() {
-		canvas.DrawTextInPos(ti.Rect.Pos, ti.Text, w)
+		drawLine(canvas, ti.Rect.Pos, ti.Text, w, ti.Direction)
 		return zgeo.Rect{}
 	}
-	reduceTextToFit(&ti)
+	if len(ti.Runs) == 0 {
+		reduceTextToFit(&ti)
+	}
 	ts, lines, widths := ti.GetBounds()
 	ts = zgeo.SizeD(math.Ceil(ts.W), math.Ceil(ts.H))
 	ra := ti.Rect.Align(ts, ti.Alignment, ti.Margin)
+	if len(ti.Runs) != 0 {
+		ti.drawRuns(canvas, ra, w)
+		if !tin.Rect.IsNull() {
+			canvas.PopState()
+		}
+		return ra
+	}
 	// https://stackoverflow.com/questions/5026961/html5-canvas-ctx-filltext-wont-do-line-breaks/21574562#21574562
 	h := ti.Font.LineHeight()
 	y := ra.Pos.Y + h*0.73 // 0.71
@@ -304,7 +470,7 @@ func (tin *Info) Draw(canvas *zcanvas.Canvas) zgeo.Rect {
 				x += (ra.Size.W - widths[i])
 			}
 		}
-		canvas.DrawTextInPos(zgeo.PosD(x, y), s, w)
+		drawLine(canvas, zgeo.PosD(x, y), s, w, ti.Direction)
 		y += h
 	}
 	if !tin.Rect.IsNull() {
@@ -369,5 +535,5 @@ func (ti *Info) GetColumnsSize(cols int) zgeo.Size {
 func (ti *Info) GetRect() zgeo.Rect {
 	zlog.Assert(!ti.Rect.IsNull())
 	box, _, _ := ti.GetBounds()
-	return ti.Rect.Align(box, ti.Alignment, ti.Margin)
+	return ti.Rect.Align(box, ti.alignment(), ti.Margin)
 }
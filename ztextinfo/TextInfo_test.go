@@ -1,6 +1,7 @@
 package ztextinfo
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/torlangballe/zutil/zgeo"
@@ -17,3 +18,176 @@ func TestWrapping(t *testing.T) {
 		t.Error("Not 3/40:", s, len(lines))
 	}
 }
+
+func TestWrapLine(t *testing.T) {
+	old := measureWidth
+	defer func() {
+		measureWidth = old
+	}()
+	measureWidth = func(str string, font *zgeo.Font) float64 {
+		return float64(len([]rune(str))) * 10
+	}
+	ti := New()
+	ti.Wrap = WrapWord
+	lines, widths := ti.WrapLine("the quick brown fox jumps", 100)
+	if len(lines) != 3 || lines[0] != "the quick" || lines[1] != "brown fox" || lines[2] != "jumps" || widths[0] != 90 {
+		t.Error("word wrap:", lines, widths)
+	}
+	ti.Hyphenate = true
+	lines, _ = ti.WrapLine("a supercalifragilistic word", 100)
+	if len(lines) != 4 || lines[0] != "a" || lines[1] != "supercali-" || lines[2] != "fragilist-" || lines[3] != "ic word" {
+		t.Error("hyphenated:", lines)
+	}
+	ti.Wrap = WrapChar
+	lines, _ = ti.WrapLine("abcdefghijkl", 50)
+	if len(lines) != 3 || lines[0] != "abcde" || lines[2] != "kl" {
+		t.Error("char wrap:", lines)
+	}
+}
+
+func TestVisualOrder(t *testing.T) {
+	tests := []struct {
+		text string
+		dir  Direction
+		want string
+	}{
+		{"abc שלום def", DirectionAuto, "abc םולש def"},
+		{"שלום (עולם) 123!", DirectionAuto, "!123 (םלוע) םולש"},
+		{"hello", DirectionRTL, "hello"},
+		{"مرحبا ١٢٣ 45", DirectionAuto, "45 ١٢٣ ابحرم"},
+	}
+	for _, test := range tests {
+		got := VisualOrder(test.text, test.dir)
+		if got != test.want {
+			t.Error("visual order:", test.text, "got:", got, "want:", test.want)
+		}
+	}
+}
+
+func TestTruncateGraphemes(t *testing.T) {
+	const text = "ne\u0301e\u0301abcde\u0301" // é is e with a combining accent
+	fits := func(str string) bool {
+		return len(Graphemes(str)) <= 6
+	}
+	tests := map[WrapType]string{
+		WrapTailTruncate:   "ne\u0301e\u0301ab…",
+		WrapHeadTruncate:   "…abcde\u0301",
+		WrapMiddleTruncate: "ne\u0301e\u0301…de\u0301",
+	}
+	for wrap, want := range tests {
+		got := truncateText(text, wrap, fits)
+		if got != want {
+			t.Errorf("%s: got %q want %q", wrap, got, want)
+		}
+	}
+}
+
+func runLineTexts(lines []runLine) []string {
+	var texts []string
+	for _, l := range lines {
+		var str string
+		for _, p := range l.pieces {
+			str += p.text
+		}
+		texts = append(texts, str)
+	}
+	return texts
+}
+
+func TestRunsMaxLinesAndTruncation(t *testing.T) {
+	old := measureWidth
+	defer func() {
+		measureWidth = old
+	}()
+	measureWidth = func(str string, font *zgeo.Font) float64 {
+		return float64(len([]rune(str))) * 10
+	}
+	ti := New()
+	ti.Wrap = WrapWord
+	ti.SetRuns([]Run{{Text: "the quick "}, {Text: "brown fox jumps over", Link: "x"}})
+	lines := ti.layoutRuns(100)
+	if got := runLineTexts(lines); len(got) != 3 || got[0] != "the quick" || got[1] != "brown fox" || got[2] != "jumps over" {
+		t.Error("wrapped:", got)
+	}
+	ti.MaxLines = 2
+	lines = ti.layoutRuns(100)
+	if got := runLineTexts(lines); len(got) != 2 || got[1] != "brown fox…" || lines[1].width > 100 {
+		t.Error("max lines truncated:", got, lines[len(lines)-1].width)
+	}
+	ti.Wrap = WrapClip
+	if got := runLineTexts(ti.layoutRuns(100)); len(got) != 2 || got[1] != "brown fox" {
+		t.Error("max lines clipped:", got)
+	}
+	ti.Wrap = WrapTailTruncate
+	ti.MaxLines = 1
+	lines = ti.layoutRuns(100)
+	if got := runLineTexts(lines); len(got) != 1 || got[0] != "the quick…" || len(lines[0].pieces) != 1 {
+		t.Error("one line:", got)
+	}
+	ti.SetRuns([]Run{{Text: "abcdefghi"}, {Text: "jk"}})
+	lines = ti.layoutRuns(100)
+	if got := runLineTexts(lines); got[0] != "abcdefghi…" || len(lines[0].pieces) != 1 {
+		t.Error("ellipsis after earlier run:", got, len(lines[0].pieces))
+	}
+	if size, _, _ := ti.runsBounds(); size.H != ti.Font.LineHeight()*1.3 {
+		t.Error("bounds not of max lines:", size)
+	}
+}
+
+func TestRunsVisualOrder(t *testing.T) {
+	old := measureWidth
+	defer func() {
+		measureWidth = old
+	}()
+	measureWidth = func(str string, font *zgeo.Font) float64 {
+		return float64(len([]rune(str))) * 10
+	}
+	type piece struct {
+		text string
+		x    float64
+		rtl  bool
+	}
+	tests := []struct {
+		runs []Run
+		rtl  bool
+		want []piece
+	}{
+		{[]Run{{Text: "abc "}, {Text: "de"}}, false, []piece{{"abc ", 0, false}, {"de", 40, false}}},
+		{[]Run{{Text: "ab שלום"}, {Text: " עולם"}}, false, []piece{{"ab ", 0, false}, {" עולם", 30, true}, {"שלום", 80, true}}},
+		{[]Run{{Text: "שלום "}, {Text: "abc"}}, true, []piece{{"abc", 0, false}, {"שלום ", 30, true}}},
+	}
+	for _, test := range tests {
+		ti := New()
+		ti.SetRuns(test.runs)
+		var got []piece
+		for _, p := range ti.visualRunLine(ti.layoutRuns(0)[0], test.rtl).pieces {
+			got = append(got, piece{p.text, p.x, p.rtl})
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%v: got %+v, want %+v", test.runs, got, test.want)
+		}
+	}
+}
+
+func TestIsSafeLink(t *testing.T) {
+	tests := map[string]bool{
+		"https://example.com/a?b=c": true,
+		"HTTP://example.com":        true,
+		"mailto:bob@example.com":    true,
+		"doc/guide.md#setup":        true,
+		"/path":                     true,
+		"//example.com":             true,
+		"javascript:alert(1)":       false,
+		"JavaScript:alert(1)":       false,
+		" javascript:alert(1)":      false,
+		"java\tscript:alert(1)":     false,
+		"data:text/html,<script>":   false,
+		"vbscript:msgbox":           false,
+		"file:///etc/passwd":        false,
+	}
+	for link, want := range tests {
+		if got := IsSafeLink(link); got != want {
+			t.Errorf("%q: got %v, want %v", link, got, want)
+		}
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !js
+
+package ztextinfo
+
+import (
+	"github.com/torlangballe/zui/zcanvas"
+	"github.com/torlangballe/zutil/zgeo"
+)
+
+// drawLine draws a line of text at pos. Go canvases draw runes left to right, so it is reordered with VisualOrder first.
+func drawLine(canvas *zcanvas.Canvas, pos zgeo.Pos, text string, strokeWidth float64, dir Direction) {
+	canvas.DrawTextInPos(pos, VisualOrder(text, dir), strokeWidth)
+}
@@ -3,9 +3,26 @@ package ztextinfo
 import (
 	"fmt"
 
+	"github.com/torlangballe/zui/zcanvas"
 	"github.com/torlangballe/zui/zview"
+	"github.com/torlangballe/zutil/zgeo"
 )
 
+// drawLine draws a line of text at pos. The browser reorders bidirectional text itself,
+// but needs the paragraph's direction to place neutrals like punctuation.
+func drawLine(canvas *zcanvas.Canvas, pos zgeo.Pos, text string, strokeWidth float64, dir Direction) {
+	if !isRTL(text, dir) {
+		canvas.DrawTextInPos(pos, text, strokeWidth)
+		return
+	}
+	context := canvas.JSContext()
+	context.Set("direction", "rtl")
+	context.Set("textAlign", "left") // with rtl, the default start alignment would put pos at the right end
+	canvas.DrawTextInPos(pos, text, strokeWidth)
+	context.Set("direction", "inherit")
+	context.Set("textAlign", "start")
+}
+
 func set(v *zview.NativeView, add, val string) {
 	v.JSStyle().Set("text-decoration-"+add, val)
 }
@@ -0,0 +1,181 @@
+// Package zundo keeps stacks of undoable changes, so they can be reverted and redone.
+// Changes to structs are recorded by their StrID with their values before and after, see ItemsAction.
+package zundo
+
+import (
+	"sync"
+)
+
+// Action is a change that can be undone and redone.
+type Action struct {
+	Name string // Name describes the change for menus, like "Edit 3 channels".
+	Undo func()
+	Redo func()
+}
+
+// Manager has a stack of actions that can be undone, and of undone actions that can be redone.
+// Adding an action clears what can be redone.
+type Manager struct {
+	MaxActions  int    // MaxActions is how many actions are kept for undo, the oldest are dropped.
+	ChangedFunc func() // ChangedFunc is called when actions are added, undone or redone, to update menus etc.
+	lock        sync.Mutex
+	undos       []Action
+	redos       []Action
+}
+
+// ItemChange is a change to an item with ID. Before is nil if it was created, After is nil if it was deleted.
+type ItemChange[S any] struct {
+	ID     string
+	Before *S
+	After  *S
+}
+
+func NewManager() *Manager {
+	m := &Manager{}
+	m.MaxActions = 50
+	return m
+}
+
+// Add pushes a done action, so it can be undone.
+func (m *Manager) Add(a Action) {
+	m.lock.Lock()
+	m.undos = append(m.undos, a)
+	if m.MaxActions > 0 && len(m.undos) > m.MaxActions {
+		m.undos = m.undos[len(m.undos)-m.MaxActions:]
+	}
+	m.redos = nil
+	m.lock.Unlock()
+	m.changed()
+}
+
+// Undo undoes the last action done or redone, returning false if there is none.
+func (m *Manager) Undo() bool {
+	m.lock.Lock()
+	if len(m.undos) == 0 {
+		m.lock.Unlock()
+		return false
+	}
+	a := m.undos[len(m.undos)-1]
+	m.undos = m.undos[:len(m.undos)-1]
+	m.redos = append(m.redos, a)
+	m.lock.Unlock()
+	a.Undo()
+	m.changed()
+	return true
+}
+
+// Redo redoes the last action undone, returning false if there is none.
+func (m *Manager) Redo() bool {
+	m.lock.Lock()
+	if len(m.redos) == 0 {
+		m.lock.Unlock()
+		return false
+	}
+	a := m.redos[len(m.redos)-1]
+	m.redos = m.redos[:len(m.redos)-1]
+	m.undos = append(m.undos, a)
+	m.lock.Unlock()
+	a.Redo()
+	m.changed()
+	return true
+}
+
+// UndoName returns the name of the action Undo would undo, and if there is one.
+func (m *Manager) UndoName() (string, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if len(m.undos) == 0 {
+		return "", false
+	}
+	return m.undos[len(m.undos)-1].Name, true
+}
+
+// RedoName returns the name of the action Redo would redo, and if there is one.
+func (m *Manager) RedoName() (string, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if len(m.redos) == 0 {
+		return "", false
+	}
+	return m.redos[len(m.redos)-1].Name, true
+}
+
+// Clear removes all actions, for instance when what they changed is reloaded.
+func (m *Manager) Clear() {
+	m.lock.Lock()
+	m.undos = nil
+	m.redos = nil
+	m.lock.Unlock()
+	m.changed()
+}
+
+func (m *Manager) changed() {
+	if m.ChangedFunc != nil {
+		m.ChangedFunc()
+	}
+}
+
+// ItemChanges pairs items before and after a change by the ID idFunc gets for them.
+// Items only in before were deleted, items only in after created.
+func ItemChanges[S any](before, after []S, idFunc func(item *S) string) []ItemChange[S] {
+	var changes []ItemChange[S]
+	index := map[string]int{}
+	for i := range before {
+		b := before[i]
+		id := idFunc(&b)
+		index[id] = len(changes)
+		changes = append(changes, ItemChange[S]{ID: id, Before: &b})
+	}
+	for i := range after {
+		a := after[i]
+		id := idFunc(&a)
+		ci, got := index[id]
+		if got {
+			changes[ci].After = &a
+			continue
+		}
+		changes = append(changes, ItemChange[S]{ID: id, After: &a})
+	}
+	return changes
+}
+
+// ItemsAction makes an action that undoes and redoes changes. Items that changed are stored with store,
+// items that didn't exist are put back with restore, or store if it is nil, and items that shouldn't exist are deleted.
+// Note that a store that only saves changes to existing items won't put back deleted ones; pass a restore that does.
+func ItemsAction[S any](name string, changes []ItemChange[S], store, restore func(items []S), delete func(ids []string)) Action {
+	if restore == nil {
+		restore = store
+	}
+	apply := func(undo bool) {
+		var stored, restored []S
+		var deleteIDs []string
+		for _, c := range changes {
+			from, to := c.Before, c.After
+			if undo {
+				from, to = to, from
+			}
+			switch {
+			case to == nil:
+				deleteIDs = append(deleteIDs, c.ID)
+			case from == nil:
+				restored = append(restored, *to)
+			default:
+				stored = append(stored, *to)
+			}
+		}
+		if len(stored) != 0 {
+			store(stored)
+		}
+		if len(restored) != 0 {
+			restore(restored)
+		}
+		if len(deleteIDs) != 0 {
+			delete(deleteIDs)
+		}
+	}
+	return Action{
+		Name: name,
+		Undo: func() { apply(true) },
+		Redo: func() { apply(false) },
+	}
+}
@@ -0,0 +1,181 @@
+package zundo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// addSetter adds an action that sets *val to to, from what it was, and sets it.
+func addSetter(m *Manager, val *string, to string) {
+	from := *val
+	*val = to
+	m.Add(Action{
+		Name: "Set " + to,
+		Undo: func() { *val = from },
+		Redo: func() { *val = to },
+	})
+}
+
+func TestManagerUndoRedo(t *testing.T) {
+	m := NewManager()
+	changes := 0
+	m.ChangedFunc = func() { changes++ }
+	if m.Undo() || m.Redo() {
+		t.Error("undo or redo with nothing done")
+	}
+	val := "a"
+	addSetter(m, &val, "b")
+	addSetter(m, &val, "c")
+	if name, can := m.UndoName(); !can || name != "Set c" {
+		t.Error("undo name:", name, can)
+	}
+	if _, can := m.RedoName(); can {
+		t.Error("redo before undo")
+	}
+	if !m.Undo() || val != "b" || !m.Undo() || val != "a" || m.Undo() {
+		t.Error("undo twice:", val)
+	}
+	if name, can := m.RedoName(); !can || name != "Set b" {
+		t.Error("redo name:", name, can)
+	}
+	if !m.Redo() || val != "b" {
+		t.Error("redo:", val)
+	}
+	if name, _ := m.UndoName(); name != "Set b" {
+		t.Error("redone not undoable:", name)
+	}
+	if changes != 5 {
+		t.Error("changed calls:", changes)
+	}
+}
+
+func TestManagerAddClearsRedo(t *testing.T) {
+	m := NewManager()
+	val := "a"
+	addSetter(m, &val, "b")
+	addSetter(m, &val, "c")
+	m.Undo()
+	addSetter(m, &val, "d")
+	if _, can := m.RedoName(); can || m.Redo() {
+		t.Error("redo kept after new action")
+	}
+	m.Undo()
+	m.Undo()
+	if val != "a" {
+		t.Error("undo past new action:", val)
+	}
+}
+
+func TestManagerMaxActionsAndClear(t *testing.T) {
+	m := NewManager()
+	m.MaxActions = 2
+	val := "a"
+	for _, s := range []string{"b", "c", "d"} {
+		addSetter(m, &val, s)
+	}
+	for m.Undo() {
+	}
+	if val != "b" {
+		t.Error("oldest action not dropped:", val)
+	}
+	m.Clear()
+	if _, can := m.UndoName(); can {
+		t.Error("undo after clear")
+	}
+	if _, can := m.RedoName(); can {
+		t.Error("redo after clear")
+	}
+}
+
+type testItem struct {
+	ID   string
+	Name string
+}
+
+func testItemID(item *testItem) string {
+	return item.ID
+}
+
+func TestItemChanges(t *testing.T) {
+	before := []testItem{{"1", "a"}, {"2", "b"}}
+	after := []testItem{{"2", "B"}, {"3", "c"}}
+	changes := ItemChanges(before, after, testItemID)
+	want := []ItemChange[testItem]{
+		{ID: "1", Before: &testItem{"1", "a"}},
+		{ID: "2", Before: &testItem{"2", "b"}, After: &testItem{"2", "B"}},
+		{ID: "3", After: &testItem{"3", "c"}},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got %+v", changes)
+	}
+	before[1].Name = "x"
+	if changes[1].Before.Name != "b" {
+		t.Error("changes share items with before")
+	}
+}
+
+// testStore is a store of items by id, with the calls made to it.
+type testStore struct {
+	items map[string]string
+	calls []string
+}
+
+func (s *testStore) store(items []testItem) {
+	var ids []string
+	for _, item := range items {
+		if _, got := s.items[item.ID]; got { // like a grid's store, which only saves existing items
+			s.items[item.ID] = item.Name
+		}
+		ids = append(ids, item.ID)
+	}
+	s.calls = append(s.calls, "store "+strings.Join(ids, ","))
+}
+
+func (s *testStore) restore(items []testItem) {
+	var ids []string
+	for _, item := range items {
+		s.items[item.ID] = item.Name
+		ids = append(ids, item.ID)
+	}
+	s.calls = append(s.calls, "restore "+strings.Join(ids, ","))
+}
+
+func (s *testStore) delete(ids []string) {
+	for _, id := range ids {
+		delete(s.items, id)
+	}
+	s.calls = append(s.calls, "delete "+strings.Join(ids, ","))
+}
+
+func TestItemsAction(t *testing.T) {
+	s := &testStore{items: map[string]string{"2": "B", "3": "C", "4": "d"}}
+	before := []testItem{{"1", "a"}, {"2", "b"}, {"3", "c"}}
+	after := []testItem{{"2", "B"}, {"3", "C"}, {"4", "d"}}
+	a := ItemsAction("Change", ItemChanges(before, after, testItemID), s.store, s.restore, s.delete)
+	a.Undo()
+	wantItems := map[string]string{"1": "a", "2": "b", "3": "c"}
+	if !reflect.DeepEqual(s.items, wantItems) {
+		t.Error("undo:", s.items)
+	}
+	// Each kind of change is grouped in one call, so a grid stores all its rows at once.
+	if !reflect.DeepEqual(s.calls, []string{"store 2,3", "restore 1", "delete 4"}) {
+		t.Error("undo calls:", s.calls)
+	}
+	s.calls = nil
+	a.Redo()
+	wantItems = map[string]string{"2": "B", "3": "C", "4": "d"}
+	if !reflect.DeepEqual(s.items, wantItems) {
+		t.Error("redo:", s.items)
+	}
+	if !reflect.DeepEqual(s.calls, []string{"store 2,3", "restore 4", "delete 1"}) {
+		t.Error("redo calls:", s.calls)
+	}
+
+	s = &testStore{items: map[string]string{}}
+	a = ItemsAction("Delete", ItemChanges([]testItem{{"1", "a"}}, nil, testItemID), s.store, nil, s.delete)
+	a.Undo()
+	if !reflect.DeepEqual(s.calls, []string{"store 1"}) {
+		t.Error("store used without restore:", s.calls)
+	}
+}
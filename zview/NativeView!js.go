@@ -3,115 +3,871 @@
 package zview
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/torlangballe/zui/zcursor"
 	"github.com/torlangballe/zui/zkeyboard"
-	"github.com/torlangballe/zui/zstyle"
+	"github.com/torlangballe/zutil/zbits"
 	"github.com/torlangballe/zutil/zbool"
 	"github.com/torlangballe/zutil/zgeo"
+	"github.com/torlangballe/zutil/zlog"
+	"github.com/torlangballe/zutil/zstr"
 )
 
+// This is the headless, non-js implementation of NativeView.
+// Instead of a DOM element, each view stores its geometry, hierarchy and appearance in memory,
+// so views can be created, added, arranged and inspected in plain go tests.
+// Nothing is drawn here; see zcanvas for painting a headless tree to an image.
+
 type baseNativeView struct {
+	kind            string // what would be the element type in js; div, label, button etc
+	parent          *NativeView
+	children        []*NativeView
+	rect            zgeo.Rect
+	hasSize         bool
+	objectName      string
+	text            string
+	toolTip         string
+	color           zgeo.Color
+	bgColor         zgeo.Color
+	transparency    float32
+	corner          float64
+	cornerAlign     zgeo.Alignment
+	stroke          HeadlessStroke
+	dropShadows     []zgeo.DropShadow
+	font            *zgeo.Font
+	underlined      bool
+	hidden          bool
+	disabled        bool
+	nonInteractive  bool
+	canTabFocus     bool
+	zIndex          int
+	contentOffset   zgeo.Pos
+	properties      map[string]any
+	style           map[string]string
+	pressHandlers   map[string]func()
+	downHandlers    map[string]func() bool
+	doublePressed   func()
+	keyHandler      func(km zkeyboard.KeyMod, down bool) bool
+	focusHandler    func(focus bool)
+	onInputHandler  func()
+	scrollHandler   func(pos zgeo.Pos)
+	exposedHandler  func(intersects bool)
+	focusInChildren func(view View, focused bool)
+}
+
+// HeadlessStroke is the border set with SetStroke in a headless view.
+type HeadlessStroke struct {
+	Width float64
+	Color zgeo.Color
+	Inset bool
+}
+
+type AddHandler interface {
+	HandleAddAsChild()
+}
+
+var (
+	SetPresentReadyFunc   func(v View, beforeWindow bool)
+	FindLayoutCellForView func(v View) *zgeo.LayoutCell
+
+	idCount     int
+	focusedView *NativeView // there is only one focused view in a headless world
+)
+
+// MakeHeadless is the non-js counterpart of MakeJSElement.
+// kind is what the element type would have been in a browser, and is used when rendering.
+func (v *NativeView) MakeHeadless(view View, kind string) {
+	v.View = view
+	v.kind = kind
+	v.zIndex = BaseZIndex
+	v.style = map[string]string{}
+	v.properties = map[string]any{}
+}
+
+// Kind returns the element type given in MakeHeadless.
+func (v *NativeView) Kind() string {
+	return v.kind
+}
+
+func (v *NativeView) Parent() *NativeView {
+	if v.parent != nil && v.parent.View != nil {
+		nv := v.parent.View.Native()
+		if nv != nil {
+			return nv
+		}
+	}
+	return v.parent
+}
+
+// NativeChildren returns the views added with AddChild, in order.
+func (v *NativeView) NativeChildren() []*NativeView {
+	return v.children
+}
+
+func (v *NativeView) Child(path string) View {
+	return ChildOfViewFunc(v.View, path)
+}
+
+func (v *NativeView) Native() *NativeView {
+	return v
+}
+
+func (v *NativeView) SetTop(top float64) {
+	v.rect.Pos.Y = top
+}
+
+func (v *NativeView) SetLeft(left float64) {
+	v.rect.Pos.X = left
+}
+
+func (v *NativeView) SetWidth(w float64) {
+	v.rect.Size.W = w
+	v.hasSize = true
+}
+
+func (v *NativeView) SetHeight(h float64) {
+	v.rect.Size.H = h
+	v.hasSize = true
+}
+
+func (v *NativeView) SetPos(pos zgeo.Pos) {
+	v.rect.Pos = pos
+}
+
+func (v *NativeView) SetSize(size zgeo.Size) {
+	v.rect.Size = size
+	v.hasSize = true
+}
+
+func (v *NativeView) SetRect(rect zgeo.Rect) {
+	v.rect = rect.ExpandedToInt()
+	v.hasSize = true
+}
+
+func (v *NativeView) HasSize() bool {
+	return v.hasSize
+}
+
+func (v *NativeView) Rect() zgeo.Rect {
+	return v.rect
+}
+
+func (v *NativeView) CalculatedSize(total zgeo.Size) (s, max zgeo.Size) {
+	return zgeo.SizeD(10, 10), zgeo.Size{}
+}
+
+func (v *NativeView) LocalRect() zgeo.Rect {
+	return zgeo.Rect{Size: v.rect.Size}
+}
+
+func (v *NativeView) SetLocalRect(rect zgeo.Rect) {
+	v.SetSize(rect.Size)
+}
+
+func (v *NativeView) ObjectName() string {
+	if v == nil {
+		return "<nil>"
+	}
+	return v.objectName
+}
+
+func (v *NativeView) SetObjectName(name string) {
+	v.objectName = name
+	idCount++
+	v.JSSet("id", fmt.Sprintf("%s-%d", name, idCount))
+}
+
+func (v *NativeView) SetColor(c zgeo.Color) {
+	v.color = c
+}
+
+func (v *NativeView) SetCursor(cursor zcursor.Type) {
+	v.SetJSStyle("cursor", string(cursor))
+}
+
+func (v *NativeView) Color() zgeo.Color {
+	return v.color
+}
+
+// SetJSStyle stores key/value, so code setting css styles can still be inspected headless.
+func (v *NativeView) SetJSStyle(key, value string) {
+	if v.style == nil {
+		v.style = map[string]string{}
+	}
+	v.style[key] = value
+}
+
+// JSStyleValue returns what was set with SetJSStyle for key.
+func (v *NativeView) JSStyleValue(key string) string {
+	return v.style[key]
+}
+
+func (v *NativeView) SetAlpha(alpha float32) {
+	v.transparency = 1 - alpha
 }
 
-type LongPresser struct{}
-
-// func NativeViewAddToRoot(v View)                                                                  {}
-func (v *NativeView) GetView() *NativeView                                   { return v }
-func (v *NativeView) Child(path string) View                                 { return nil }
-func (v *NativeView) Rect() zgeo.Rect                                        { return zgeo.Rect{} }
-func (v *NativeView) LocalRect() zgeo.Rect                                   { return zgeo.Rect{} }
-func (v *NativeView) Parent() *NativeView                                    { return nil }
-func (v *NativeView) ObjectName() string                                     { return "" }
-func (v *NativeView) Hierarchy() string                                      { return "" }
-func (v *NativeView) SetLocalRect(rect zgeo.Rect)                            {}
-func (v *NativeView) SetObjectName(name string)                              {}
-func (v *NativeView) SetColor(c zgeo.Color)                                  {}
-func (v *NativeView) SetAlpha(alpha float32)                                 {}
-func (v *NativeView) SetBGColor(c zgeo.Color)                                {}
-func (v *NativeView) SetCorner(radius float64)                               {}
-func (v *NativeView) SetCorners(radius float64, align zgeo.Alignment)        {}
-func (v *NativeView) SetStroke(width float64, c zgeo.Color, inset bool)      {}
+func (v *NativeView) Alpha() float32 {
+	return 1 - v.transparency
+}
+
+func (v *NativeView) SetBGColor(c zgeo.Color) {
+	v.bgColor = c
+}
+
+func (v *NativeView) BGColor() zgeo.Color {
+	if !v.bgColor.Valid {
+		return zgeo.ColorClear
+	}
+	return v.bgColor
+}
+
+func (v *NativeView) SetStyleForAllPlatforms(key, value string) {
+	v.SetJSStyle(key, value)
+}
+
+func (v *NativeView) SetSelectable(on bool) {
+	val := "none"
+	if on {
+		val = "all"
+	}
+	v.SetJSStyle("user-select", val)
+}
+
+func (v *NativeView) SetCorner(radius float64) {
+	v.corner = radius
+	v.cornerAlign = zgeo.TopLeft | zgeo.TopRight | zgeo.BottomLeft | zgeo.BottomRight
+}
+
+func (v *NativeView) SetCorners(radius float64, cAlignment ...zgeo.Alignment) {
+	v.corner = radius
+	v.cornerAlign = 0
+	for _, a := range cAlignment {
+		v.cornerAlign |= a
+	}
+}
+
+func (v *NativeView) Corner() float64 {
+	return v.corner
+}
+
+// Corners returns the radius and which corners SetCorner/SetCorners rounded.
+func (v *NativeView) Corners() (radius float64, align zgeo.Alignment) {
+	return v.corner, v.cornerAlign
+}
+
+func (v *NativeView) SetStroke(width float64, c zgeo.Color, inset bool) {
+	v.stroke = HeadlessStroke{Width: width, Color: c, Inset: inset}
+}
+
+func (v *NativeView) Stroke() HeadlessStroke {
+	return v.stroke
+}
+
+func (v *NativeView) SetStrokeSide(width float64, c zgeo.Color, a zgeo.Alignment, inset bool) {
+	v.SetStroke(width, c, inset)
+}
+
+func (v *NativeView) SetOutline(width float64, c zgeo.Color, offset float64) {}
 func (v *NativeView) Scale(scale float64)                                    {}
 func (v *NativeView) Rotate(deg float64)                                     {}
-func (v *NativeView) Focus(focus bool)                                       {}
-func (v *NativeView) SetCanTabFocus(can bool)                                {}
-func (v *NativeView) CanTabFocus() bool                                      { return false }
-func (v *NativeView) SetOpaque(opaque bool)                                  {}
-func (v *NativeView) DumpTree()                                              {}
-func (v *NativeView) SetFont(font *zgeo.Font)                                {}
-func (v *NativeView) Color() zgeo.Color                                      { return zgeo.Color{} }
-func (v *NativeView) BGColor() zgeo.Color                                    { return zgeo.Color{} }
-func (v *NativeView) SetCursor(cursor zcursor.Type)                          {}
-func (v *NativeView) Alpha() float32                                         { return 1 }
-func (v *NativeView) GetScale() float64                                      { return 1 }
-func (v *NativeView) Show(show bool)                                         {}
-func (v *NativeView) IsShown() bool                                          { return true }
-func (v *NativeView) SetUsable(usable bool)                                  {}
-func (v *NativeView) Usable() bool                                           { return true }
-func (v *NativeView) SetInteractive(interactive bool)                        {}
-func (v *NativeView) Interactive() bool                                      { return true }
-func (v *NativeView) IsFocused() bool                                        { return true }
-func (v *NativeView) GetChild(path string) *NativeView                       { return nil }
-func (v *NativeView) RemoveFromParent()                                      {}
-func (v *NativeView) Font() *zgeo.Font                                       { return nil }
-func (v *NativeView) SetText(text string)                                    {}
-func (v *NativeView) Text() string                                           { return "" }
-func (v *NativeView) InsertBefore(before View)                               {}
-func (v *NativeView) AddChild(child View, index int)                         {}
-func (v *NativeView) RemoveChild(child View)                                 {}
-func (v *NativeView) SetDropShadow(shadow zstyle.DropShadow)                 {}
-func (v *NativeView) SetToolTip(str string)                                  {}
-func (v *NativeView) SetChildrenAboveParent(above bool)                      {}
-func (v *NativeView) SetScrollHandler(handler func(pos zgeo.Pos))            {}
-func (v *NativeView) JSSet(property string, value interface{})               {}
-func (v *NativeView) JSGet(property string) any                              { return nil }
-func (v *NativeView) JSCall(method string, args ...interface{}) any          { return nil }
-func (v *NativeView) SetDraggable(getData func() (data string, mime string)) {}
-func (v *NativeView) SetUploader(mimesOrExtensions []string, got func(data []byte, name string), use func(name string) bool, progress func(p float64)) {
-}
-func (v *NativeView) ReplaceChild(child, with View) error                             { return nil }
-func (v *NativeView) AllParents() (all []*NativeView)                                 { return }
-func (v *NativeView) GetFocusedChildView(andSelf bool) View                           { return nil }
-func (v *NativeView) GetPathOfChild(child View) string                                { return "" }
-func (v *NativeView) AbsoluteRect() zgeo.Rect                                         { return zgeo.Rect{} }
-func (v *NativeView) SetOnInputHandler(handler func())                                {}
-func (v *NativeView) SetKeyHandler(handler func(km zkeyboard.KeyMod, down bool) bool) {}
-func (v *NativeView) SetRect(rect zgeo.Rect)                                          {}
-
-// func (v *NativeView) SetStyle(kSetKeyHandlerey, value string)                                              {}
-func (v *NativeView) SetZIndex(index int)                                                     {}
-func (v *NativeView) SetSwipeHandler(handler func(pos, dir zgeo.Pos))                         {}
-func (v *NativeView) SetOnPointerMoved(handler func(pos zgeo.Pos))                            {}
-func (v *NativeView) SetHandleExposed(handle func(intersects bool))                           {}
-func (v *NativeView) MakeLink(surl, name string)                                              {}
-func (v *NativeView) SetStrokeSide(width float64, c zgeo.Color, a zgeo.Alignment, inset bool) {}
-func (v *NativeView) HasSize() bool                                                           { return false }
+func (v *NativeView) RotateDeg(deg float64)                                  {}
 
-func (v *NativeView) SetPointerDropHandler(handler func(dtype DragType, data []byte, name string, pos zgeo.Pos) bool) {
+func (v *NativeView) GetScale() float64 {
+	return 1
+}
+
+func (v *NativeView) Show(show bool) {
+	v.hidden = !show
+}
+
+func (v *NativeView) IsShown() bool {
+	return !v.hidden
+}
+
+func (v *NativeView) IsUsable() bool {
+	return !v.disabled
+}
+
+func (v *NativeView) SetUsable(usable bool) bool {
+	if usable == v.IsUsable() {
+		return false
+	}
+	zbits.ChangeBits(&v.Flags, ViewUsableFlag, usable)
+	v.disabled = !usable
+	for _, c := range v.allChildren() {
+		c.disabled = !usable
+	}
+	return true
+}
+
+func (v *NativeView) SetInteractive(interactive bool) {
+	v.nonInteractive = !interactive
+}
+
+func (v *NativeView) IsInteractive() bool {
+	return !v.nonInteractive
+}
+
+func (v *NativeView) IsFocused() bool {
+	return focusedView == v
+}
+
+func (v *NativeView) Focus(focus bool) {
+	old := focusedView
+	if focus {
+		if old == v {
+			return
+		}
+		focusedView = v
+	} else {
+		if old != v {
+			return
+		}
+		focusedView = nil
+	}
+	if old != nil && old != focusedView {
+		old.callFocusHandlers(false)
+	}
+	if focusedView != nil {
+		focusedView.callFocusHandlers(true)
+	}
+}
+
+func (v *NativeView) callFocusHandlers(focused bool) {
+	if v.focusHandler != nil {
+		v.focusHandler(focused)
+	}
+	for _, p := range v.AllParents() {
+		if p.focusInChildren != nil {
+			p.focusInChildren(v.View, focused)
+		}
+	}
+}
+
+func (v *NativeView) CanTabFocus() bool {
+	return v.canTabFocus
+}
+
+func (v *NativeView) SetKeepFocusOnOutsideClick() {}
+
+func (v *NativeView) SetCanTabFocus(can bool) {
+	v.canTabFocus = can
+}
+
+func (v *NativeView) SetFocusHandler(focused func(focus bool)) {
+	v.focusHandler = focused
+}
+
+func (root *NativeView) HandleFocusInChildren(in, out bool, handle func(view View, focused bool)) {
+	if !in && !out {
+		return
+	}
+	root.focusInChildren = func(view View, focused bool) {
+		if (focused && in) || (!focused && out) {
+			handle(view, focused)
+		}
+	}
+}
+
+func (root *NativeView) GetFocusedChildView(andSelf bool) View {
+	if focusedView == nil {
+		return nil
+	}
+	if andSelf && root.IsFocused() {
+		return root
+	}
+	if root.IsParentOf(focusedView) {
+		return focusedView.View
+	}
+	return nil
+}
+
+func (v *NativeView) SetOpaque(opaque bool) {}
+
+func (v *NativeView) Hierarchy() string {
+	if v == nil {
+		return "nil"
+	}
+	return v.HierarchyToRoot(nil)
+}
+
+func (v *NativeView) HierarchyToRoot(root *NativeView) string {
+	var str string
+	var found, added bool
+	if root == nil {
+		found = true
+		str = "/"
+	}
+	for _, p := range v.AllParents() {
+		if !found {
+			found = (root == p)
+		}
+		if found {
+			if added {
+				str += "/"
+			}
+			str += p.ObjectName()
+			added = true
+		}
+	}
+	str += "/" + v.ObjectName()
+	return str
+}
+
+// DumpTree returns an indented list of v and all its native children, with their rects.
+func (v *NativeView) DumpTree() string {
+	var lines []string
+	v.dumpTree(&lines, "")
+	return strings.Join(lines, "\n")
 }
+
+func (v *NativeView) dumpTree(lines *[]string, indent string) {
+	line := zstr.Spaced(indent+v.ObjectName(), v.kind, v.rect)
+	if v.hidden {
+		line += " hidden"
+	}
+	if v.disabled {
+		line += " disabled"
+	}
+	*lines = append(*lines, line)
+	for _, c := range v.children {
+		c.dumpTree(lines, indent+"  ")
+	}
+}
+
+func (v *NativeView) RemoveFromParent(callRemoveFuncs bool) {
+	if v.parent == nil {
+		zlog.Error("RemoveFromParent with no parent:", v.Hierarchy())
+		return
+	}
+	v.parent.RemoveChild(v.View, callRemoveFuncs)
+	v.parent = nil
+}
+
+func (v *NativeView) SetTextUnderline(under bool) {
+	v.underlined = under
+}
+
+func (v *NativeView) IsTextUnderlined() bool {
+	return v.underlined
+}
+
+func (v *NativeView) SetFont(font *zgeo.Font) {
+	v.font = font
+}
+
+func (v *NativeView) Font() *zgeo.Font {
+	if v.font == nil {
+		return zgeo.FontDefault(0)
+	}
+	return v.font
+}
+
+func (v *NativeView) SetInnerText(text string) {
+	v.text = text
+}
+
+func (v *NativeView) InnerText() string {
+	return v.text
+}
+
+func (v *NativeView) InsertBefore(before View) {
+	p := before.Native().parent
+	if p == nil {
+		return
+	}
+	if v.parent != nil {
+		v.parent.removeNativeChild(v)
+	}
+	v.parent = p
+	p.insertNativeChild(v, before.Native())
+}
+
+func (v *NativeView) insertNativeChild(n, before *NativeView) {
+	if before != nil {
+		for i, c := range v.children {
+			if c == before {
+				v.children = append(v.children[:i], append([]*NativeView{n}, v.children[i:]...)...)
+				return
+			}
+		}
+	}
+	v.children = append(v.children, n)
+}
+
+func (v *NativeView) removeNativeChild(n *NativeView) bool {
+	for i, c := range v.children {
+		if c == n {
+			v.children = append(v.children[:i], v.children[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (v *NativeView) AddChild(child, before View) {
+	n := child.Native()
+	if n == nil {
+		zlog.Fatal("NativeView AddChild child not native", v.Hierarchy(), child.ObjectName())
+	}
+	if n.parent != nil {
+		n.parent.removeNativeChild(n)
+	}
+	n.parent = v
+	n.PerformAddRemoveFuncs(true)
+	var b *NativeView
+	if before != nil {
+		b = before.Native()
+	}
+	v.insertNativeChild(n, b)
+	if v.IsPresented() && SetPresentReadyFunc != nil {
+		SetPresentReadyFunc(child, true)
+		SetPresentReadyFunc(child, false)
+	}
+}
+
+func (v *NativeView) GetPathOfChild(child View) string {
+	path := child.Native().HierarchyToRoot(v)
+	if path == "" {
+		return path
+	}
+	zstr.HeadUntilWithRest(path, "/", &path) // remove first path component, which is v's
+	return path
+}
+
+func (v *NativeView) ReplaceChild(child, with View) {
+	var focusedPath string
+	focused := v.GetFocusedChildView(false)
+	if focused != nil {
+		focusedPath = child.Native().GetPathOfChild(focused)
+	}
+	v.AddChild(with, child)
+	with.SetRect(child.Rect())
+	v.RemoveChild(child, true)
+	if focusedPath != "" {
+		f := ChildOfViewFunc(with, focusedPath)
+		if f != nil {
+			f.Native().Focus(true)
+		}
+	}
+	ExposeView(with)
+}
+
+func (v *NativeView) AllParents() (all []*NativeView) {
+	for v.parent != nil {
+		all = append([]*NativeView{v.parent}, all...)
+		v = v.parent
+	}
+	return
+}
+
+func (v *NativeView) allChildren() (all []*NativeView) {
+	for _, c := range v.children {
+		all = append(all, c)
+		all = append(all, c.allChildren()...)
+	}
+	return all
+}
+
+func (v *NativeView) SetZIndex(index int) {
+	v.zIndex = index
+}
+
+func (v *NativeView) ZIndex() int {
+	return v.zIndex
+}
+
+func (v *NativeView) RemoveChild(child View, callRemoveFuncs bool) {
+	nv := child.Native()
+	if nv == nil {
+		panic("NativeView RemoveChild child not native")
+	}
+	if callRemoveFuncs {
+		nv.PerformAddRemoveFuncs(false)
+	}
+	if focusedView != nil && (focusedView == nv || nv.IsParentOf(focusedView)) {
+		focusedView = nil
+	}
+	v.removeNativeChild(nv)
+	// nv.parent is kept, like in js, so a collapsed child can still be uncollapsed in its container.
+}
+
+func (v *NativeView) SetDropShadow(shadow ...zgeo.DropShadow) {
+	v.dropShadows = shadow
+}
+
+func (v *NativeView) DropShadows() []zgeo.DropShadow {
+	return v.dropShadows
+}
+
+func (v *NativeView) SetToolTip(str string) {
+	tta, _ := v.View.(ToolTipAdder)
+	if tta != nil {
+		str += tta.GetToolTipAddition()
+	}
+	v.toolTip = str
+}
+
+func (v *NativeView) ToolTip() string {
+	return v.toolTip
+}
+
+func (v *NativeView) AbsoluteRect() zgeo.Rect {
+	r := v.rect
+	for p := v.parent; p != nil; p = p.parent {
+		r.Pos.Add(p.rect.Pos)
+		r.Pos.Subtract(p.contentOffset)
+	}
+	return r
+}
+
+func (v *NativeView) AbsoluteRectWithParentOffset() zgeo.Rect {
+	r := v.AbsoluteRect()
+	if v.parent != nil {
+		r.Pos.Add(v.parent.contentOffset)
+	}
+	return r
+}
+
+func makeDownPressKey(id string, long bool, mods zkeyboard.Modifier) string {
+	if id == "" {
+		id = "$general"
+	}
+	if long {
+		id += ".$long"
+	} else {
+		id += ".$short"
+	}
+	return fmt.Sprintf("%s^%s", id, mods)
+}
+
+func (v *NativeView) DoMouseDown(id string, mods zkeyboard.Modifier) {
+	f := v.downHandlers[makeDownPressKey(id+"$down", false, mods)]
+	if f != nil {
+		f()
+	}
+}
+
+// ClickAll calls all press handlers set on v, as a synthetic click would in js.
+func (v *NativeView) ClickAll() {
+	for _, f := range v.pressHandlers {
+		f()
+	}
+}
+
+func (v *NativeView) Click(id string, long bool, mods zkeyboard.Modifier) {
+	f := v.pressHandlers[makeDownPressKey(id, long, mods)]
+	if f != nil {
+		f()
+	}
+}
+
+func (v *NativeView) SetPressedHandler(id string, mods zkeyboard.Modifier, handler func()) {
+	v.setPressHandler(makeDownPressKey(id, false, mods), handler)
+}
+
+func (v *NativeView) SetLongPressedHandler(id string, mods zkeyboard.Modifier, handler func()) {
+	v.setPressHandler(makeDownPressKey(id, true, mods), handler)
+}
+
+func (v *NativeView) setPressHandler(key string, handler func()) {
+	if handler == nil {
+		delete(v.pressHandlers, key)
+		return
+	}
+	if v.pressHandlers == nil {
+		v.pressHandlers = map[string]func(){}
+	}
+	v.pressHandlers[key] = func() {
+		if v.IsUsable() {
+			handler()
+		}
+	}
+}
+
+func (v *NativeView) CallPressHandlers() {}
+
+func (v *NativeView) HasPressedDownHandler() bool {
+	return len(v.downHandlers) != 0
+}
+
+func (v *NativeView) SetPressedDownHandler(id string, mods zkeyboard.Modifier, handler func() bool) {
+	key := makeDownPressKey(id+"$down", false, mods)
+	if handler == nil {
+		delete(v.downHandlers, key)
+		return
+	}
+	if v.downHandlers == nil {
+		v.downHandlers = map[string]func() bool{}
+	}
+	v.downHandlers[key] = handler
+}
+
+func (v *NativeView) SetDoublePressedHandler(handler func()) {
+	v.doublePressed = handler
+}
+
+// DoublePress calls the handler set with SetDoublePressedHandler.
+func (v *NativeView) DoublePress() {
+	if v.doublePressed != nil {
+		v.doublePressed()
+	}
+}
+
+func (v *NativeView) SetKeyHandler(handler func(km zkeyboard.KeyMod, down bool) bool) {
+	v.keyHandler = handler
+}
+
+// SendKey calls v's key handler as if km was pressed down and released while v was focused.
+// It returns true if the down press was handled.
+func (v *NativeView) SendKey(km zkeyboard.KeyMod) bool {
+	if v.keyHandler == nil {
+		return false
+	}
+	zkeyboard.CurrentKeyDown = km
+	handled := v.keyHandler(km, true)
+	zkeyboard.CurrentKeyDown = zkeyboard.KeyMod{}
+	v.keyHandler(km, false)
+	return handled
+}
+
+func (v *NativeView) SetOnInputHandler(handler func()) {
+	v.onInputHandler = handler
+}
+
+// Input calls the handler set with SetOnInputHandler, as typing in a text field would.
+func (v *NativeView) Input() {
+	if v.onInputHandler != nil {
+		v.onInputHandler()
+	}
+}
+
+func (v *NativeView) SetChildrenAboveParent(above bool) {
+	str := "hidden"
+	if above {
+		str = "visible"
+	}
+	v.SetJSStyle("overflow", str)
+}
+
+// JSCall does nothing headless, and returns nil.
+func (v *NativeView) JSCall(method string, args ...any) any {
+	return nil
+}
+
+// JSSet stores a property, which can be read back with JSGet.
+func (v *NativeView) JSSet(property string, value any) {
+	if v.properties == nil {
+		v.properties = map[string]any{}
+	}
+	v.properties[property] = value
+}
+
+func (v *NativeView) JSGet(property string) any {
+	return v.properties[property]
+}
+
+func (v *NativeView) SetScrollHandler(handler func(pos zgeo.Pos)) {
+	v.scrollHandler = handler
+}
+
+func (v *NativeView) ContentOffset() zgeo.Pos {
+	return v.contentOffset
+}
+
+func (v *NativeView) setContentOffset(pos zgeo.Pos) {
+	v.contentOffset = pos
+	if v.scrollHandler != nil {
+		v.scrollHandler(pos)
+	}
+}
+
+func (v *NativeView) SetXContentOffsetAnimated(x float64, done func()) {
+	v.SetXContentOffset(x)
+	if done != nil {
+		done()
+	}
+}
+
+func (v *NativeView) SetYContentOffsetAnimated(y float64, done func()) {
+	v.SetYContentOffset(y)
+	if done != nil {
+		done()
+	}
+}
+
+func (v *NativeView) SetXContentOffset(x float64) {
+	v.setContentOffset(zgeo.PosD(x, v.contentOffset.Y))
+}
+
+func (v *NativeView) SetYContentOffset(y float64) {
+	v.setContentOffset(zgeo.PosD(v.contentOffset.X, y))
+}
+
+func (v *NativeView) SetRootYContentOffset(y float64) {
+	toModalWindowOnly := true
+	v.RootParent(toModalWindowOnly).SetYContentOffset(y)
+}
+
+func (v *NativeView) ShowScrollBars(x, y bool) {}
+
+func (v *NativeView) SetHandleExposed(handle func(intersectsViewport bool)) {
+	v.exposedHandler = handle
+	handle(true) // headless views are always considered visible
+	v.AddOnRemoveFunc(func() {
+		handle(false)
+	})
+}
+
+func (v *NativeView) MakeLink(surl, name string) {
+	v.JSSet("download", name)
+	v.JSSet("href", surl)
+}
+
+func (v *NativeView) SetTilePath(spath string, size zgeo.Size) {
+	v.SetJSStyle("background-image", spath)
+}
+
+func (nv *NativeView) SetNativePadding(p zgeo.Rect) {}
+func (nv *NativeView) SetNativeMargin(m zgeo.Rect)  {}
+func (nv *NativeView) ShowBackface(visible bool)    {}
+func (nv *NativeView) EnvokeFocusIn()               {}
+
+func (v *NativeView) RootParent(toModalWindowOnly bool) *NativeView {
+	all := v.AllParents()
+	if len(all) == 0 {
+		return v
+	}
+	i := 0
+	if all[i].ObjectName() == "window" {
+		i++
+	}
+	if len(all) > i && toModalWindowOnly && all[i].ObjectName() == "$blocker" {
+		i++
+	}
+	if i >= len(all) {
+		return v
+	}
+	return all[i]
+}
+
+func (v *NativeView) SetSwipeHandler(handler func(pos, dir zgeo.Pos))        {}
+func (v *NativeView) SetDraggable(getData func() (data string, mime string)) {}
 func (v *NativeView) SetPointerEnterHandler(moves bool, handler func(pos zgeo.Pos, inside zbool.BoolInd)) {
 }
 func (v *NativeView) SetPressUpDownMovedHandler(handler func(pos zgeo.Pos, down zbool.BoolInd) bool) {
 }
-func (v *NativeView) SetPressedDownHandler(handler func())                                   {}
-func (v *NativeView) SetDoublePressedHandler(handler func())                                 {}
-func (v *NativeView) HasPressedDownHandler() bool                                            { return false }
-func (v *NativeView) Native() *NativeView                                                    { return v }
-func (v *NativeView) SetStyling(style zstyle.Styling)                                        {}
-func (v *NativeView) SetSelectable(on bool)                                                  {}
-func (v *NativeView) SetJSStyle(key, value string)                                           {}
-func (v *NativeView) SetOutline(width float64, c zgeo.Color, offset float64)                 {}
-func (nv *NativeView) SetNativePadding(m zgeo.Rect)                                          {}
-func (nv *NativeView) SetNativeMargin(m zgeo.Rect)                                           {}
-func (nv *NativeView) ShowBackface(visible bool)                                             {}
-func (v *NativeView) SetTilePath(spath string)                                               {}
-func (v *NativeView) Click()                                                                 {}
-func (v *NativeView) RootParent() *NativeView                                                { return nil }
-func (v *NativeView) SetFocusHandler(focused func(focus bool))                               {}
-func (*NativeView) HandleFocusInChildren(in, out bool, handle func(view View, focused bool)) {}
+func (v *NativeView) SetStateOnPress(event any) {}
+func (v *NativeView) ClearStateOnUpPress()      {}
 
-func DownloadURI(uri, name string) {}
+func (v *NativeView) SetPointerDropHandler(handler func(dtype DragType, data []byte, name string, pos zgeo.Pos) bool) {
+}
 
-func (v *NativeView) CalculatedSize(total zgeo.Size) (s, max zgeo.Size) {
-	return zgeo.SizeD(10, 10), zgeo.Size{}
+func (v *NativeView) SetUploader(mimesOrExtensions []string, got func(data []byte, name string), skip func(name string) bool, progress func(p float64)) {
 }
+
+func StopPropagationOfLastPressedEvent() {}
+
+func DownloadURI(uri, name string) {}
@@ -13,5 +13,12 @@ func (v *WebView) init(minSize zgeo.Size, isFrame bool) {
 	v.minSize = minSize
 }
 
-func (v *WebView) SetURL(surl string)         {}
-func (v *WebView) SetHTMLContent(html string) {}
+func (v *WebView) SetURL(surl string)                     {}
+func (v *WebView) SetHTMLContent(html string)             {}
+func (v *WebView) SetCookies(cookieMap map[string]string) {}
+
+func (v *WebView) updateWidgets() {
+	if v.Back != nil {
+		v.Back.SetUsable(len(v.History) > 1)
+	}
+}
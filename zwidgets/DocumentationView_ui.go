@@ -31,6 +31,7 @@ type DocumentationIconView struct {
 	zimageview.XImageView
 	docPath string
 	Modal   bool
+	removed bool // removed is set while the view isn't in the GUI, so its documentation isn't in zdocs.MainIndex
 }
 
 var (
@@ -55,6 +56,15 @@ func DocumentationIconViewNew(docPath string) *DocumentationIconView {
 		// }, nil)
 		DocumentationViewPresent(docPath, v.Modal) // go
 	})
+	// The documentation is added to zdocs.MainIndex when fetched, so is removed here, and added back if re-added.
+	v.AddOnRemoveFunc(func() {
+		v.removed = true
+		zdocs.MainIndex.Remove(cachedSearchableItems[v.docPath]...)
+	})
+	v.AddOnAddFunc(func() {
+		v.removed = false
+		zdocs.MainIndex.Add(cachedSearchableItems[v.docPath]...)
+	})
 	return v
 }
 
@@ -228,6 +238,9 @@ func (v *DocumentationIconView) getMarkdownAsSearchableItems(currentPath []zdocs
 	})
 	addItem(docPath, title, &items, &start, &lines)
 	cachedSearchableItems[v.docPath] = items
+	if !v.removed {
+		zdocs.MainIndex.Add(items...) // it was fetched after the view was indexed, so is added now
+	}
 	// zlog.Info("DocumentationIconView.getMarkdownAsSearchableItems", v.docPath, len(items))
 }
 
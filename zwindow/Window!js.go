@@ -53,3 +53,16 @@ func FromNativeView(v *zview.NativeView) *Window { return nil }
 func Current() *Window                           { return nil }
 func (win *Window) AddStyle()                    {}
 func (w *Window) Reload()                        {}
+
+// ScrollBarSize is 0 headless, where nothing scrolls with bars.
+func (w *Window) ScrollBarSize() float64 { return 0 }
+
+func ScrollBarSizeForView(view zview.View) float64 { return 0 }
+
+func (win *Window) AddKeyPressHandler(view zview.View, km zkeyboard.KeyMod, down bool, handler func() bool) (id int64) {
+	return 0
+}
+
+func (win *Window) AddFocusHandler(view zview.View, focus bool, handler func()) (id int64) {
+	return 0
+}
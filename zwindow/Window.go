@@ -84,6 +84,15 @@ func FindWithID(winID string) *Window {
 	return nil
 }
 
+// All returns the open windows, in no particular order.
+func All() []*Window {
+	all := make([]*Window, 0, len(windows))
+	for w := range windows {
+		all = append(all, w)
+	}
+	return all
+}
+
 func setValues(v url.Values, add url.Values) {
 	for k, ss := range add {
 		for _, s := range ss {